@@ -4,6 +4,7 @@ go 1.22.2
 
 require (
 	github.com/go-echarts/go-echarts/v2 v2.4.1
+	golang.org/x/net v0.21.0
 	golang.org/x/text v0.14.0
 	gopkg.in/ini.v1 v1.67.0
 )
@@ -5,25 +5,57 @@ package main
 
 import (
 	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/csv"
 	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"flag"
 	"fmt"
+	"golang.org/x/net/idna"
+	"golang.org/x/net/publicsuffix"
 	"gopkg.in/ini.v1"
+	"html"
 	"io"
 	"log"
 	"math/rand"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
+	"path/filepath"
 	"regexp"
 	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"text/template"
 	"time"
+	"unicode"
 )
 
 // Version
-var version = "v0.2"
+var version = "v0.3"
+
+// Changelog v0.3
+// Subdomains segment replaced with host, protocol, www vs. apex & registrable domain segments
+// URL hygiene segment (duplicate & malformed URL variants)
+// Static resources segment replaced with a data-driven file type segment (sl_file_type)
+// Static segments moved to user-defined templates (templates folder), with conditional inclusion rules
+// Segments built as an in-memory model. Exported as Botify DSL, JSON, YAML, CSV (URL counts) & Markdown
+// Segments converted to GA4 content groups, Search Console filters, Screaming Frog segments & Python regex mapping
+// Apply the generated segmentation to the Botify project via the API, after reviewing the changes. Merged with the project, disabled unless allowPush is set
+// Merge the generated segments with the project's existing segmentation (fetched, pasted or uploaded). Stale sl_ segments kept unless removal is confirmed
+// Segmentation history per organisation/project, with the differences between runs
+// Choose the analysis & compare two analyses. Crawl delta segment (new, removed & persistent URLs, warns when an export is capped) & folder deltas (new, removed & persistent URLs)
+// Botify URL filters (compliant, HTTP 200, indexable, max. depth) chosen in the form or on the command line. Shown in the header
+// robots.txt segment (Googlebot, longest match) & report of the rules blocking the most crawled URLs
+// Sitemap presence segment. URLs crawled not in sitemap, in sitemap not crawled (orphan candidates) & in sitemap and crawled. Counts by folder
+// Human-readable folder labels (decoded, title-cased, truncated & unique), with an optional label mapping file
+// Deterministic output. Equal counts sorted by text, option to omit the generation timestamp
 
 // Changelog v0.2
 // UI updates & refinements (segmentifyLite)
@@ -36,6 +68,36 @@ var version = "v0.2"
 // Delete temp file when regex generation is complete
 // Identify PDP pages and generate segment (experimental)
 
+// Botify API URL. Can be pointed to a local stand-in of the API with the envBotifyAPIURL environment variable
+var botifyAPIURL = "https://api.botify.com"
+
+// Project segmentation endpoint. GET returns the current segmentation, PUT replaces it
+// The endpoint & payload are not covered by the public Botify API reference (https://developers.botify.com/reference),
+// pushing is disabled unless allowPush is set in segmentifyLite.ini
+var segmentationEndpoint = "/v1/projects/%s/%s/segmentation"
+
+// Allow the generated segmentation to be pushed to the Botify project. Set in segmentifyLite.ini (allowPush)
+var allowPush bool
+
+// Segmentation document exchanged with the project segmentation endpoint
+type botifySegmentation struct {
+	Segmentation string `json:"segmentation"`
+}
+
+// Runs which can be applied to the Botify project, keyed by session ID
+type generatedRun struct {
+	organisation string
+	project      string
+	cacheFolder  string
+	generated    time.Time
+}
+
+var generatedRuns = make(map[string]generatedRun)
+
+// Runs are kept for generatedRunTTL, up to maxGeneratedRuns runs. The oldest runs are removed first
+var generatedRunTTL = 24 * time.Hour
+var maxGeneratedRuns = 100
+
 // Token, log folder and cache folder acquired from environment variables
 var envBotifyAPIToken string
 var envSegmentifyLiteLogFolder string
@@ -53,6 +115,16 @@ var clearScreen = "\033[H\033[2J"
 
 // Default input and output files
 var urlExtractFile = "siteurlsExport.tmp"
+
+// Analysis to compare with, chosen in the form. Its URLs are exported to the comparison file
+var compareSlug string
+var urlCompareFile = "siteurlsCompare.tmp"
+
+// Folder level deltas between the two analyses, saved to the cache folder
+var crawlDeltaFile = "crawlDelta.csv"
+
+// Maximum number of new or removed URLs listed in the crawl delta segment
+var crawlDeltaMaxListedURLs = 100
 var regexOutputFile = "segment.txt"
 
 // Maximum No. of URLs to process
@@ -91,10 +163,69 @@ var cacheFolderRoot string
 var sessionIDCounter int
 var sessionID []byte
 
+// File type families used to generate the file type segment
+// The defaults can be overridden in the [fileTypes] section of segmentifyLite.ini
+type fileTypeFamily struct {
+	Name       string
+	Extensions []string
+}
+
+var fileTypeFamilies = []fileTypeFamily{
+	{"APIs", []string{"json", "jsonld", "graphql"}},
+	{"Images", []string{"avif", "bmp", "gif", "ico", "jpeg", "jpg", "png", "svg", "tif", "tiff", "webp"}},
+	{"Scripts", []string{"js", "mjs", "map", "wasm"}},
+	{"Styles", []string{"css"}},
+	{"Fonts", []string{"eot", "otf", "ttf", "woff", "woff2"}},
+	{"Documents", []string{"csv", "doc", "docx", "odt", "pdf", "ppt", "pptx", "rtf", "tsv", "txt", "xls", "xlsx"}},
+	{"Media", []string{"avi", "m4a", "mov", "mp3", "mp4", "mpeg", "mpg", "ogg", "wav", "webm"}},
+	{"Feeds", []string{"atom", "rss", "xml"}},
+}
+
+// Paths used to identify API URLs which have no file extension
+var apiPaths = []string{"/api/", "/graphql", "/wp-json/"}
+
+// Maximum No. of extensions not found in the dictionary to include in the file type segment
+var maxOtherFileExtensions = 20
+
+// Extensions used by HTML pages, excluded from the file type segment
+var pageExtensions = []string{"asp", "aspx", "cfm", "do", "htm", "html", "jsp", "php", "shtml"}
+
+// Folder containing the segment templates
+var templatesFolder = "templates"
+
+// Values detected in the crawl, used by the segment templates. Hosts are kept in their ASCII form as found in the URLs
+var detectedHosts []string
+var topFolders []string
+var urlsScanned int
+
+// The analyses whose URL export was capped at maxURLsToProcess. Used to warn that the crawl delta is incomplete
+var cappedAnalyses []string
+var urlsWithParameters int
+
+// Analysis slug & folder thresholds used for the current run, saved to the segmentation history
+// The analysis slug is chosen in the form, otherwise the latest analysis is used
+var analysisSlug string
+var level1Threshold int
+var level2Threshold int
+
 // PDP Regex
 var generatePDPRegex bool
 var isProductURL bool
 
+// The segmentation model for the current run
+var segmentModel *segmentation
+
+// Omit the generation timestamp from the segment files. Set in segmentifyLite.ini (omitTimestamp), or for a run in the form
+var defaultOmitTimestamp bool
+var omitTimestamp bool
+
+// Export file names. Saved in the cache folder and downloadable from the result page
+var exportDSLFile = "segment.txt"
+var exportJSONFile = "segment.json"
+var exportYAMLFile = "segment.yaml"
+var exportCSVFile = "folderCounts.csv"
+var exportMarkdownFile = "segment.md"
+
 // Declare the mutex
 var mutex sync.Mutex
 
@@ -107,16 +238,22 @@ type botifyResponse struct {
 
 // FolderCount defines a struct to hold text value and its associated count
 type FolderCount struct {
-	Text  string
-	Count int
+	Text  string `json:"text"`
+	Count int    `json:"count"`
 }
 
 // ByCount implements a sorting interface for FolderCount slice
+// Highest count first. Equal counts are sorted by text so that the output is the same for every run on the same crawl
 type ByCount []FolderCount
 
-func (a ByCount) Len() int           { return len(a) }
-func (a ByCount) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
-func (a ByCount) Less(i, j int) bool { return a[i].Count > a[j].Count }
+func (a ByCount) Len() int      { return len(a) }
+func (a ByCount) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a ByCount) Less(i, j int) bool {
+	if a[i].Count != a[j].Count {
+		return a[i].Count > a[j].Count
+	}
+	return a[i].Text < a[j].Text
+}
 
 func main() {
 
@@ -134,7 +271,11 @@ func main() {
 		defer mutex.Unlock()
 
 		// Retrieve the form data from the request (org and username)
-		err := r.ParseForm()
+		// The form is multipart when an existing segmentation is uploaded
+		err := r.ParseMultipartForm(maxExistingSegmentationSize)
+		if errors.Is(err, http.ErrNotMultipart) {
+			err = r.ParseForm()
+		}
 		if err != nil {
 			fmt.Println(red+"Error. Cannot parse form:"+reset, err)
 			return
@@ -142,6 +283,24 @@ func main() {
 		organisation = r.Form.Get("organization")
 		project = r.Form.Get("project")
 
+		// Reset the values detected in the previous run
+		sfccDetected = false
+		shopifyDetected = false
+		generatePDPRegex = false
+		detectedHosts = nil
+		topFolders = nil
+		cappedAnalyses = nil
+		urlsScanned = 0
+		urlsWithParameters = 0
+		analysisSlug = strings.TrimSpace(r.Form.Get("analysisSlug"))
+		compareSlug = strings.TrimSpace(r.Form.Get("compareSlug"))
+		activeURLFilter = urlFilterFromForm(r)
+		omitTimestamp = defaultOmitTimestamp || r.Form.Get("omitTimestamp") != ""
+		robotsEvaluated = false
+		sitemapsCompared = false
+		level1Threshold = 0
+		level2Threshold = 0
+
 		// Generate a session ID used for grouping log entries
 		sessionID, err := generateSessionID(8)
 		if err != nil {
@@ -166,6 +325,14 @@ func main() {
 			return
 		}
 
+		// An analysis chosen in the form cannot be found
+		if dataStatus == "errorNoAnalysisFound" {
+			writeLog(sessionID, organisation, project, "No analysis found")
+			generateErrorPage("No analysis found. Check the analysis slugs and try again. (" + organisation + "/" + project + ": " + strings.Trim(analysisSlug+", "+compareSlug, ", ") + ")")
+			http.Redirect(w, r, cacheFolder+"/"+"go_seo_segmentifyLiteError.html", http.StatusFound)
+			return
+		}
+
 		// An error occurred in the process URLs function
 		if dataStatus == "errorProcessURLs" {
 			writeLog(sessionID, organisation, project, "No project found")
@@ -176,49 +343,84 @@ func main() {
 
 		writeLog(sessionID, organisation, project, "URLs acquired")
 
-		// Generate the output file to store the regex
-		generateRegexFile()
+		// Create the segmentation model used to store the generated segments
+		newSegmentModel()
 
 		//Level 1 and 2 folders
 		level1and2Folders()
 
-		// PDP pages. Only generate if PDP pages have been detected
-		if isProductURL {
-			insertPDPRegex()
-		}
-
-		//Subdomains
-		subDomains()
-
-		//Parameter keys
-		parameterKeys()
+		//Hosts, protocol, www vs. apex & registrable domain
+		hostNames()
 
-		//Parameter keys utilization
-		parameterUsage()
+		//Parameter keys. The same scan collects the URL hygiene issues
+		hygiene := parameterKeys()
 
-		//No. of parameter keys
-		noOfParameters()
-
-		//No. of folders
-		noOfFolders()
+		//URL hygiene
+		urlHygiene(hygiene)
 
 		// Salesforce Commerce Cloud if detected
 		if sfccDetected {
 			writeLog(sessionID, organisation, project, "SFCC detected")
-			sfccURLs()
+			fmt.Println(purple + "Salesforce Commerce Cloud (Demandware)" + reset)
 		}
 
 		// Shopify if detected
 		if shopifyDetected {
 			writeLog(sessionID, organisation, project, "Shopify detected")
-			shopifyURLs()
+			fmt.Println(purple + "Shopify" + reset)
+		}
+
+		//Segments generated from the templates folder. Parameter usage, No. of parameters, No. of folders, PDP, SFCC & Shopify
+		segmentTemplates()
+
+		//File types (static resources & APIs)
+		fileTypes()
+
+		//New & removed URLs compared with another analysis
+		crawlDelta()
+
+		//Allowed & disallowed URLs, evaluated against the robots.txt
+		robotsContent, robotsSource, err := robotsTxt(r)
+		if err != nil {
+			fmt.Println(red+"Error. Cannot get the robots.txt. The robots.txt segment is not generated:"+reset, err)
+			writeLog(sessionID, organisation, project, "robots.txt not available")
+		} else if robotsSource != "" {
+			robotsSegment(robotsContent, robotsSource)
+			robotsEvaluated = true
+		}
+
+		//Crawled URLs compared with the sitemaps
+		sitemapURLSet, sitemapSources, err := sitemapURLs(r)
+		if err != nil {
+			fmt.Println(red+"Error. Cannot read the sitemaps. The sitemap presence segment is not generated:"+reset, err)
+			writeLog(sessionID, organisation, project, "Sitemaps not available")
+		} else if len(sitemapSources) > 0 {
+			sitemapPresence(sitemapURLSet, sitemapSources)
+			sitemapsCompared = true
 		}
 
-		//Static resources
-		staticResources()
+		// Render the segmentation model to the export formats
+		exportSegmentation()
+
+		// Merge with the project's existing segmentation when requested
+		segmentMerge = nil
+		existingDSL, existingSource, err := existingSegmentation(r)
+		if err != nil {
+			fmt.Println(red+"Error. Cannot get the existing segmentation. The generated segments are not merged:"+reset, err)
+			writeLog(sessionID, organisation, project, "Existing segmentation not available")
+		} else if strings.TrimSpace(existingDSL) != "" {
+			mergeWithExisting(existingDSL, existingSource, r.Form.Get("dropStaleSegments") != "")
+			writeLog(sessionID, organisation, project, "Merged with the existing segmentation")
+		}
 
 		writeLog(sessionID, organisation, project, "Regex generated successfully")
 
+		// Save the run to the project's segmentation history
+		saveHistory(sessionID)
+
+		// Keep the run so that the segmentation can be applied to the project
+		keepGeneratedRun(sessionID, generatedRun{organisation, project, cacheFolder, time.Now()})
+
 		// Generate the HTML used to present the regex
 		generateSegmentationRegex(sessionID)
 
@@ -229,6 +431,12 @@ func main() {
 		http.Redirect(w, r, cacheFolder+"/go_seo_segmentifyLite.html", http.StatusFound)
 	})
 
+	// Apply the generated segmentation to the Botify project
+	http.HandleFunc("/apply", applySegmentationHandler)
+
+	// Segmentation history & differences between runs
+	http.HandleFunc("/history", historyHandler)
+
 	// Start the HTTP server
 	err := http.ListenAndServe(port, nil)
 	if err != nil {
@@ -241,7 +449,7 @@ func main() {
 func processURLs(sessionID string) string {
 
 	//Get the last analysis slug
-	url := fmt.Sprintf("https://api.botify.com/v1/analyses/%s/%s?page=1&only_success=true", organisation, project)
+	url := fmt.Sprintf(botifyAPIURL+"/v1/analyses/%s/%s?page=1&only_success=true", organisation, project)
 
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
@@ -287,34 +495,195 @@ func processURLs(sessionID string) string {
 	fmt.Println()
 	fmt.Printf(yellow + sessionID + purple + " Generating segmentation regex" + reset)
 	fmt.Printf("\n%s%s%s Organisation: %s, Project: %s\n", yellow, sessionID, reset, organisation, project)
+	if description := activeURLFilter.description(); description != "" {
+		fmt.Printf("%s%s%s URL filter: %s\n", yellow, sessionID, reset, description)
+	}
+
+	//Use the chosen analysis, otherwise the latest analysis
+	chosenSlug := analysisSlug != ""
+	if !chosenSlug {
+		analysisSlug = responseObject.Results[0].Slug
+		fmt.Println(yellow+sessionID+reset+" Latest analysis slug:", analysisSlug)
+	} else {
+		fmt.Println(yellow+sessionID+reset+" Analysis slug:", analysisSlug)
+	}
+
+	//Export the URLs of the analysis
+	dataStatus := exportAnalysisURLs(sessionID, analysisSlug, urlExtractFile, true)
+	if dataStatus == "errorNoProjectFound" && chosenSlug {
+		return "errorNoAnalysisFound"
+	}
+	if dataStatus != "success" {
+		return dataStatus
+	}
+
+	//Export the URLs of the analysis to compare with
+	if compareSlug != "" {
+		fmt.Println(yellow+sessionID+reset+" Comparison analysis slug:", compareSlug)
+		dataStatus = exportAnalysisURLs(sessionID, compareSlug, urlCompareFile, false)
+		if dataStatus == "errorNoProjectFound" {
+			return "errorNoAnalysisFound"
+		}
+	}
+
+	defer func() {
+		if err := res.Body.Close(); err != nil {
+			fmt.Println(red+"Error. processURLs. Closing (3):"+reset, err)
+		}
+	}()
+
+	return dataStatus
+}
+
+// Botify URL filters applied when exporting the URLs, so that the thresholds reflect the pages that matter
+// Chosen in the form. Filters set on the command line are always applied
+type urlFilter struct {
+	Compliant bool `json:"compliant,omitempty"`
+	HTTP200   bool `json:"http200,omitempty"`
+	Indexable bool `json:"indexable,omitempty"`
+	MaxDepth  int  `json:"maxDepth,omitempty"`
+}
+
+var defaultURLFilter urlFilter
+var activeURLFilter urlFilter
+
+// Botify fields used by the URL filters
+var compliantField = "compliant.is_compliant"
+var httpCodeField = "http_code"
+var indexableField = "indexable.is_indexable"
+var depthField = "depth"
+
+// Get the default URL filters from the command line
+func getURLFilterFlags() {
+
+	flag.BoolVar(&defaultURLFilter.Compliant, "compliant", false, "Only segment compliant URLs")
+	flag.BoolVar(&defaultURLFilter.HTTP200, "http200", false, "Only segment URLs returning HTTP 200")
+	flag.BoolVar(&defaultURLFilter.Indexable, "indexable", false, "Only segment indexable URLs")
+	flag.IntVar(&defaultURLFilter.MaxDepth, "maxDepth", 0, "Only segment URLs up to this depth (0 for all depths)")
+	flag.Parse()
+
+	if description := defaultURLFilter.description(); description != "" {
+		fmt.Println(yellow + "Default URL filter: " + description + reset)
+	}
+}
+
+// The URL filters chosen in the form, added to the filters set on the command line
+// The form's max. depth replaces the command line max. depth
+func urlFilterFromForm(r *http.Request) urlFilter {
+
+	filter := defaultURLFilter
+	filter.Compliant = filter.Compliant || r.Form.Get("compliant") != ""
+	filter.HTTP200 = filter.HTTP200 || r.Form.Get("http200") != ""
+	filter.Indexable = filter.Indexable || r.Form.Get("indexable") != ""
+
+	// The max. depth entered in the form overrides the default when greater than 0. Empty or 0 keeps the default
+	if maxDepth := strings.TrimSpace(r.Form.Get("maxDepth")); maxDepth != "" {
+		depth, err := strconv.Atoi(maxDepth)
+		switch {
+		case err != nil || depth < 0:
+			fmt.Println(red+"Error. urlFilterFromForm. Invalid max. depth, ignored:"+reset, maxDepth)
+		case depth > 0:
+			filter.MaxDepth = depth
+		}
+	}
+
+	return filter
+}
+
+// The Botify filter predicates
+func (filter urlFilter) predicates() []map[string]interface{} {
+
+	var predicates []map[string]interface{}
+	if filter.Compliant {
+		predicates = append(predicates, map[string]interface{}{"field": compliantField, "predicate": "eq", "value": true})
+	}
+	if filter.HTTP200 {
+		predicates = append(predicates, map[string]interface{}{"field": httpCodeField, "predicate": "eq", "value": 200})
+	}
+	if filter.Indexable {
+		predicates = append(predicates, map[string]interface{}{"field": indexableField, "predicate": "eq", "value": true})
+	}
+	if filter.MaxDepth > 0 {
+		predicates = append(predicates, map[string]interface{}{"field": depthField, "predicate": "lte", "value": filter.MaxDepth})
+	}
+
+	return predicates
+}
+
+// The body of the URL export request
+func (filter urlFilter) requestBody() (string, error) {
+
+	body := map[string]interface{}{"fields": []string{"url"}}
+
+	predicates := filter.predicates()
+	switch len(predicates) {
+	case 0:
+	case 1:
+		body["filters"] = predicates[0]
+	default:
+		body["filters"] = map[string]interface{}{"and": predicates}
+	}
+
+	content, err := json.Marshal(body)
+
+	return string(content), err
+}
+
+// The URL filters as text, used in the segment file header. Empty when no filter is applied
+func (filter urlFilter) description() string {
+
+	var filters []string
+	if filter.Compliant {
+		filters = append(filters, "compliant")
+	}
+	if filter.HTTP200 {
+		filters = append(filters, "HTTP 200")
+	}
+	if filter.Indexable {
+		filters = append(filters, "indexable")
+	}
+	if filter.MaxDepth > 0 {
+		filters = append(filters, fmt.Sprintf("depth <= %d", filter.MaxDepth))
+	}
+
+	return strings.Join(filters, ", ")
+}
+
+// Export the URLs of an analysis to a file
+// SFCC & Shopify are only detected in the analysis used to generate the segments
+func exportAnalysisURLs(sessionID string, slug string, fileName string, detectPlatforms bool) string {
 
 	//Create a file for writing
-	file, err := os.Create(urlExtractFile)
+	file, err := os.Create(fileName)
 	if err != nil {
-		fmt.Println(red+"\nError. processURLs. Cannot create file: "+reset, err)
+		fmt.Println(red+"\nError. exportAnalysisURLs. Cannot create file: "+reset, err)
 		return "errorProcessURLs"
 
 	}
 
 	defer func() {
 		if err := file.Close(); err != nil {
-			fmt.Println(red+"Error. processURLs. Closing (2):"+reset, err)
+			fmt.Println(red+"Error. exportAnalysisURLs. Closing (2):"+reset, err)
 		}
 	}()
 
 	//Initialize total count
 	totalCount := 0
-	fmt.Println(yellow+sessionID+reset+" Latest analysis slug:", responseObject.Results[0].Slug)
 
-	analysisSlug := responseObject.Results[0].Slug
+	//The request body includes the URL filters
+	requestBody, err := activeURLFilter.requestBody()
+	if err != nil {
+		fmt.Println(red+"\nError. exportAnalysisURLs. Cannot create the request body: "+reset, err)
+		return "errorProcessURLs"
+	}
 
 	//Iterate through pages 1 through to the maximum no of pages defined by maxURLsToProcess
 	//Each page returns 1000 URLs
 	for page := 1; page <= maxURLsToProcess; page++ {
 
-		url := fmt.Sprintf("https://api.botify.com/v1/analyses/%s/%s/%s/urls?area=current&page=%d&size=1000", organisation, project, analysisSlug, page)
+		url := fmt.Sprintf(botifyAPIURL+"/v1/analyses/%s/%s/%s/urls?area=current&page=%d&size=1000", organisation, project, slug, page)
 
-		payload := strings.NewReader("{\"fields\":[\"url\"]}")
+		payload := strings.NewReader(requestBody)
 
 		req, _ := http.NewRequest("POST", url, payload)
 		//bloo
@@ -324,21 +693,21 @@ func processURLs(sessionID string) string {
 
 		res, err := http.DefaultClient.Do(req)
 		if err != nil {
-			fmt.Println(red+"\nError. processURLs. Cannot connect to the API: "+reset, err)
+			fmt.Println(red+"\nError. exportAnalysisURLs. Cannot connect to the API: "+reset, err)
 			return "errorProcessURLs"
 		}
 
 		//Decode JSON response
 		var response map[string]interface{}
 		if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
-			fmt.Println(red+"\nError. processURLs. Cannot decode JSON: "+reset, err)
+			fmt.Println(red+"\nError. exportAnalysisURLs. Cannot decode JSON: "+reset, err)
 			return "errorProcessURLs"
 		}
 
 		//Extract URLs from the "results" key
 		results, ok := response["results"].([]interface{})
 		if !ok {
-			fmt.Println(red + "\nError. processURLs. Invalid credentials or no crawls found in the project (2)" + reset)
+			fmt.Println(red + "\nError. exportAnalysisURLs. Invalid credentials or no crawls found in the project (2)" + reset)
 			return "errorNoProjectFound"
 		}
 
@@ -348,15 +717,15 @@ func processURLs(sessionID string) string {
 			if resultMap, ok := result.(map[string]interface{}); ok {
 				if url, ok := resultMap["url"].(string); ok {
 					// Check if SFCC is used. This bool us used to determine if the SFCC regex is generated
-					if strings.Contains(url, "/demandware/") {
+					if detectPlatforms && strings.Contains(url, "/demandware/") {
 						sfccDetected = true
 					}
 					// Check if Shopify is used. This bool us used to determine if the Shopify regex is generated
-					if strings.Contains(url, "/collections/") && strings.Contains(url, "/products/") {
+					if detectPlatforms && strings.Contains(url, "/collections/") && strings.Contains(url, "/products/") {
 						shopifyDetected = true
 					}
 					if _, err := file.WriteString(url + "\n"); err != nil {
-						fmt.Println(red+"\nError. processURLs. Cannot write to file: "+reset, err)
+						fmt.Println(red+"\nError. exportAnalysisURLs. Cannot write to file: "+reset, err)
 						return "errorProcessURLs"
 					}
 					count++
@@ -372,966 +741,4404 @@ func processURLs(sessionID string) string {
 
 		//Max. number of URLs has been reached
 		if totalCount > maxURLsToProcess {
+			fmt.Printf(yellow+"Warning. exportAnalysisURLs. The export of %s was capped at %d URLs\n"+reset, slug, totalCount)
+			cappedAnalyses = append(cappedAnalyses, slug)
 			break
 		}
 
 		fmt.Printf("%s%s%s Page %d: %d URLs processed\n", yellow, sessionID, reset, page, count)
 	}
 
-	defer func() {
-		if err := res.Body.Close(); err != nil {
-			fmt.Println(red+"Error. processURLs. Closing (3):"+reset, err)
-		}
-	}()
-
 	return "success"
 }
 
-// Generate regex for level 1 and 2 folders
-func level1and2Folders() {
+// Generate the crawl delta segment. URLs new in the analysis, removed since the comparison analysis & persistent (found in both)
+// Folders which are entirely new, removed or persistent are matched by folder, other URLs are listed. All other URLs are in ~Other
+func crawlDelta() {
 
-	//Level1 folders
-	//Get the threshold. Use the level 1 slashCount
-	_, thresholdValueL1 := levelThreshold(urlExtractFile, slashCountLevel1)
+	if compareSlug == "" {
+		return
+	}
 
-	//generate the regex
-	segmentFolders(thresholdValueL1, slashCountLevel1)
+	currentURLs, err := readURLSet(urlExtractFile)
+	if err != nil {
+		fmt.Println(red+"Error. crawlDelta. Cannot read the analysis URLs:"+reset, err)
+		return
+	}
+	previousURLs, err := readURLSet(urlCompareFile)
+	if err != nil {
+		fmt.Println(red+"Error. crawlDelta. Cannot read the comparison analysis URLs:"+reset, err)
+		return
+	}
 
-	//Level2 folders
-	//Get the threshold. Use the level 2 slashCount
-	_, thresholdValueL2 := levelThreshold(urlExtractFile, slashCountLevel2)
+	// Level 1 folder deltas
+	folders := make(map[string]*folderDelta)
+	delta := func(folder string) *folderDelta {
+		if folders[folder] == nil {
+			folders[folder] = &folderDelta{Folder: folder}
+		}
+		return folders[folder]
+	}
 
-	//Level2 folders
-	segmentFolders(thresholdValueL2, slashCountLevel2)
-}
+	var newURLs, removedURLs, persistentURLs []string
+	for currentURL := range currentURLs {
+		if previousURLs[currentURL] {
+			delta(level1Folder(currentURL)).Persistent++
+			persistentURLs = append(persistentURLs, currentURL)
+			continue
+		}
+		delta(level1Folder(currentURL)).New++
+		newURLs = append(newURLs, currentURL)
+	}
+	for previousURL := range previousURLs {
+		if !currentURLs[previousURL] {
+			delta(level1Folder(previousURL)).Removed++
+			removedURLs = append(removedURLs, previousURL)
+		}
+	}
 
-func generateRegexFile() {
+	var folderDeltas []folderDelta
+	for _, folder := range folders {
+		folderDeltas = append(folderDeltas, *folder)
+	}
+	sort.Slice(folderDeltas, func(i, j int) bool {
+		changesI, changesJ := folderDeltas[i].New+folderDeltas[i].Removed, folderDeltas[j].New+folderDeltas[j].Removed
+		if changesI != changesJ {
+			return changesI > changesJ
+		}
+		return folderDeltas[i].Folder < folderDeltas[j].Folder
+	})
 
-	//Always create the file.
-	outputFile, err := os.Create(regexOutputFile)
-	if err != nil {
-		fmt.Printf(red+"\nError. generateRegexFile. Cannot create output file: %v\n"+reset, err)
-		os.Exit(1)
+	// Folders found in only one of the analyses, and folders without new or removed URLs
+	var newFolders, removedFolders, persistentFolders []string
+	for _, folder := range folderDeltas {
+		if !isFolder(folder.Folder) {
+			continue
+		}
+		switch {
+		case folder.New > 0 && folder.Removed == 0 && folder.Persistent == 0:
+			newFolders = append(newFolders, folder.Folder)
+		case folder.Removed > 0 && folder.New == 0 && folder.Persistent == 0:
+			removedFolders = append(removedFolders, folder.Folder)
+		case folder.Persistent > 0 && folder.New == 0 && folder.Removed == 0:
+			persistentFolders = append(persistentFolders, folder.Folder)
+		}
 	}
 
-	defer func() {
-		if err := outputFile.Close(); err != nil {
-			fmt.Println(red+"Error. generateRegexFile. Closing (4):"+reset, err)
+	deltaSegment := &segment{
+		Name:          "sl_crawl_delta",
+		Comments:      []string{fmt.Sprintf("Analysis %s compared with %s", analysisSlug, compareSlug)},
+		AnalysisTitle: fmt.Sprintf("Crawl delta folder analysis (%s vs. %s)", analysisSlug, compareSlug),
+	}
+	deltaSegment.Values = append(deltaSegment.Values,
+		folderAndURLValue("New", newFolders, newURLs, crawlDeltaMaxListedURLs),
+		folderAndURLValue("Removed", removedFolders, removedURLs, crawlDeltaMaxListedURLs),
+		folderAndURLValue("Persistent", persistentFolders, persistentURLs, crawlDeltaMaxListedURLs),
+		segmentValue{Label: "~Other", Rules: []segmentRule{{"path", "/*"}},
+			Comments: []string{"Unclassified. Includes the new, removed & persistent URLs which are not listed above"}})
+
+	// URLs beyond the cap of a truncated export are wrongly seen as new or removed
+	if len(cappedAnalyses) > 0 {
+		warning := fmt.Sprintf("Warning. The URL export of %s was capped at %d URLs. New & removed URLs are not reliable", strings.Join(cappedAnalyses, " & "), maxURLsToProcess)
+		deltaSegment.Comments = append(deltaSegment.Comments, warning)
+		fmt.Println(yellow + warning + reset)
+	}
+
+	// The number of new, removed & persistent URLs in each folder. The count is the number of URLs in the analysis
+	for _, folder := range folderDeltas {
+		if folder.New == 0 && folder.Removed == 0 {
+			continue
 		}
-	}()
+		deltaSegment.Analysis = append(deltaSegment.Analysis, FolderCount{
+			fmt.Sprintf("%s new: %d, removed: %d, persistent: %d", folder.Folder, folder.New, folder.Removed, folder.Persistent),
+			folder.New + folder.Persistent,
+		})
+	}
 
-	//Create a writer to write to the output file
-	writer := bufio.NewWriter(outputFile)
+	addSegment(deltaSegment)
 
-	// Get the user's local time zone for the header
-	userLocation, err := time.LoadLocation("") // Load the default local time zone
+	fmt.Printf(purple+"Crawl delta (%s vs. %s): %d new, %d removed, %d persistent URLs\n"+reset,
+		analysisSlug, compareSlug, len(newURLs), len(removedURLs), len(currentURLs)-len(newURLs))
+
+	// Folder level deltas
+	csvContent, err := renderFolderDeltasCSV(folderDeltas)
 	if err != nil {
-		fmt.Println("\nError loading user's location:", err)
+		fmt.Println(red+"Error. crawlDelta. Cannot render the folder deltas:"+reset, err)
 		return
 	}
-	// Get the current date and time in the user's local time zone
-	currentTime := time.Now().In(userLocation)
+	if err := os.WriteFile(cacheFolder+"/"+crawlDeltaFile, []byte(csvContent), 0644); err != nil {
+		fmt.Println(red+"Error. crawlDelta. Cannot write the folder deltas:"+reset, err)
+	}
+}
 
-	_, err = writer.WriteString(fmt.Sprintf("# Regex made with love using segmentifyLite %s\n", version))
+// New, removed & persistent URLs in a level 1 folder
+type folderDelta struct {
+	Folder     string
+	New        int
+	Removed    int
+	Persistent int
+}
 
-	if err != nil {
-		fmt.Printf(red+"\nError. generateRegexFile. Cannot write header to output file: %v\n"+reset, err)
-		os.Exit(1)
+// A value matching whole level 1 folders & listed URLs. The URLs outside these folders are listed, up to maxListedURLs
+func folderAndURLValue(label string, folders []string, rawURLs []string, maxListedURLs int) segmentValue {
+
+	value := segmentValue{Label: label, Operator: "or"}
+	for _, folder := range folders {
+		value.Rules = append(value.Rules, segmentRule{"url", "*" + folder + "/*"})
 	}
 
-	_, err = writer.WriteString(fmt.Sprintf("# Organisation name: %s\n", organisation))
-	if err != nil {
-		errMsg := fmt.Errorf(red+"Error. Cannot write organisation name in Regex file: %w"+reset, err)
-		println(errMsg)
+	var listedURLs []string
+	for _, rawURL := range rawURLs {
+		folder := level1Folder(rawURL)
+		if !containsString(folders, folder) || !strings.HasPrefix(rawURL, folder+"/") {
+			listedURLs = append(listedURLs, rawURL)
+		}
 	}
-	_, err = writer.WriteString(fmt.Sprintf("# Project name: %s\n", project))
-	if err != nil {
-		errMsg := fmt.Errorf(red+"Error. Cannot write project name in Regex file: %w"+reset, err)
-		println(errMsg)
+	sort.Strings(listedURLs)
+
+	for i, rawURL := range listedURLs {
+		if i == maxListedURLs {
+			value.Comments = append(value.Comments, fmt.Sprintf("%d further URLs not listed", len(listedURLs)-maxListedURLs))
+			break
+		}
+		value.Rules = append(value.Rules, segmentRule{"url", rawURL})
 	}
-	_, err = writer.WriteString(fmt.Sprintf("# Generated %s", currentTime.Format(time.RFC1123)))
-	if err != nil {
-		errMsg := fmt.Errorf(red+"Error. Cannot write generate date/time name in Regex file: %w"+reset, err)
-		println(errMsg)
+
+	// A value must have at least one rule. Match nothing when there are no URLs
+	if len(value.Rules) == 0 {
+		value.Operator = ""
+		value.Rules = []segmentRule{{"url", "rx:^$"}}
 	}
 
-	//Flush the writer to ensure all data is written to the file
-	err = writer.Flush()
-	if err != nil {
-		fmt.Printf(red+"\nError. generateRegexFile. Cannot flush writer: %v\n"+reset, err)
-		os.Exit(1)
+	return value
+}
+
+// The folder of a URL, as used by the folder segments
+// slashCount = 4 for Level 1 folders, slashCount = 5 for Level 2 folders
+func folderText(line string, slashCount int) (string, bool) {
+
+	//Split the line into substrings using a forward-slash as delimiter
+	parts := strings.Split(line, "/")
+	if len(parts) < slashCount {
+		return "", false
 	}
+
+	//Extract the text & trim any leading or trailing whitespace
+	return strings.TrimSpace(strings.Join(parts[:slashCount], "/")), true
 }
 
-func segmentFolders(thresholdValue int, slashCount int) {
+// The level 1 folder of a URL. URLs in the root folder are grouped by host
+func level1Folder(rawURL string) string {
 
-	//Open the input file for reading
-	file, err := os.Open(urlExtractFile)
+	if text, found := folderText(rawURL, slashCountLevel1); found {
+		return text
+	}
+
+	parts := strings.Split(rawURL, "/")
+
+	return strings.TrimSpace(strings.Join(parts[:min(len(parts), 3)], "/"))
+}
+
+// Is the level 1 folder a folder, rather than the root folder of a host
+func isFolder(folder string) bool {
+	hostAndFolder := strings.TrimPrefix(strings.TrimPrefix(folder, "https://"), "http://")
+	return strings.Contains(hostAndFolder, "/") && !strings.HasSuffix(hostAndFolder, "/")
+}
+
+// Is the string in the list
+func containsString(list []string, item string) bool {
+
+	for _, listItem := range list {
+		if listItem == item {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Read the URLs exported to a file. URLs containing quotation marks are skipped, as they are when segmenting folders
+func readURLSet(fileName string) (map[string]bool, error) {
+
+	file, err := os.Open(fileName)
 	if err != nil {
-		os.Exit(1)
+		return nil, err
 	}
 	defer func() {
 		if err := file.Close(); err != nil {
-			fmt.Println(red+"Error. segmentFolders. Closing (5):"+reset, err)
+			fmt.Println(red+"Error. readURLSet. Closing (23):"+reset, err)
 		}
 	}()
 
-	//Create a scanner to read the file line by line
+	urls := make(map[string]bool)
 	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.Contains(line, "\"") {
+			continue
+		}
+		urls[line] = true
+	}
 
-	//Map to keep track of counts of unique values
-	FolderCounts := make(map[string]int)
+	return urls, scanner.Err()
+}
 
-	//Variable to keep track of the total number of records processed
-	totalRecords := 0
+// Render the folder level deltas as CSV
+func renderFolderDeltasCSV(folderDeltas []folderDelta) (string, error) {
 
-	//Counter to track the number of records scanned
-	recordCounter := 0
+	var csvContent strings.Builder
+	writer := csv.NewWriter(&csvContent)
 
-	//Counter to track the number of folders excluded from the regex
-	noFoldersExcluded := 0
+	if err := writer.Write([]string{"Folder", "New", "Removed", "Persistent"}); err != nil {
+		return "", err
+	}
+	for _, folder := range folderDeltas {
+		if err := writer.Write([]string{folder.Folder, strconv.Itoa(folder.New), strconv.Itoa(folder.Removed), strconv.Itoa(folder.Persistent)}); err != nil {
+			return "", err
+		}
+	}
+	writer.Flush()
 
-	//Iterate through each line in the file
-	for scanner.Scan() {
-		line := scanner.Text()
-		totalRecords++
-		recordCounter++
+	return csvContent.String(), writer.Error()
+}
 
-		//Check if the line contains a quotation mark, if yes, skip to the next line
-		if strings.Contains(line, "\"") {
-			continue
-		}
+// robots.txt report. The URLs blocked by each rule, saved to the cache folder
+var robotsReportFile = "robotsReport.csv"
 
-		// Is this a product URL?
-		isProductURL = isValidisProductURL(line)
-		if isProductURL {
-			println(line)
-			generatePDPRegex = true
-		}
+// Was the robots.txt evaluated for the current run
+var robotsEvaluated bool
 
-		//Split the line into substrings using a forward-slash as delimiter
-		// slashCount = 4 for Level 1 folders
-		// slashCount = 5 for Level 2 folders
-		parts := strings.Split(line, "/")
+// Maximum size of a robots.txt file. Google ignores the content after 500 KiB
+var maxRobotsTxtSize int64 = 500 * 1024
 
-		if len(parts) >= slashCount {
-			//Extract the text
-			text := strings.Join(parts[:slashCount], "/")
+// A robots.txt allow or disallow rule
+type robotsRule struct {
+	Allow   bool
+	Pattern string
+	regex   *regexp.Regexp
+}
 
-			//Trim any leading or trailing whitespace
-			text = strings.TrimSpace(text)
+// The number of crawled URLs decided by a robots.txt rule
+type robotsRuleCount struct {
+	Allow   bool
+	Pattern string
+	Count   int
+}
 
-			//Update the count for this value if it's not empty
-			if text != "" {
-				FolderCounts[text]++
-			}
-		}
+// Get the robots.txt to evaluate the URLs against, when requested in the form
+// Pasted text is used first, then an uploaded file, then the robots.txt URL. Otherwise it is fetched from the root of the main host
+func robotsTxt(r *http.Request) (content string, source string, err error) {
+
+	if r.Form.Get("robotsSegment") == "" {
+		return "", "", nil
 	}
 
-	fmt.Printf("\n")
-
-	//Create a slice to hold FolderCount structs
-	var sortedCounts []FolderCount
+	content, source, err = pastedOrUploaded(r, "robotsTxt", "robotsTxtFile")
+	if err != nil || content != "" {
+		return content, source, err
+	}
 
-	//Populate the slice with data from the map
-	for folderName, count := range FolderCounts {
-		if count > thresholdValue {
-			sortedCounts = append(sortedCounts, FolderCount{folderName, count})
-		} else {
-			// Count the number of folders excluded
-			noFoldersExcluded++
+	robotsURL := strings.TrimSpace(r.Form.Get("robotsURL"))
+	if robotsURL == "" {
+		siteRoot, err := mainSiteRoot()
+		if err != nil {
+			return "", "", err
 		}
+		robotsURL = siteRoot + "/robots.txt"
 	}
 
-	//Sort the slice based on counts
-	sort.Sort(ByCount(sortedCounts))
+	content, err = fetchRobotsTxt(robotsURL)
+
+	return content, robotsURL, err
+}
+
+// The scheme & host with the most crawled URLs
+func mainSiteRoot() (string, error) {
 
-	//Open the file in append mode, create if it doesn't exist
-	outputFile, err := os.OpenFile(regexOutputFile, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	urls, err := readURLSet(urlExtractFile)
 	if err != nil {
-		panic(err)
+		return "", err
 	}
 
-	defer func() {
-		if err := outputFile.Close(); err != nil {
-			fmt.Println(red+"Error. segmentFolders. Closing (6):"+reset, err)
+	roots := make(map[string]int)
+	for rawURL := range urls {
+		parsedURL, err := url.Parse(rawURL)
+		if err != nil || parsedURL.Host == "" {
+			continue
 		}
-	}()
+		roots[parsedURL.Scheme+"://"+parsedURL.Host]++
+	}
+
+	sortedRoots := sortCounts(roots)
+	if len(sortedRoots) == 0 {
+		return "", errors.New("no host found in the crawled URLs")
+	}
 
-	//Create a writer to write to the output file
-	writer := bufio.NewWriter(outputFile)
+	return sortedRoots[0].Text, nil
+}
 
-	//Write the segment name
-	// SlashCount = 4 signals level 1 folders
-	// SlashCount = 5 signals level 2 folders
+// Fetch a robots.txt file
+// As with Googlebot, a 4xx response is treated as no robots.txt (everything is allowed)
+func fetchRobotsTxt(robotsURL string) (string, error) {
 
-	// Level 1
-	if slashCount == 4 {
-		if _, err := writer.WriteString(fmt.Sprintf("\n\n[segment:sl_level1_folders]\n@Home\npath /\n\n")); err != nil {
-			fmt.Printf(red+"Error. segmentFolders. Cannot write segment to writer. Level 1 folders: %v\n"+reset, err)
-		}
+	client := &http.Client{Timeout: 30 * time.Second}
+	res, err := client.Get(robotsURL)
+	if err != nil {
+		return "", err
 	}
 
-	// Level 2
-	if slashCount == 5 {
-		if _, err := writer.WriteString(fmt.Sprintf("\n\n[segment:sl_level2_folders]\n@Home\npath /\n\n")); err != nil {
-			fmt.Printf(red+"Error. segmentFolders. Cannot write segment to writer. Level 2 folders: %v\n"+reset, err)
+	defer func() {
+		if err := res.Body.Close(); err != nil {
+			fmt.Println(red+"Error. fetchRobotsTxt. Closing (24):"+reset, err)
 		}
+	}()
+
+	switch {
+	case res.StatusCode >= 200 && res.StatusCode <= 299:
+	case res.StatusCode >= 400 && res.StatusCode <= 499:
+		fmt.Println(yellow+"Warning. fetchRobotsTxt. No robots.txt found, all URLs are allowed:"+reset, res.Status)
+		return "", nil
+	default:
+		return "", fmt.Errorf("%s returned %s", robotsURL, res.Status)
 	}
 
-	//Write the regex
-	for _, folderValueCount := range sortedCounts {
-		if folderValueCount.Text != "" {
-			//Extract the text between the third and fourth forward-slashes
-			parts := strings.SplitN(folderValueCount.Text, "/", 4)
-			if len(parts) >= 4 && parts[3] != "" {
-				folderLabel := parts[3] //Extract the text between the third and fourth forward-slashes
-				_, err := writer.WriteString(fmt.Sprintf("@%s\nurl *%s/*\n\n", folderLabel, folderValueCount.Text))
-				if err != nil {
-					fmt.Printf(red+"\nError. segmentFolders. Cannot write to output file: %v\n"+reset, err)
-					os.Exit(1)
+	content, err := io.ReadAll(io.LimitReader(res.Body, maxRobotsTxtSize))
+
+	return string(content), err
+}
+
+// Parse the rules of the Googlebot group. The "*" group is used when there is no Googlebot group
+// Groups for the same user agent are combined
+func parseRobotsTxt(content string) []robotsRule {
+
+	groupRules := make(map[string][]robotsRule)
+	var groupAgents []string
+	inRules := false
+
+	for _, line := range strings.Split(content, "\n") {
+		if index := strings.Index(line, "#"); index != -1 {
+			line = line[:index]
+		}
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			// A user agent following rules starts a new group
+			if inRules {
+				groupAgents = nil
+				inRules = false
+			}
+			groupAgents = append(groupAgents, strings.ToLower(value))
+		case "allow", "disallow":
+			inRules = true
+			// The group is kept even when its rules are empty. An empty Disallow allows everything to its user agents
+			for _, agent := range groupAgents {
+				if _, found := groupRules[agent]; !found {
+					groupRules[agent] = []robotsRule{}
 				}
 			}
+			// An empty rule matches nothing
+			if value == "" {
+				continue
+			}
+			rule := robotsRule{Allow: key == "allow", Pattern: value, regex: regexp.MustCompile(robotsPatternRegex(value))}
+			for _, agent := range groupAgents {
+				groupRules[agent] = append(groupRules[agent], rule)
+			}
 		}
 	}
 
-	//Write the footer lines
-	_, err = writer.WriteString("@~Other\npath /*\n# ----End of level2Folders Segment----\n")
-	if err != nil {
-		fmt.Printf(red+"Error. segmentFolders. Cannot write segment to writer: %v\n"+reset, err)
+	if rules, found := groupRules["googlebot"]; found {
+		return rules
 	}
 
-	//Insert the number of URLs found in each folder as comments
-	_, err = writer.WriteString("\n# ----Folder URL analysis----\n")
-	if err != nil {
-		fmt.Printf(red+"Error. segmentFolders. Cannot write segment to writer: %v\n"+reset, err)
-	}
-	for _, folderValueCount := range sortedCounts {
-		_, err := writer.WriteString(fmt.Sprintf("# --%s (URLs found: %d)\n", folderValueCount.Text, folderValueCount.Count))
-		if err != nil {
-			fmt.Printf(red+"Error. segmentFolders. Cannot write segment to writer: %v\n"+reset, err)
-		}
+	return groupRules["*"]
+}
+
+// Convert a robots.txt pattern to a regex matching the path & query. "*" matches any characters, "$" anchors the end
+func robotsPatternRegex(pattern string) string {
+
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+
+	parts := strings.Split(pattern, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
 	}
 
-	//Flush the writer to ensure all data is written to the file
-	err = writer.Flush()
-	if err != nil {
-		fmt.Printf(red+"\nError. segmentFolders. Cannot flush writer: %v\n"+reset, err)
-		os.Exit(1)
+	regex := "^" + strings.Join(parts, ".*")
+	if anchored {
+		regex += "$"
 	}
-}
 
-// Regex for subdomains
-func subDomains() {
+	return regex
+}
 
-	//Open the input file
-	file, err := os.Open(urlExtractFile)
-	if err != nil {
-		os.Exit(1)
-	}
+// The rule deciding if a URL is allowed. The longest matching pattern wins, allow wins when an allow & disallow rule are the same length
+// nil when no rule matches (the URL is allowed)
+func robotsDecision(rules []robotsRule, pathAndQuery string) *robotsRule {
 
-	defer func() {
-		if err := file.Close(); err != nil {
-			fmt.Println(red+"Error. subDomains. Closing (7):"+reset, err)
+	var decision *robotsRule
+	for i := range rules {
+		rule := &rules[i]
+		if !rule.regex.MatchString(pathAndQuery) {
+			continue
 		}
-	}()
+		if decision == nil || len(rule.Pattern) > len(decision.Pattern) ||
+			(len(rule.Pattern) == len(decision.Pattern) && rule.Allow && !decision.Allow) {
+			decision = rule
+		}
+	}
 
-	//Create a scanner to read the file line by line
-	scanner := bufio.NewScanner(file)
+	return decision
+}
 
-	//Map to keep track of counts of unique values
-	FolderCounts := make(map[string]int)
+// Generate the robots.txt segment. URLs disallowed by each rule & allowed URLs
+// Values are ordered by pattern length so that the first matching value is the robots.txt longest match
+// Allow rules are only included when they override a disallow rule for crawled URLs
+// A robots.txt only applies to its own host. URLs on other hosts are not evaluated and are in ~Other
+func robotsSegment(content string, source string) {
 
-	//Variable to keep track of the total number of records processed
-	totalRecords := 0
+	rules := parseRobotsTxt(content)
 
-	//Counter to track the number of records scanned
-	recordCounter := 0
+	urls, err := readURLSet(urlExtractFile)
+	if err != nil {
+		fmt.Println(red+"Error. robotsSegment. Cannot read the URLs:"+reset, err)
+		return
+	}
 
-	for scanner.Scan() {
-		line := scanner.Text()
-		totalRecords++
-		recordCounter++
+	robotsHost := robotsScopeHost(source, urls)
+	if robotsHost == "" {
+		fmt.Println(red + "Error. robotsSegment. No host found in the crawled URLs" + reset)
+		return
+	}
 
-		//Check if the line contains a quotation mark, if yes, skip to the next line
-		if strings.Contains(line, "\"") {
+	// Counted by rule type & pattern, the same rule may be repeated in the combined groups
+	decisionCounts := make(map[robotsRuleCount]int)
+	otherHostURLs := 0
+	for rawURL := range urls {
+		parsedURL, err := url.Parse(rawURL)
+		if err != nil {
 			continue
 		}
+		if !strings.EqualFold(parsedURL.Host, robotsHost) {
+			otherHostURLs++
+			continue
+		}
+		decision := robotsDecision(rules, parsedURL.RequestURI())
+		if decision == nil {
+			continue
+		}
+		if decision.Allow && !overridesDisallow(rules, *decision, parsedURL.RequestURI()) {
+			continue
+		}
+		decisionCounts[robotsRuleCount{Allow: decision.Allow, Pattern: decision.Pattern}]++
+	}
 
-		//Split the line into substrings using a forward-slash as delimiter
-		parts := strings.Split(line, "/")
-		//Check if there are at least 4 parts in the line
-		if len(parts) >= 4 {
-			//Extract the text between the third and fourth forward-slashes
-			text := strings.Join(parts[:3], "/")
-
-			//Trim any leading or trailing whitespace
-			text = strings.TrimSpace(text)
+	var ruleCounts []robotsRuleCount
+	for rule, count := range decisionCounts {
+		rule.Count = count
+		ruleCounts = append(ruleCounts, rule)
+	}
 
-			//Update the count for this value if it's not empty
-			if text != "" {
-				//Update the count for this value if it's not empty
-				FolderCounts[text]++
-			}
+	// Longest pattern first, allow before disallow, then pattern
+	sort.Slice(ruleCounts, func(i, j int) bool {
+		ruleI, ruleJ := ruleCounts[i], ruleCounts[j]
+		if len(ruleI.Pattern) != len(ruleJ.Pattern) {
+			return len(ruleI.Pattern) > len(ruleJ.Pattern)
+		}
+		if ruleI.Allow != ruleJ.Allow {
+			return ruleI.Allow
 		}
+		return ruleI.Pattern < ruleJ.Pattern
+	})
+
+	robotsSeg := &segment{
+		Name:          "sl_robots",
+		Comments:      []string{"robots.txt " + source + ". Googlebot rules, longest match. Applied to " + robotsHost},
+		AnalysisTitle: "robots.txt rules blocking crawled URLs",
 	}
 
-	//Create a slice to hold FolderCount structs
-	var sortedCounts []FolderCount
+	disallowedURLs := 0
+	for _, ruleCount := range ruleCounts {
+		label := "Disallowed " + ruleCount.Pattern
+		if ruleCount.Allow {
+			label = "Allowed " + ruleCount.Pattern
+		} else {
+			disallowedURLs += ruleCount.Count
+		}
+		robotsSeg.Values = append(robotsSeg.Values, segmentValue{
+			Label: label,
+			Rules: []segmentRule{{"url", "rx:" + robotsURLRegex(robotsHost, ruleCount.Pattern)}},
+		})
+	}
+	robotsSeg.Values = append(robotsSeg.Values, segmentValue{Label: "Allowed", Rules: []segmentRule{{"url", "rx:" + hostRegex(robotsHost, false)}}})
+	if otherHostURLs > 0 {
+		robotsSeg.Values = append(robotsSeg.Values, segmentValue{Label: "~Other", Rules: []segmentRule{{"path", "/*"}},
+			Comments: []string{"URLs on other hosts, not evaluated against the robots.txt of " + robotsHost}})
+		fmt.Printf(yellow+"Warning. robots.txt applies to %s only. %d URLs on other hosts are not evaluated\n"+reset, robotsHost, otherHostURLs)
+	}
 
-	//Populate the slice with data from the map
-	for folderName, count := range FolderCounts {
-		sortedCounts = append(sortedCounts, FolderCount{folderName, count})
+	// The disallow rules blocking the most crawled URLs
+	sort.SliceStable(ruleCounts, func(i, j int) bool { return ruleCounts[i].Count > ruleCounts[j].Count })
+	for _, ruleCount := range ruleCounts {
+		if !ruleCount.Allow {
+			robotsSeg.Analysis = append(robotsSeg.Analysis, FolderCount{"Disallow: " + ruleCount.Pattern, ruleCount.Count})
+		}
 	}
 
-	//Sort the slice based on counts
-	sort.Sort(ByCount(sortedCounts))
+	addSegment(robotsSeg)
 
-	//Open the file in append mode, create if it doesn't exist
-	outputFile, err := os.OpenFile(regexOutputFile, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	fmt.Printf(purple+"robots.txt: %d of %d URLs disallowed by %d rules\n"+reset, disallowedURLs, len(urls), len(robotsSeg.Analysis))
+
+	reportContent, err := renderRobotsReportCSV(ruleCounts)
 	if err != nil {
-		panic(err)
+		fmt.Println(red+"Error. robotsSegment. Cannot render the robots.txt report:"+reset, err)
+		return
 	}
+	if err := os.WriteFile(cacheFolder+"/"+robotsReportFile, []byte(reportContent), 0644); err != nil {
+		fmt.Println(red+"Error. robotsSegment. Cannot write the robots.txt report:"+reset, err)
+	}
+}
 
-	defer func() {
-		if err := outputFile.Close(); err != nil {
-			fmt.Println(red+"Error. subDomains. Closing (8):"+reset, err)
+// Does an allow rule override a disallow rule which also matches the URL
+func overridesDisallow(rules []robotsRule, allowRule robotsRule, pathAndQuery string) bool {
+
+	for _, rule := range rules {
+		if !rule.Allow && rule.regex.MatchString(pathAndQuery) && len(rule.Pattern) <= len(allowRule.Pattern) {
+			return true
 		}
-	}()
+	}
 
-	//Create a writer to write to the output file
-	writer := bufio.NewWriter(outputFile)
+	return false
+}
 
-	//Write the header lines
-	_, err = writer.WriteString(fmt.Sprintf("\n\n[segment:sl_subdomains]\n@Home\npath /\n\n"))
-	if err != nil {
-		fmt.Printf(red+"Error. subDomains. Cannot write segment to writer: %v\n"+reset, err)
-	}
+// Convert a robots.txt pattern to a regex matching the full URL on the robots.txt host
+func robotsURLRegex(robotsHost string, pattern string) string {
+	return strings.TrimSuffix(hostRegex(robotsHost, false), "[/?#]") + strings.TrimPrefix(robotsPatternRegex(pattern), "^")
+}
 
-	//Write the regex
-	for _, folderValueCount := range sortedCounts {
-		if folderValueCount.Text != "" {
-			//Extract the text between the third and fourth forward-slashes
-			parts := strings.SplitN(folderValueCount.Text, "/", 4)
-			if len(parts) >= 3 && parts[2] != "" {
-				folderLabel := parts[2] //Extract the text between the third and fourth forward-slashes
-				_, err := writer.WriteString(fmt.Sprintf("@%s\nurl *%s/*\n\n", folderLabel, folderValueCount.Text))
-				if err != nil {
-					fmt.Printf(red+"Error. subDomains. Cannot write segment to writer: %v\n"+reset, err)
-					// Handle or return the error as needed
-				}
-			}
+// The host the robots.txt applies to. The host of the robots.txt URL when it was crawled, otherwise the host with the most crawled URLs
+// (pasted or uploaded robots.txt, or a robots.txt fetched from a local stand-in)
+func robotsScopeHost(source string, urls map[string]bool) string {
+
+	hostCounts := make(map[string]int)
+	for rawURL := range urls {
+		parsedURL, err := url.Parse(rawURL)
+		if err != nil || parsedURL.Host == "" {
+			continue
 		}
+		hostCounts[strings.ToLower(parsedURL.Host)]++
 	}
 
-	//Write the footer lines
-	_, err = writer.WriteString("@~Other\npath /*\n# ----End of subDomains Segment----\n")
-	if err != nil {
-		fmt.Printf(red+"Error. subDomains. Cannot write segment to writer: %v\n"+reset, err)
+	if robotsURL, err := url.Parse(source); err == nil && hostCounts[strings.ToLower(robotsURL.Host)] > 0 {
+		return strings.ToLower(robotsURL.Host)
 	}
 
-	//Insert the number of URLs found in each folder as comments
-	_, err = writer.WriteString("\n# ----subDomains Folder URL analysis----\n")
-	if err != nil {
-		fmt.Printf(red+"Error. subDomains. Cannot write segment to writer: %v\n"+reset, err)
-	}
-	for _, folderValueCount := range sortedCounts {
-		_, err := writer.WriteString(fmt.Sprintf("# --%s (URLs found: %d)\n", folderValueCount.Text, folderValueCount.Count))
-		if err != nil {
-			fmt.Printf(red+"\nError. subDomains. Cannot write to output file: %v\n"+reset, err)
-			os.Exit(1)
-		}
+	sortedHosts := sortCounts(hostCounts)
+	if len(sortedHosts) == 0 {
+		return ""
 	}
 
-	//Flush the writer to ensure all data is written to the file
-	err = writer.Flush()
-	if err != nil {
-		fmt.Printf(red+"\nError. subDomains. Cannot flush writer: %v\n"+reset, err)
-		os.Exit(1)
-	}
+	return sortedHosts[0].Text
 }
 
-// Regex to identify which parameter keys are used
-func parameterKeys() {
+// Render the robots.txt report as CSV. The rules blocking the most crawled URLs first
+func renderRobotsReportCSV(ruleCounts []robotsRuleCount) (string, error) {
 
-	//Open the input file
-	file, err := os.Open(urlExtractFile)
-	if err != nil {
-		os.Exit(1)
-	}
+	var csvContent strings.Builder
+	writer := csv.NewWriter(&csvContent)
 
-	defer func() {
-		if err := file.Close(); err != nil {
-			fmt.Println(red+"Error. parameterKeys. Closing (9):"+reset, err)
+	if err := writer.Write([]string{"Rule", "Pattern", "URLs"}); err != nil {
+		return "", err
+	}
+	for _, ruleCount := range ruleCounts {
+		ruleType := "Disallow"
+		if ruleCount.Allow {
+			ruleType = "Allow"
 		}
-	}()
+		if err := writer.Write([]string{ruleType, ruleCount.Pattern, strconv.Itoa(ruleCount.Count)}); err != nil {
+			return "", err
+		}
+	}
+	writer.Flush()
 
-	//Create a scanner to read the file line by line
-	scanner := bufio.NewScanner(file)
+	return csvContent.String(), writer.Error()
+}
 
-	//Map to keep track of counts of unique values
-	FolderCounts := make(map[string]int)
+// Sitemap presence report. The URLs of each class in each level 1 folder, saved to the cache folder
+var sitemapPresenceFile = "sitemapPresence.csv"
 
-	//Variable to keep track of the total number of records processed
-	totalRecords := 0
+// Maximum number of sitemaps read, including the sitemaps listed in sitemap indexes
+var maxSitemaps = 50
 
-	//Counter to track the number of records scanned
-	recordCounter := 0
+// Maximum size of a sitemap. The sitemap protocol limit is 50MB uncompressed
+var maxSitemapSize int64 = 50 * 1024 * 1024
 
-	for scanner.Scan() {
-		line := scanner.Text()
-		totalRecords++
-		recordCounter++
+// Maximum number of URLs listed in each sitemap presence value
+var sitemapMaxListedURLs = 100
 
-		//Check if the line contains a quotation mark, if yes, skip to the next line
-		if strings.Contains(line, "\"") {
-			continue
-		}
+// Were sitemaps compared with the crawl for the current run
+var sitemapsCompared bool
 
-		//Split the line into substrings using question mark as delimiter
-		parts := strings.Split(line, "?")
+// A sitemap or sitemap index. Only the locations are used
+type sitemapDocument struct {
+	XMLName  xml.Name
+	URLs     []sitemapLocation `xml:"url"`
+	Sitemaps []sitemapLocation `xml:"sitemap"`
+}
 
-		//Iterate over the parts after each question mark
-		for _, part := range parts[1:] {
-			//Find the index of the equals sign
-			equalsIndex := strings.Index(part, "=")
-			if equalsIndex != -1 {
-				//Extract the text between the question mark and the equals sign
-				text := part[:equalsIndex]
+type sitemapLocation struct {
+	Loc string `xml:"loc"`
+}
 
-				//Trim any leading or trailing whitespace
-				text = strings.TrimSpace(text)
+// In sitemap & crawled, crawled but not in sitemap & in sitemap but not crawled URLs in a level 1 folder
+type folderPresence struct {
+	Folder       string
+	Both         int
+	NotInSitemap int
+	NotCrawled   int
+}
 
-				//Update the count for this value
-				FolderCounts[text]++
+// Get the sitemap URLs, when requested in the form
+// Uploaded sitemaps are used first, otherwise the sitemaps listed in the form are fetched. Sitemap indexes are followed
+func sitemapURLs(r *http.Request) (urls map[string]bool, sources []string, err error) {
+
+	if r.Form.Get("sitemapSegment") == "" {
+		return nil, nil, nil
+	}
+
+	urls = make(map[string]bool)
+	sitemapsRead := 0
+
+	if r.MultipartForm != nil {
+		for _, fileHeader := range r.MultipartForm.File["sitemapFiles"] {
+			file, err := fileHeader.Open()
+			if err != nil {
+				return nil, nil, err
 			}
+			content, err := io.ReadAll(io.LimitReader(file, maxSitemapSize))
+			if closeErr := file.Close(); closeErr != nil {
+				fmt.Println(red+"Error. sitemapURLs. Closing (25):"+reset, closeErr)
+			}
+			if err != nil {
+				return nil, nil, err
+			}
+			if len(bytes.TrimSpace(content)) == 0 {
+				continue
+			}
+			if err := readSitemap(content, fileHeader.Filename, urls, &sitemapsRead); err != nil {
+				return nil, nil, err
+			}
+			sources = append(sources, fileHeader.Filename)
 		}
 	}
+	if len(sources) > 0 {
+		return urls, sources, nil
+	}
 
-	//Subtract 2 in order to account for the two header records which are defaults in Botify URL extracts
-	totalRecords -= 2
+	for _, sitemapURL := range strings.Fields(r.Form.Get("sitemapURLs")) {
+		if err := fetchSitemap(sitemapURL, urls, &sitemapsRead); err != nil {
+			return nil, nil, err
+		}
+		sources = append(sources, sitemapURL)
+	}
 
-	fmt.Printf("\n")
+	if len(sources) == 0 {
+		return nil, nil, errors.New("no sitemap URL or file provided")
+	}
 
-	//Create a slice to hold FolderCount structs
-	var sortedCounts []FolderCount
+	return urls, sources, nil
+}
 
-	//Populate the slice with data from the map
-	for folderName, count := range FolderCounts {
-		sortedCounts = append(sortedCounts, FolderCount{folderName, count})
-	}
+// Fetch a sitemap & add its URLs. Sitemap indexes are followed
+func fetchSitemap(sitemapURL string, urls map[string]bool, sitemapsRead *int) error {
 
-	//Sort the slice based on counts
-	sort.Sort(ByCount(sortedCounts))
+	if *sitemapsRead >= maxSitemaps {
+		fmt.Printf(yellow+"Warning. fetchSitemap. Maximum no. of sitemaps (%d) reached, %s not read\n"+reset, maxSitemaps, sitemapURL)
+		return nil
+	}
 
-	//Open the file in append mode, create if it doesn't exist
-	outputFile, err := os.OpenFile(regexOutputFile, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	client := &http.Client{Timeout: 60 * time.Second}
+	res, err := client.Get(sitemapURL)
 	if err != nil {
-		panic(err)
+		return err
 	}
 
 	defer func() {
-		if err := outputFile.Close(); err != nil {
-			fmt.Println(red+"Error. parameterKeys. Closing (10):"+reset, err)
+		if err := res.Body.Close(); err != nil {
+			fmt.Println(red+"Error. fetchSitemap. Closing (26):"+reset, err)
 		}
 	}()
 
-	//Create a writer to write to the output file
-	writer := bufio.NewWriter(outputFile)
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %s", sitemapURL, res.Status)
+	}
 
-	//Write the header lines
-	_, err = writer.WriteString(fmt.Sprintf("\n\n[segment:sl_parameter_keys]\n"))
+	content, err := io.ReadAll(io.LimitReader(res.Body, maxSitemapSize))
 	if err != nil {
-		fmt.Printf(red+"Error. parameterKeys. Cannot write segment to writer: %v\n"+reset, err)
+		return err
 	}
 
-	//Write the regex
-	for _, folderValueCount := range sortedCounts {
-		_, err := writer.WriteString(fmt.Sprintf("@%s\nquery *%s=*\n\n", folderValueCount.Text, folderValueCount.Text))
+	return readSitemap(content, sitemapURL, urls, sitemapsRead)
+}
+
+// Read a sitemap (gzipped or not) & add its URLs. The sitemaps listed in a sitemap index are fetched
+func readSitemap(content []byte, source string, urls map[string]bool, sitemapsRead *int) error {
+
+	*sitemapsRead++
+
+	// Gzipped sitemap
+	if len(content) > 2 && content[0] == 0x1f && content[1] == 0x8b {
+		reader, err := gzip.NewReader(bytes.NewReader(content))
 		if err != nil {
-			fmt.Printf(red+"\nError. parameterKeys. Cannot write to output file: %v\n"+reset, err)
-			os.Exit(1)
+			return fmt.Errorf("%s: %v", source, err)
+		}
+		content, err = io.ReadAll(io.LimitReader(reader, maxSitemapSize))
+		if err != nil {
+			return fmt.Errorf("%s: %v", source, err)
 		}
 	}
 
-	//Write the footer lines
-	_, err = writer.WriteString("@~Other\npath /*\n# ----End of parameterKeys Segment----\n")
-	if err != nil {
-		fmt.Printf(red+"Error. parameterKeys. Cannot write segment to writer: %v\n"+reset, err)
-		// Handle or return the error as needed
+	var document sitemapDocument
+	if err := xml.Unmarshal(content, &document); err != nil {
+		return fmt.Errorf("%s: %v", source, err)
 	}
 
-	//Insert the number of URLs found in each folder as comments
-	_, err = writer.WriteString("\n# ----parameterKeys URL analysis----\n")
-	if err != nil {
-		fmt.Printf(red+"Error. parameterKeys. Cannot write segment to writer: %v\n"+reset, err)
-		// Handle or return the error as needed
+	for _, location := range document.URLs {
+		if loc := strings.TrimSpace(location.Loc); loc != "" && len(urls) < maxURLsToProcess {
+			urls[loc] = true
+		}
 	}
-	for _, folderValueCount := range sortedCounts {
-		_, err := writer.WriteString(fmt.Sprintf("# --%s (URLs found: %d)\n", folderValueCount.Text, folderValueCount.Count))
-		if err != nil {
-			fmt.Printf(red+"\nError. parameterKeys. Cannot write to output file: %v\n"+reset, err)
-			os.Exit(1)
+
+	for _, location := range document.Sitemaps {
+		if loc := strings.TrimSpace(location.Loc); loc != "" {
+			if err := fetchSitemap(loc, urls, sitemapsRead); err != nil {
+				return err
+			}
 		}
 	}
 
-	//Flush the writer to ensure all data is written to the file
-	err = writer.Flush()
+	return nil
+}
+
+// Generate the sitemap presence segment. URLs crawled but not in the sitemaps, in the sitemaps but not crawled (orphan candidates) & in both
+// Level 1 folders found only in the sitemaps, only in the crawl or with all their URLs in both are matched by folder, other URLs are listed. All other URLs are in ~Other
+func sitemapPresence(sitemapURLSet map[string]bool, sources []string) {
+
+	crawledURLs, err := readURLSet(urlExtractFile)
 	if err != nil {
-		fmt.Printf(red+"\nError. parameterKeys. Cannot flush writer: %v\n"+reset, err)
-		os.Exit(1)
+		fmt.Println(red+"Error. sitemapPresence. Cannot read the URLs:"+reset, err)
+		return
 	}
-}
 
-// Regex to identify of a parameter key is used in the URL
-func parameterUsage() {
+	folders := make(map[string]*folderPresence)
+	presence := func(folder string) *folderPresence {
+		if folders[folder] == nil {
+			folders[folder] = &folderPresence{Folder: folder}
+		}
+		return folders[folder]
+	}
 
-	//URLs containing parameters
-	parameterUsageRegex := `
+	var bothURLs, notInSitemapURLs, notCrawledURLs []string
+	for crawledURL := range crawledURLs {
+		if sitemapURLSet[crawledURL] {
+			presence(level1Folder(crawledURL)).Both++
+			bothURLs = append(bothURLs, crawledURL)
+			continue
+		}
+		presence(level1Folder(crawledURL)).NotInSitemap++
+		notInSitemapURLs = append(notInSitemapURLs, crawledURL)
+	}
+	for sitemapURL := range sitemapURLSet {
+		if !crawledURLs[sitemapURL] {
+			presence(level1Folder(sitemapURL)).NotCrawled++
+			notCrawledURLs = append(notCrawledURLs, sitemapURL)
+		}
+	}
+
+	var folderPresences []folderPresence
+	for _, folder := range folders {
+		folderPresences = append(folderPresences, *folder)
+	}
+	sort.Slice(folderPresences, func(i, j int) bool {
+		totalI := folderPresences[i].Both + folderPresences[i].NotInSitemap + folderPresences[i].NotCrawled
+		totalJ := folderPresences[j].Both + folderPresences[j].NotInSitemap + folderPresences[j].NotCrawled
+		if totalI != totalJ {
+			return totalI > totalJ
+		}
+		return folderPresences[i].Folder < folderPresences[j].Folder
+	})
+
+	// Folders found only in the sitemaps, only in the crawl, or with all their URLs in both
+	var bothFolders, notCrawledFolders, notInSitemapFolders []string
+	for _, folder := range folderPresences {
+		if !isFolder(folder.Folder) {
+			continue
+		}
+		switch {
+		case folder.NotCrawled > 0 && folder.NotInSitemap == 0 && folder.Both == 0:
+			notCrawledFolders = append(notCrawledFolders, folder.Folder)
+		case folder.NotInSitemap > 0 && folder.NotCrawled == 0 && folder.Both == 0:
+			notInSitemapFolders = append(notInSitemapFolders, folder.Folder)
+		case folder.Both > 0 && folder.NotInSitemap == 0 && folder.NotCrawled == 0:
+			bothFolders = append(bothFolders, folder.Folder)
+		}
+	}
+
+	presenceSegment := &segment{
+		Name:          "sl_sitemap_presence",
+		Comments:      []string{"Sitemaps: " + strings.Join(sources, ", ")},
+		AnalysisTitle: "Sitemap presence folder analysis",
+	}
+	presenceSegment.Values = append(presenceSegment.Values,
+		folderAndURLValue("In sitemap, not crawled", notCrawledFolders, notCrawledURLs, sitemapMaxListedURLs),
+		folderAndURLValue("Crawled, not in sitemap", notInSitemapFolders, notInSitemapURLs, sitemapMaxListedURLs),
+		folderAndURLValue("In sitemap & crawled", bothFolders, bothURLs, sitemapMaxListedURLs),
+		segmentValue{Label: "~Other", Rules: []segmentRule{{"path", "/*"}},
+			Comments: []string{"Unclassified. Includes the URLs which are not listed above"}})
+
+	// The number of URLs of each class in each folder. The count is the number of crawled URLs
+	for _, folder := range folderPresences {
+		presenceSegment.Analysis = append(presenceSegment.Analysis, FolderCount{
+			fmt.Sprintf("%s in sitemap & crawled: %d, crawled not in sitemap: %d, in sitemap not crawled: %d", folder.Folder, folder.Both, folder.NotInSitemap, folder.NotCrawled),
+			folder.Both + folder.NotInSitemap,
+		})
+	}
+
+	addSegment(presenceSegment)
+
+	fmt.Printf(purple+"Sitemaps: %d URLs in sitemap & crawled, %d crawled not in sitemap, %d in sitemap not crawled\n"+reset,
+		len(crawledURLs)-len(notInSitemapURLs), len(notInSitemapURLs), len(notCrawledURLs))
+
+	csvContent, err := renderFolderPresenceCSV(folderPresences)
+	if err != nil {
+		fmt.Println(red+"Error. sitemapPresence. Cannot render the sitemap presence report:"+reset, err)
+		return
+	}
+	if err := os.WriteFile(cacheFolder+"/"+sitemapPresenceFile, []byte(csvContent), 0644); err != nil {
+		fmt.Println(red+"Error. sitemapPresence. Cannot write the sitemap presence report:"+reset, err)
+	}
+}
+
+// Render the sitemap presence of each folder as CSV
+func renderFolderPresenceCSV(folderPresences []folderPresence) (string, error) {
+
+	var csvContent strings.Builder
+	writer := csv.NewWriter(&csvContent)
+
+	if err := writer.Write([]string{"Folder", "In sitemap & crawled", "Crawled, not in sitemap", "In sitemap, not crawled"}); err != nil {
+		return "", err
+	}
+	for _, folder := range folderPresences {
+		if err := writer.Write([]string{folder.Folder, strconv.Itoa(folder.Both), strconv.Itoa(folder.NotInSitemap), strconv.Itoa(folder.NotCrawled)}); err != nil {
+			return "", err
+		}
+	}
+	writer.Flush()
+
+	return csvContent.String(), writer.Error()
+}
+
+// Folder labels. Labels longer than maxLabelLength characters are truncated
+var maxLabelLength = 40
+
+// Optional folder → friendly label mapping file (labelMappingFile in segmentifyLite.ini)
+var labelMappingFile = "labelMapping.csv"
+var labelMapping = make(map[string]string)
+
+// Normalise the folder labels of a segment. Mapped to a friendly label, otherwise decoded, title-cased & truncated
+// Labels used more than once are qualified (e.g. "Shoes (www)", "Shoes (m)"), then numbered if they are still not unique
+func normaliseLabels(rawLabels []string, qualifiers []string) []string {
+
+	labels := make([]string, len(rawLabels))
+	for i, rawLabel := range rawLabels {
+		labels[i] = friendlyLabel(rawLabel)
+	}
+
+	// Qualify the labels used more than once
+	labelIndexes := make(map[string][]int)
+	for i, label := range labels {
+		labelIndexes[strings.ToLower(label)] = append(labelIndexes[strings.ToLower(label)], i)
+	}
+	for _, indexes := range labelIndexes {
+		if len(indexes) < 2 {
+			continue
+		}
+		shortQualifiers := make(map[string]bool)
+		for _, i := range indexes {
+			shortQualifiers[shortQualifier(qualifiers[i])] = true
+		}
+		for _, i := range indexes {
+			// The first part of the host is used when it is enough to tell the labels apart
+			qualifier := qualifiers[i]
+			if len(shortQualifiers) == len(indexes) {
+				qualifier = shortQualifier(qualifier)
+			}
+			if qualifier != "" {
+				labels[i] = labels[i] + " (" + qualifier + ")"
+			}
+		}
+	}
+
+	// Number the labels which are still not unique. The number is increased until the numbered label is not used by another label
+	takenLabels := make(map[string]bool)
+	for _, label := range labels {
+		takenLabels[strings.ToLower(label)] = true
+	}
+	usedLabels := make(map[string]bool)
+	for i, label := range labels {
+		key := strings.ToLower(label)
+		if !usedLabels[key] {
+			usedLabels[key] = true
+			continue
+		}
+		for n := 2; ; n++ {
+			numberedLabel := fmt.Sprintf("%s %d", label, n)
+			if !takenLabels[strings.ToLower(numberedLabel)] {
+				labels[i] = numberedLabel
+				takenLabels[strings.ToLower(numberedLabel)] = true
+				usedLabels[strings.ToLower(numberedLabel)] = true
+				break
+			}
+		}
+	}
+
+	return labels
+}
+
+// A friendly label for a folder. The mapping file is used first
+func friendlyLabel(rawLabel string) string {
+
+	decodedLabel, err := url.PathUnescape(rawLabel)
+	if err != nil {
+		decodedLabel = rawLabel
+	}
+
+	if mappedLabel, found := labelMapping[strings.ToLower(decodedLabel)]; found {
+		return mappedLabel
+	}
+
+	// Separators become spaces, each word is title-cased
+	label := strings.Map(func(r rune) rune {
+		if r == '-' || r == '_' || r == '+' {
+			return ' '
+		}
+		return r
+	}, decodedLabel)
+	// "~" is reserved for the catch-all value
+	label = strings.TrimLeft(label, "~")
+	label = titleCase(strings.Join(strings.Fields(label), " "))
+
+	if runes := []rune(label); len(runes) > maxLabelLength {
+		label = strings.TrimSpace(string(runes[:maxLabelLength-3])) + "..."
+	}
+
+	if label == "" {
+		return rawLabel
+	}
+
+	return label
+}
+
+// Title-case each word. Words start after a space or a forward-slash
+func titleCase(text string) string {
+
+	runes := []rune(strings.ToLower(text))
+	for i := range runes {
+		if i == 0 || runes[i-1] == ' ' || runes[i-1] == '/' {
+			runes[i] = unicode.ToUpper(runes[i])
+		}
+	}
+
+	return string(runes)
+}
+
+// The first part of a host, used to qualify labels. "www.example.com" → "www"
+func shortQualifier(qualifier string) string {
+
+	shortQualifier, _, _ := strings.Cut(qualifier, ".")
+
+	return shortQualifier
+}
+
+// Get the folder → friendly label mapping
+// Each line of the mapping file is a folder (as found in the URL, e.g. mens/shoes for a level 2 folder) followed by its label
+func getLabelMapping() {
+
+	cfg, err := ini.Load("segmentifyLite.ini")
+	if err == nil && cfg.Section("").HasKey("labelMappingFile") {
+		labelMappingFile = cfg.Section("").Key("labelMappingFile").String()
+	}
+
+	file, err := os.Open(labelMappingFile)
+	if err != nil {
+		// The mapping file is optional
+		return
+	}
+
+	defer func() {
+		if err := file.Close(); err != nil {
+			fmt.Println(red+"Error. getLabelMapping. Closing (27):"+reset, err)
+		}
+	}()
+
+	reader := csv.NewReader(file)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = 2
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		fmt.Println(red+"Error. getLabelMapping. Cannot read the label mapping file:"+reset, err)
+		return
+	}
+
+	for _, record := range records {
+		folder := strings.Trim(strings.TrimSpace(record[0]), "/")
+		if decodedFolder, err := url.PathUnescape(folder); err == nil {
+			folder = decodedFolder
+		}
+		labelMapping[strings.ToLower(folder)] = strings.TrimSpace(record[1])
+	}
+
+	fmt.Printf(green+"Label mapping: %d folders mapped (%s)\n"+reset, len(labelMapping), labelMappingFile)
+}
+
+// Generate regex for level 1 and 2 folders
+func level1and2Folders() {
+
+	//Level1 folders
+	//Get the threshold. Use the level 1 slashCount
+	_, thresholdValueL1 := levelThreshold(urlExtractFile, slashCountLevel1)
+	level1Threshold = thresholdValueL1
+
+	//generate the regex
+	segmentFolders(thresholdValueL1, slashCountLevel1)
+
+	//Level2 folders
+	//Get the threshold. Use the level 2 slashCount
+	_, thresholdValueL2 := levelThreshold(urlExtractFile, slashCountLevel2)
+	level2Threshold = thresholdValueL2
+
+	//Level2 folders
+	segmentFolders(thresholdValueL2, slashCountLevel2)
+}
+
+// Segmentation model. The generated segments are built in memory and rendered to each export format
+type segmentation struct {
+	Organisation string     `json:"organisation"`
+	Project      string     `json:"project"`
+	Version      string     `json:"version"`
+	Generated    string     `json:"generated,omitempty"`
+	Comments     []string   `json:"comments,omitempty"`
+	Segments     []*segment `json:"segments"`
+}
+
+// A segment, its values and the URL analysis written as comments
+type segment struct {
+	Name          string         `json:"name"`
+	Comments      []string       `json:"comments,omitempty"`
+	Values        []segmentValue `json:"values"`
+	AnalysisTitle string         `json:"analysisTitle,omitempty"`
+	Analysis      []FolderCount  `json:"analysis,omitempty"`
+}
+
+// A segment value. The operator ("or", "and") groups the rules, when empty the rules are listed as is
+type segmentValue struct {
+	Label    string        `json:"label"`
+	Operator string        `json:"operator,omitempty"`
+	Rules    []segmentRule `json:"rules"`
+	Comments []string      `json:"comments,omitempty"`
+}
+
+// A segment rule. Field is path, url, query etc., the pattern may be a wildcard pattern or "rx:" regex
+type segmentRule struct {
+	Field   string `json:"field"`
+	Pattern string `json:"pattern"`
+}
+
+// Create the segmentation model used to store the generated segments
+func newSegmentModel() {
+
+	// Get the user's local time zone for the header
+	userLocation, err := time.LoadLocation("") // Load the default local time zone
+	if err != nil {
+		fmt.Println("\nError loading user's location:", err)
+		userLocation = time.UTC
+	}
+	// Get the current date and time in the user's local time zone
+	currentTime := time.Now().In(userLocation)
+
+	segmentModel = &segmentation{
+		Organisation: organisation,
+		Project:      project,
+		Version:      version,
+	}
+
+	// The generation timestamp can be omitted so that runs on the same crawl produce identical files
+	if !omitTimestamp {
+		segmentModel.Generated = currentTime.Format(time.RFC1123)
+	}
+
+	// The URL filter applied when exporting the URLs
+	if description := activeURLFilter.description(); description != "" {
+		segmentModel.Comments = append(segmentModel.Comments, "URL filter: "+description)
+	}
+}
+
+// Add a segment to the segmentation model
+func addSegment(newSegment *segment) {
+	segmentModel.Segments = append(segmentModel.Segments, newSegment)
+}
+
+// The home page value used at the start of most segments
+func homeValue() segmentValue {
+	return segmentValue{Label: "Home", Rules: []segmentRule{{"path", "/"}}}
+}
+
+// The catch-all value used at the end of every generated segment
+func otherValue() segmentValue {
+	return segmentValue{Label: "~Other", Rules: []segmentRule{{"path", "/*"}}}
+}
+
+// Render the segmentation model as Botify segmentation DSL
+func renderBotifyDSL(model *segmentation) string {
+
+	var dsl strings.Builder
+
+	dsl.WriteString(fmt.Sprintf("# Regex made with love using segmentifyLite %s\n", model.Version))
+	dsl.WriteString(fmt.Sprintf("# Organisation name: %s\n", model.Organisation))
+	dsl.WriteString(fmt.Sprintf("# Project name: %s\n", model.Project))
+	if model.Generated != "" {
+		dsl.WriteString(fmt.Sprintf("# Generated %s\n", model.Generated))
+	}
+	for _, comment := range model.Comments {
+		dsl.WriteString("# " + comment + "\n")
+	}
+
+	for _, modelSegment := range model.Segments {
+		dsl.WriteString(renderSegmentDSL(modelSegment))
+	}
+
+	return dsl.String()
+}
+
+// Render a single segment as Botify segmentation DSL
+func renderSegmentDSL(modelSegment *segment) string {
+
+	var dsl strings.Builder
+
+	dsl.WriteString(fmt.Sprintf("\n\n[segment:%s]\n", modelSegment.Name))
+	for _, comment := range modelSegment.Comments {
+		dsl.WriteString("# " + comment + "\n")
+	}
+
+	for _, value := range modelSegment.Values {
+		dsl.WriteString("@" + value.Label + "\n")
+		for _, comment := range value.Comments {
+			dsl.WriteString("# " + comment + "\n")
+		}
+		if value.Operator != "" {
+			dsl.WriteString(value.Operator + " (\n")
+		}
+		for _, rule := range value.Rules {
+			dsl.WriteString(rule.Field + " " + rule.Pattern + "\n")
+		}
+		if value.Operator != "" {
+			dsl.WriteString(")\n")
+		}
+		dsl.WriteString("\n")
+	}
+	dsl.WriteString(fmt.Sprintf("# ----End of %s Segment----\n", modelSegment.Name))
+
+	//Insert the number of URLs found for each value as comments
+	if len(modelSegment.Analysis) > 0 {
+		dsl.WriteString(fmt.Sprintf("\n# ----%s----\n", modelSegment.AnalysisTitle))
+		for _, analysisCount := range modelSegment.Analysis {
+			dsl.WriteString(fmt.Sprintf("# --%s (URLs found: %d)\n", analysisCount.Text, analysisCount.Count))
+		}
+	}
+
+	return dsl.String()
+}
+
+// Render the segmentation model as a JSON document
+func renderJSON(model *segmentation) ([]byte, error) {
+	return json.MarshalIndent(model, "", "  ")
+}
+
+// Render the segmentation model as a YAML document
+// Strings are written as double-quoted scalars so no escaping rules other than JSON's are needed
+func renderYAML(model *segmentation) string {
+
+	var yaml strings.Builder
+	quote := strconv.Quote
+
+	yaml.WriteString(fmt.Sprintf("organisation: %s\n", quote(model.Organisation)))
+	yaml.WriteString(fmt.Sprintf("project: %s\n", quote(model.Project)))
+	yaml.WriteString(fmt.Sprintf("version: %s\n", quote(model.Version)))
+	if model.Generated != "" {
+		yaml.WriteString(fmt.Sprintf("generated: %s\n", quote(model.Generated)))
+	}
+	writeYAMLList(&yaml, "", "comments", model.Comments)
+
+	yaml.WriteString("segments:\n")
+	for _, modelSegment := range model.Segments {
+		yaml.WriteString(fmt.Sprintf("  - name: %s\n", quote(modelSegment.Name)))
+		writeYAMLList(&yaml, "    ", "comments", modelSegment.Comments)
+		yaml.WriteString("    values:\n")
+		for _, value := range modelSegment.Values {
+			yaml.WriteString(fmt.Sprintf("      - label: %s\n", quote(value.Label)))
+			if value.Operator != "" {
+				yaml.WriteString(fmt.Sprintf("        operator: %s\n", quote(value.Operator)))
+			}
+			writeYAMLList(&yaml, "        ", "comments", value.Comments)
+			yaml.WriteString("        rules:\n")
+			for _, rule := range value.Rules {
+				yaml.WriteString(fmt.Sprintf("          - field: %s\n            pattern: %s\n", quote(rule.Field), quote(rule.Pattern)))
+			}
+		}
+		if len(modelSegment.Analysis) > 0 {
+			yaml.WriteString(fmt.Sprintf("    analysisTitle: %s\n", quote(modelSegment.AnalysisTitle)))
+			yaml.WriteString("    analysis:\n")
+			for _, analysisCount := range modelSegment.Analysis {
+				yaml.WriteString(fmt.Sprintf("      - text: %s\n        count: %d\n", quote(analysisCount.Text), analysisCount.Count))
+			}
+		}
+	}
+
+	return yaml.String()
+}
+
+// Write a YAML list of strings. Empty lists are omitted
+func writeYAMLList(yaml *strings.Builder, indent string, key string, items []string) {
+
+	if len(items) == 0 {
+		return
+	}
+	yaml.WriteString(indent + key + ":\n")
+	for _, item := range items {
+		yaml.WriteString(indent + "  - " + strconv.Quote(item) + "\n")
+	}
+}
+
+// Render the URL counts of every segment as CSV
+func renderFolderCountsCSV(model *segmentation) (string, error) {
+
+	var csvContent strings.Builder
+	writer := csv.NewWriter(&csvContent)
+
+	if err := writer.Write([]string{"Segment", "Value", "URLs"}); err != nil {
+		return "", err
+	}
+	for _, modelSegment := range model.Segments {
+		for _, analysisCount := range modelSegment.Analysis {
+			if err := writer.Write([]string{modelSegment.Name, analysisCount.Text, strconv.Itoa(analysisCount.Count)}); err != nil {
+				return "", err
+			}
+		}
+	}
+	writer.Flush()
+
+	return csvContent.String(), writer.Error()
+}
+
+// Render a Markdown summary of the segmentation model
+func renderMarkdown(model *segmentation) string {
+
+	var markdown strings.Builder
+
+	markdown.WriteString(fmt.Sprintf("# segmentifyLite %s\n\n", model.Version))
+	markdown.WriteString(fmt.Sprintf("- **Organisation:** %s\n", model.Organisation))
+	markdown.WriteString(fmt.Sprintf("- **Project:** %s\n", model.Project))
+	if model.Generated != "" {
+		markdown.WriteString(fmt.Sprintf("- **Generated:** %s\n", model.Generated))
+	}
+	for _, comment := range model.Comments {
+		markdown.WriteString(fmt.Sprintf("- %s\n", comment))
+	}
+
+	markdown.WriteString("\n## Segments\n\n| Segment | Values |\n| --- | --- |\n")
+	for _, modelSegment := range model.Segments {
+		markdown.WriteString(fmt.Sprintf("| %s | %d |\n", markdownEscape(modelSegment.Name), len(modelSegment.Values)))
+	}
+
+	for _, modelSegment := range model.Segments {
+		markdown.WriteString(fmt.Sprintf("\n## %s\n\n", markdownEscape(modelSegment.Name)))
+		for _, comment := range modelSegment.Comments {
+			markdown.WriteString(fmt.Sprintf("> %s\n\n", markdownEscape(comment)))
+		}
+
+		var labels []string
+		for _, value := range modelSegment.Values {
+			labels = append(labels, "`"+value.Label+"`")
+		}
+		markdown.WriteString(fmt.Sprintf("**Values:** %s\n", strings.Join(labels, ", ")))
+
+		if len(modelSegment.Analysis) > 0 {
+			markdown.WriteString("\n| Value | URLs |\n| --- | ---: |\n")
+			for _, analysisCount := range modelSegment.Analysis {
+				markdown.WriteString(fmt.Sprintf("| %s | %d |\n", markdownEscape(analysisCount.Text), analysisCount.Count))
+			}
+		}
+	}
+
+	return markdown.String()
+}
+
+// Escape the characters which break a Markdown table
+func markdownEscape(text string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(text)
+}
+
+// Parse Botify segmentation DSL into a segmentation model
+// The segmentifyLite header is read back into the model, other comments before the first segment are kept as model comments
+// "End of" markers are dropped, they are regenerated when rendering
+func parseBotifyDSL(dsl string) (*segmentation, error) {
+
+	model := &segmentation{}
+
+	var currentSegment *segment
+	var currentValue *segmentValue
+	inAnalysis := false
+
+	// The current value is copied back into the segment when the next value or segment starts
+	closeValue := func() {
+		if currentSegment != nil && currentValue != nil {
+			currentSegment.Values = append(currentSegment.Values, *currentValue)
+		}
+		currentValue = nil
+	}
+
+	for lineNo, line := range strings.Split(dsl, "\n") {
+		trimmedLine := strings.TrimSpace(line)
+
+		switch {
+		case trimmedLine == "":
+			continue
+
+		case strings.HasPrefix(trimmedLine, "[segment:") && strings.HasSuffix(trimmedLine, "]"):
+			closeValue()
+			currentSegment = &segment{Name: strings.TrimSuffix(strings.TrimPrefix(trimmedLine, "[segment:"), "]")}
+			model.Segments = append(model.Segments, currentSegment)
+			inAnalysis = false
+
+		case strings.HasPrefix(trimmedLine, "#"):
+			comment := strings.TrimSpace(strings.TrimPrefix(trimmedLine, "#"))
+			switch {
+			case currentSegment == nil && strings.HasPrefix(comment, "Regex made with love using segmentifyLite "):
+				model.Version = strings.TrimPrefix(comment, "Regex made with love using segmentifyLite ")
+			case currentSegment == nil && strings.HasPrefix(comment, "Organisation name: "):
+				model.Organisation = strings.TrimPrefix(comment, "Organisation name: ")
+			case currentSegment == nil && strings.HasPrefix(comment, "Project name: "):
+				model.Project = strings.TrimPrefix(comment, "Project name: ")
+			case currentSegment == nil && strings.HasPrefix(comment, "Generated "):
+				model.Generated = strings.TrimPrefix(comment, "Generated ")
+			case currentSegment == nil:
+				model.Comments = append(model.Comments, comment)
+			case strings.HasPrefix(comment, "----End of"):
+				closeValue()
+			case strings.HasPrefix(comment, "----") && strings.HasSuffix(comment, "----"):
+				closeValue()
+				currentSegment.AnalysisTitle = strings.Trim(comment, "-")
+				inAnalysis = true
+			case inAnalysis && analysisLinePattern.MatchString(comment):
+				match := analysisLinePattern.FindStringSubmatch(comment)
+				count, _ := strconv.Atoi(match[2])
+				currentSegment.Analysis = append(currentSegment.Analysis, FolderCount{match[1], count})
+			case currentValue != nil:
+				currentValue.Comments = append(currentValue.Comments, comment)
+			default:
+				currentSegment.Comments = append(currentSegment.Comments, comment)
+			}
+
+		case currentSegment == nil:
+			return nil, fmt.Errorf("line %d: %q is not part of a segment", lineNo+1, trimmedLine)
+
+		case strings.HasPrefix(trimmedLine, "@"):
+			closeValue()
+			currentValue = &segmentValue{Label: strings.TrimPrefix(trimmedLine, "@")}
+
+		case currentValue == nil:
+			return nil, fmt.Errorf("line %d: rule %q is not part of a value", lineNo+1, trimmedLine)
+
+		case trimmedLine == ")":
+			continue
+
+		case strings.HasSuffix(trimmedLine, "("):
+			currentValue.Operator = strings.TrimSpace(strings.TrimSuffix(trimmedLine, "("))
+
+		default:
+			fields := strings.SplitN(trimmedLine, " ", 2)
+			if len(fields) != 2 {
+				return nil, fmt.Errorf("line %d: invalid rule %q", lineNo+1, trimmedLine)
+			}
+			currentValue.Rules = append(currentValue.Rules, segmentRule{fields[0], strings.TrimSpace(fields[1])})
+		}
+	}
+	closeValue()
+
+	return model, nil
+}
+
+// URL analysis comment lines. "--text (URLs found: 123)"
+var analysisLinePattern = regexp.MustCompile(`^--(.*) \(URLs found: (\d+)\)$`)
+
+// Export the segmentation model. The Botify DSL is saved to the regex output file and every format is saved to the cache folder
+func exportSegmentation() {
+
+	dsl := renderBotifyDSL(segmentModel)
+
+	jsonContent, err := renderJSON(segmentModel)
+	if err != nil {
+		fmt.Printf(red+"Error. exportSegmentation. Cannot render JSON: %v\n"+reset, err)
+	}
+
+	csvContent, err := renderFolderCountsCSV(segmentModel)
+	if err != nil {
+		fmt.Printf(red+"Error. exportSegmentation. Cannot render CSV: %v\n"+reset, err)
+	}
+
+	exports := []struct {
+		path    string
+		content string
+	}{
+		{regexOutputFile, dsl},
+		{cacheFolder + "/" + exportDSLFile, dsl},
+		{cacheFolder + "/" + exportJSONFile, string(jsonContent)},
+		{cacheFolder + "/" + exportYAMLFile, renderYAML(segmentModel)},
+		{cacheFolder + "/" + exportCSVFile, csvContent},
+		{cacheFolder + "/" + exportMarkdownFile, renderMarkdown(segmentModel)},
+	}
+
+	//Segments converted to other tools' formats. Constructs which cannot be translated exactly are reported as warnings
+	for _, converter := range segmentConverters {
+		content, warnings := converter.convert(segmentModel)
+		for _, warning := range warnings {
+			fmt.Printf(yellow+"Warning. %s: %s\n"+reset, converter.name, warning)
+		}
+		exports = append(exports, struct {
+			path    string
+			content string
+		}{cacheFolder + "/" + converter.fileName, content})
+	}
+
+	for _, export := range exports {
+		if err := os.WriteFile(export.path, []byte(export.content), 0644); err != nil {
+			fmt.Printf(red+"Error. exportSegmentation. Cannot write %s: %v\n"+reset, export.path, err)
+		}
+	}
+}
+
+// Segment converters. Each converts the segmentation model to another tool's format
+// and returns warnings for the Botify constructs which cannot be translated exactly
+type segmentConverter struct {
+	name     string
+	fileName string
+	convert  func(model *segmentation) (string, []string)
+}
+
+var segmentConverters = []segmentConverter{
+	{"GA4 content groups", "ga4ContentGroups.json", convertGA4},
+	{"Search Console page filters", "searchConsoleFilters.txt", convertSearchConsole},
+	{"Screaming Frog segments", "screamingFrogSegments.json", convertScreamingFrog},
+	{"Python regex mapping", "segmentMapping.py", convertPython},
+}
+
+// Maximum length of a Search Console regex filter
+var searchConsoleMaxRegexLength = 4096
+
+// Convert a rule to a regex matching the full URL. The regex is unanchored unless the rule itself is anchored
+// Wildcards in path rules do not match beyond the path, "$" at the end of a path regex matches the end of the path
+func ruleToURLRegex(rule segmentRule) (string, error) {
+
+	isRegex := strings.HasPrefix(rule.Pattern, "rx:")
+	pattern := strings.TrimPrefix(rule.Pattern, "rx:")
+
+	switch strings.ToLower(rule.Field) {
+	case "url":
+		if isRegex {
+			return pattern, nil
+		}
+		return "^" + globToRegex(pattern, ".*") + "$", nil
+
+	case "path":
+		origin := `^https?://[^/?#]+`
+		if !isRegex {
+			return origin + globToRegex(pattern, "[^?#]*") + `(?:[?#].*)?$`, nil
+		}
+		if strings.HasSuffix(pattern, "$") && !strings.HasSuffix(pattern, `\$`) {
+			pattern = strings.TrimSuffix(pattern, "$") + `(?:[?#].*)?$`
+		}
+		if strings.HasPrefix(pattern, "^") {
+			return origin + strings.TrimPrefix(pattern, "^"), nil
+		}
+		return origin + "[^?#]*?" + pattern, nil
+
+	case "query":
+		origin := `^[^?#]*\?`
+		if !isRegex {
+			return origin + globToRegex(pattern, ".*") + "$", nil
+		}
+		if strings.HasPrefix(pattern, "^") {
+			return origin + strings.TrimPrefix(pattern, "^"), nil
+		}
+		return origin + ".*?" + pattern, nil
+	}
+
+	return "", fmt.Errorf("the %q field is not supported", rule.Field)
+}
+
+// Convert a Botify wildcard pattern to a regex. Each "*" is replaced with the wildcard regex
+func globToRegex(pattern string, wildcard string) string {
+
+	parts := strings.Split(pattern, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+
+	return strings.Join(parts, wildcard)
+}
+
+// Convert a value to the regexes of its rules. "or" values (and single rules) match any rule, other values match all rules
+func valueRegexes(value segmentValue) (regexes []string, matchAll bool, warnings []string) {
+
+	for _, rule := range value.Rules {
+		regex, err := ruleToURLRegex(rule)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("@%s: rule %q skipped, %v", value.Label, rule.Field+" "+rule.Pattern, err))
+			continue
+		}
+		regexes = append(regexes, regex)
+	}
+
+	matchAll = len(value.Rules) > 1 && !strings.EqualFold(value.Operator, "or")
+
+	return regexes, matchAll, warnings
+}
+
+// Warn for each wildcard rule of a value. "*" is translated to a regex, which may not match exactly the same URLs as in Botify
+func wildcardWarnings(value segmentValue) (warnings []string) {
+
+	for _, rule := range value.Rules {
+		if strings.HasPrefix(rule.Pattern, "rx:") || !strings.Contains(rule.Pattern, "*") {
+			continue
+		}
+		regex, err := ruleToURLRegex(rule)
+		if err != nil {
+			continue
+		}
+		warnings = append(warnings, fmt.Sprintf("@%s: wildcard rule %q translated to the regex %s, check that it matches the expected URLs", value.Label, rule.Field+" "+rule.Pattern, regex))
+	}
+
+	return warnings
+}
+
+// Join regexes with alternation
+func anyRegex(regexes []string) string {
+
+	if len(regexes) == 1 {
+		return regexes[0]
+	}
+
+	return "(?:" + strings.Join(regexes, ")|(?:") + ")"
+}
+
+// Wrap an unanchored regex so that it matches the full URL
+func fullMatchRegex(regex string) string {
+	return ".*(?:" + regex + ").*"
+}
+
+// Check that a regex is valid RE2, the syntax used by Google Analytics & Search Console
+func checkRE2(regex string) error {
+	_, err := regexp.Compile(regex)
+	return err
+}
+
+// Is the value the catch-all value added at the end of the generated segments
+func isCatchAllValue(value segmentValue) bool {
+	return len(value.Rules) == 1 && value.Rules[0].Field == "path" && value.Rules[0].Pattern == "/*"
+}
+
+// Convert the segmentation model to GA4 content groups
+// Each segment becomes a content group, evaluated in order (e.g. a GTM RegEx Table variable setting content_group)
+// Regexes are RE2 and must fully match page_location
+func convertGA4(model *segmentation) (string, []string) {
+
+	type ga4Rule struct {
+		ContentGroup string `json:"contentGroup"`
+		Regex        string `json:"regex"`
+	}
+	type ga4ContentGroup struct {
+		Name         string    `json:"name"`
+		Dimension    string    `json:"dimension"`
+		MatchType    string    `json:"matchType"`
+		Rules        []ga4Rule `json:"rules"`
+		DefaultValue string    `json:"defaultValue"`
+	}
+	type ga4Document struct {
+		Generator     string            `json:"generator"`
+		Organisation  string            `json:"organisation"`
+		Project       string            `json:"project"`
+		ContentGroups []ga4ContentGroup `json:"contentGroups"`
+		Warnings      []string          `json:"warnings,omitempty"`
+	}
+
+	document := ga4Document{Generator: "segmentifyLite " + model.Version, Organisation: model.Organisation, Project: model.Project}
+	var warnings []string
+
+	for _, modelSegment := range model.Segments {
+		contentGroup := ga4ContentGroup{Name: modelSegment.Name, Dimension: "page_location", MatchType: "FULL_REGEXP", DefaultValue: "(other)"}
+
+		for _, value := range modelSegment.Values {
+			if isCatchAllValue(value) {
+				contentGroup.DefaultValue = value.Label
+				continue
+			}
+
+			regexes, matchAll, valueWarnings := valueRegexes(value)
+			valueWarnings = append(valueWarnings, wildcardWarnings(value)...)
+			for _, warning := range valueWarnings {
+				warnings = append(warnings, modelSegment.Name+" "+warning)
+			}
+			if len(regexes) == 0 {
+				continue
+			}
+			if matchAll {
+				warnings = append(warnings, fmt.Sprintf("%s @%s: all %d rules must match, RE2 cannot combine them in one regex. Only the first rule is used", modelSegment.Name, value.Label, len(regexes)))
+				regexes = regexes[:1]
+			}
+
+			regex := fullMatchRegex(anyRegex(regexes))
+			if err := checkRE2(regex); err != nil {
+				warnings = append(warnings, fmt.Sprintf("%s @%s: regex is not valid RE2 (%v)", modelSegment.Name, value.Label, err))
+			}
+			contentGroup.Rules = append(contentGroup.Rules, ga4Rule{value.Label, regex})
+		}
+
+		document.ContentGroups = append(document.ContentGroups, contentGroup)
+	}
+
+	document.Warnings = warnings
+	content, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return "", append(warnings, err.Error())
+	}
+
+	return string(content), warnings
+}
+
+// Convert the segmentation model to Search Console page filters ("Custom (regex)", "Matches regex")
+// Each filter is applied independently, the first-match ordering of Botify cannot be reproduced
+func convertSearchConsole(model *segmentation) (string, []string) {
+
+	var filters strings.Builder
+	var warnings []string
+
+	for _, modelSegment := range model.Segments {
+		filters.WriteString(fmt.Sprintf("\n[%s]\n", modelSegment.Name))
+
+		valuesConverted := 0
+		for _, value := range modelSegment.Values {
+			if isCatchAllValue(value) {
+				continue
+			}
+
+			regexes, matchAll, valueWarnings := valueRegexes(value)
+			valueWarnings = append(valueWarnings, wildcardWarnings(value)...)
+			for _, warning := range valueWarnings {
+				warnings = append(warnings, modelSegment.Name+" "+warning)
+			}
+			if len(regexes) == 0 {
+				continue
+			}
+			if matchAll {
+				warnings = append(warnings, fmt.Sprintf("%s @%s: all %d rules must match, a single Search Console filter cannot combine them. Only the first rule is used", modelSegment.Name, value.Label, len(regexes)))
+				regexes = regexes[:1]
+			}
+
+			regex := anyRegex(regexes)
+			if err := checkRE2(regex); err != nil {
+				warnings = append(warnings, fmt.Sprintf("%s @%s: regex is not valid RE2 (%v)", modelSegment.Name, value.Label, err))
+			}
+			if len(regex) > searchConsoleMaxRegexLength {
+				warnings = append(warnings, fmt.Sprintf("%s @%s: regex is %d characters, Search Console accepts %d", modelSegment.Name, value.Label, len(regex), searchConsoleMaxRegexLength))
+			}
+
+			filters.WriteString(fmt.Sprintf("@%s\n%s\n", value.Label, regex))
+			valuesConverted++
+		}
+
+		if valuesConverted > 1 {
+			warnings = append(warnings, fmt.Sprintf("%s: values are matched in order in Botify. Search Console filters are independent, a URL can match more than one value", modelSegment.Name))
+		}
+	}
+
+	var content strings.Builder
+	content.WriteString(fmt.Sprintf("# Search Console page filters generated by segmentifyLite %s\n", model.Version))
+	content.WriteString(fmt.Sprintf("# Organisation name: %s\n# Project name: %s\n", model.Organisation, model.Project))
+	content.WriteString("# Use with Page > Custom (regex) > Matches regex\n")
+	for _, warning := range warnings {
+		content.WriteString("# Warning: " + warning + "\n")
+	}
+	content.WriteString(filters.String())
+
+	return content.String(), warnings
+}
+
+// Convert the segmentation model to Screaming Frog segments
+// Segments are matched on the Address in order, rules must fully match
+func convertScreamingFrog(model *segmentation) (string, []string) {
+
+	type screamingFrogRule struct {
+		Field    string `json:"field"`
+		Operator string `json:"operator"`
+		Value    string `json:"value"`
+	}
+	type screamingFrogSegment struct {
+		Group string              `json:"group"`
+		Name  string              `json:"name"`
+		Match string              `json:"match"`
+		Rules []screamingFrogRule `json:"rules"`
+	}
+	type screamingFrogDocument struct {
+		Generator    string                 `json:"generator"`
+		Organisation string                 `json:"organisation"`
+		Project      string                 `json:"project"`
+		Segments     []screamingFrogSegment `json:"segments"`
+		Warnings     []string               `json:"warnings,omitempty"`
+	}
+
+	document := screamingFrogDocument{Generator: "segmentifyLite " + model.Version, Organisation: model.Organisation, Project: model.Project}
+	var warnings []string
+
+	for _, modelSegment := range model.Segments {
+		for _, value := range modelSegment.Values {
+			regexes, matchAll, valueWarnings := valueRegexes(value)
+			for _, warning := range valueWarnings {
+				warnings = append(warnings, modelSegment.Name+" "+warning)
+			}
+			if len(regexes) == 0 {
+				continue
+			}
+
+			screamingFrog := screamingFrogSegment{Group: modelSegment.Name, Name: value.Label, Match: "Any"}
+			if matchAll {
+				screamingFrog.Match = "All"
+			}
+			for _, regex := range regexes {
+				screamingFrog.Rules = append(screamingFrog.Rules, screamingFrogRule{"Address", "Matches Regex", fullMatchRegex(regex)})
+			}
+			document.Segments = append(document.Segments, screamingFrog)
+		}
+	}
+
+	if len(model.Segments) > 1 {
+		warnings = append(warnings, fmt.Sprintf("a URL belongs to one Screaming Frog segment only. The %d Botify segments are listed as groups, import one group at a time", len(model.Segments)))
+	}
+
+	document.Warnings = warnings
+	content, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return "", append(warnings, err.Error())
+	}
+
+	return string(content), warnings
+}
+
+// Convert the segmentation model to a Python mapping of segment values to regexes
+// Values are listed in Botify order, the first match wins. Values where all rules must match use lookaheads
+func convertPython(model *segmentation) (string, []string) {
+
+	var python strings.Builder
+	var warnings []string
+
+	python.WriteString(fmt.Sprintf("# Segment regex mapping generated by segmentifyLite %s\n", model.Version))
+	python.WriteString(fmt.Sprintf("# Organisation name: %s\n# Project name: %s\n", model.Organisation, model.Project))
+	python.WriteString("# Regexes are searched in the full URL. Values are listed in order, the first match wins\n\n")
+	python.WriteString("import re\n\nSEGMENTS = {\n")
+
+	for _, modelSegment := range model.Segments {
+		python.WriteString(fmt.Sprintf("    %s: [\n", strconv.Quote(modelSegment.Name)))
+		for _, value := range modelSegment.Values {
+			regexes, matchAll, valueWarnings := valueRegexes(value)
+			for _, warning := range valueWarnings {
+				warnings = append(warnings, modelSegment.Name+" "+warning)
+			}
+			if len(regexes) == 0 {
+				continue
+			}
+
+			regex := anyRegex(regexes)
+			if matchAll {
+				regex = "^(?=.*?(?:" + strings.Join(regexes, "))(?=.*?(?:") + "))"
+			}
+			python.WriteString(fmt.Sprintf("        (%s, re.compile(%s)),\n", strconv.Quote(value.Label), strconv.Quote(regex)))
+		}
+		python.WriteString("    ],\n")
+	}
+
+	python.WriteString(`}
+
+
+def segment_url(url):
+    """Return a dict of segment name to the first matching value for the URL."""
+    values = {}
+    for segment, rules in SEGMENTS.items():
+        for label, pattern in rules:
+            if pattern.search(url):
+                values[segment] = label
+                break
+    return values
+`)
+
+	for _, warning := range warnings {
+		python.WriteString("# Warning: " + warning + "\n")
+	}
+
+	return python.String(), warnings
+}
+
+func segmentFolders(thresholdValue int, slashCount int) {
+
+	//Open the input file for reading
+	file, err := os.Open(urlExtractFile)
+	if err != nil {
+		os.Exit(1)
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			fmt.Println(red+"Error. segmentFolders. Closing (5):"+reset, err)
+		}
+	}()
+
+	//Create a scanner to read the file line by line
+	scanner := bufio.NewScanner(file)
+
+	//Map to keep track of counts of unique values
+	FolderCounts := make(map[string]int)
+
+	//Variable to keep track of the total number of records processed
+	totalRecords := 0
+
+	//Counter to track the number of records scanned
+	recordCounter := 0
+
+	//Counter to track the number of folders excluded from the regex
+	noFoldersExcluded := 0
+
+	//Iterate through each line in the file
+	for scanner.Scan() {
+		line := scanner.Text()
+		totalRecords++
+		recordCounter++
+
+		//Check if the line contains a quotation mark, if yes, skip to the next line
+		if strings.Contains(line, "\"") {
+			continue
+		}
+
+		// Is this a product URL?
+		isProductURL = isValidisProductURL(line)
+		if isProductURL {
+			println(line)
+			generatePDPRegex = true
+		}
+
+		//Extract the folder
+		// slashCount = 4 for Level 1 folders
+		// slashCount = 5 for Level 2 folders
+		text, found := folderText(line, slashCount)
+
+		//Update the count for this value if it's not empty
+		if found && text != "" {
+			FolderCounts[text]++
+		}
+	}
+
+	fmt.Printf("\n")
+
+	//Create a slice to hold FolderCount structs
+	var sortedCounts []FolderCount
+
+	//Populate the slice with data from the map
+	for folderName, count := range FolderCounts {
+		if count > thresholdValue {
+			sortedCounts = append(sortedCounts, FolderCount{folderName, count})
+		} else {
+			// Count the number of folders excluded
+			noFoldersExcluded++
+		}
+	}
+
+	//Sort the slice based on counts
+	sort.Sort(ByCount(sortedCounts))
+
+	//Segment name
+	// SlashCount = 4 signals level 1 folders
+	// SlashCount = 5 signals level 2 folders
+	folderSegment := &segment{Name: "sl_level1_folders", AnalysisTitle: "Folder URL analysis"}
+	if slashCount == slashCountLevel2 {
+		folderSegment.Name = "sl_level2_folders"
+	}
+	folderSegment.Values = append(folderSegment.Values, homeValue())
+
+	//Generate the regex
+	var folderValues []segmentValue
+	var folderLabels, folderHosts []string
+	for _, folderValueCount := range sortedCounts {
+		if folderValueCount.Text != "" {
+			//Extract the text between the third and fourth forward-slashes
+			parts := strings.SplitN(folderValueCount.Text, "/", 4)
+			if len(parts) >= 4 && parts[3] != "" {
+				folderLabel := parts[3] //Extract the text between the third and fourth forward-slashes
+				if slashCount == slashCountLevel1 {
+					topFolders = append(topFolders, folderLabel)
+				}
+				folderLabels = append(folderLabels, folderLabel)
+				folderHosts = append(folderHosts, parts[2])
+				folderValues = append(folderValues, segmentValue{
+					Rules: []segmentRule{{"url", "*" + folderValueCount.Text + "/*"}},
+				})
+			}
+		}
+	}
+
+	//Human-readable labels. The same folder on two hosts is qualified by the host
+	for i, label := range normaliseLabels(folderLabels, folderHosts) {
+		folderValues[i].Label = label
+	}
+	folderSegment.Values = append(folderSegment.Values, folderValues...)
+	folderSegment.Values = append(folderSegment.Values, otherValue())
+
+	//The number of URLs found in each folder
+	folderSegment.Analysis = sortedCounts
+
+	addSegment(folderSegment)
+}
+
+// Regex for hosts, protocols, www vs. apex and registrable domains
+func hostNames() {
+
+	//Open the input file
+	file, err := os.Open(urlExtractFile)
+	if err != nil {
+		os.Exit(1)
+	}
+
+	defer func() {
+		if err := file.Close(); err != nil {
+			fmt.Println(red+"Error. hostNames. Closing (7):"+reset, err)
+		}
+	}()
+
+	//Create a scanner to read the file line by line
+	scanner := bufio.NewScanner(file)
+
+	//Maps to keep track of counts of unique values
+	hostCounts := make(map[string]int)
+	protocolCounts := make(map[string]int)
+	wwwCounts := make(map[string]int)
+	domainCounts := make(map[string]int)
+
+	//The hosts seen for each www class, used to build the apex & subdomain rules
+	wwwHosts := make(map[string]map[string]bool)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		//Check if the line contains a quotation mark, if yes, skip to the next line
+		if strings.Contains(line, "\"") {
+			continue
+		}
+
+		//Normalise the host. Lines which are not absolute URLs are ignored
+		scheme, host, ok := normaliseHost(line)
+		if !ok {
+			continue
+		}
+
+		hostCounts[host]++
+		protocolCounts[scheme]++
+		domainCounts[registrableDomain(host)]++
+
+		class := wwwClass(host)
+		wwwCounts[class]++
+		if wwwHosts[class] == nil {
+			wwwHosts[class] = make(map[string]bool)
+		}
+		wwwHosts[class][host] = true
+	}
+
+	//Hosts. The label is the decoded (Unicode) host, the rule uses the ASCII host as found in the crawl
+	sortedHosts := sortCounts(hostCounts)
+	hostSegment := &segment{Name: "sl_host"}
+	for _, hostCount := range sortedHosts {
+		detectedHosts = append(detectedHosts, hostCount.Text)
+		hostSegment.Values = append(hostSegment.Values, segmentValue{
+			Label: decodeHost(hostCount.Text),
+			Rules: []segmentRule{{"url", "rx:" + hostRegex(hostCount.Text, false)}},
+		})
+	}
+	addHostSegment(hostSegment, sortedHosts)
+
+	//Protocol
+	sortedProtocols := sortCounts(protocolCounts)
+	protocolSegment := &segment{Name: "sl_protocol"}
+	for _, protocolCount := range sortedProtocols {
+		protocolSegment.Values = append(protocolSegment.Values, segmentValue{
+			Label: strings.ToUpper(protocolCount.Text),
+			Rules: []segmentRule{{"url", protocolCount.Text + "://*"}},
+		})
+	}
+	addHostSegment(protocolSegment, sortedProtocols)
+
+	//www vs. apex vs. other subdomains
+	wwwSegment := &segment{Name: "sl_www"}
+	for _, class := range []string{"www", "apex", "subdomain"} {
+		if len(wwwHosts[class]) == 0 {
+			continue
+		}
+		if class == "www" {
+			wwwSegment.Values = append(wwwSegment.Values, segmentValue{Label: "www", Rules: []segmentRule{{"url", `rx:^https?://www\.`}}})
+			continue
+		}
+		classValue := segmentValue{Label: class, Operator: "or"}
+		for _, hostCount := range sortCounts(boolsToCounts(wwwHosts[class], hostCounts)) {
+			classValue.Rules = append(classValue.Rules, segmentRule{"url", "rx:" + hostRegex(hostCount.Text, false)})
+		}
+		wwwSegment.Values = append(wwwSegment.Values, classValue)
+	}
+	addHostSegment(wwwSegment, sortCounts(wwwCounts))
+
+	//Registrable domain (eTLD+1)
+	sortedDomains := sortCounts(domainCounts)
+	domainSegment := &segment{Name: "sl_registrable_domain"}
+	for _, domainCount := range sortedDomains {
+		domainSegment.Values = append(domainSegment.Values, segmentValue{
+			Label: decodeHost(domainCount.Text),
+			Rules: []segmentRule{{"url", "rx:" + hostRegex(domainCount.Text, true)}},
+		})
+	}
+	addHostSegment(domainSegment, sortedDomains)
+}
+
+// Add a host related segment, with the catch-all value and the URL analysis, to the segmentation model
+func addHostSegment(hostSegment *segment, sortedCounts []FolderCount) {
+
+	hostSegment.Values = append(hostSegment.Values, otherValue())
+
+	//The number of URLs found for each value. Hosts are decoded for display
+	hostSegment.AnalysisTitle = hostSegment.Name + " URL analysis"
+	for _, valueCount := range sortedCounts {
+		hostSegment.Analysis = append(hostSegment.Analysis, FolderCount{decodeHost(valueCount.Text), valueCount.Count})
+	}
+
+	addSegment(hostSegment)
+}
+
+// Sort a map of counts into a FolderCount slice
+func sortCounts(counts map[string]int) []FolderCount {
+
+	var sortedCounts []FolderCount
+	for text, count := range counts {
+		sortedCounts = append(sortedCounts, FolderCount{text, count})
+	}
+	sort.Sort(ByCount(sortedCounts))
+
+	return sortedCounts
+}
+
+// Pick the counts for a set of keys
+func boolsToCounts(keys map[string]bool, counts map[string]int) map[string]int {
+
+	picked := make(map[string]int)
+	for key := range keys {
+		picked[key] = counts[key]
+	}
+
+	return picked
+}
+
+// Normalise the protocol and host of a URL
+// The host is lower-cased, any trailing dot is removed and the default port for the protocol is dropped
+func normaliseHost(rawURL string) (scheme string, host string, ok bool) {
+
+	parsedURL, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || parsedURL.Host == "" {
+		return "", "", false
+	}
+
+	scheme = strings.ToLower(parsedURL.Scheme)
+	if scheme != "http" && scheme != "https" {
+		return "", "", false
+	}
+
+	host = strings.TrimSuffix(strings.ToLower(parsedURL.Hostname()), ".")
+	if host == "" {
+		return "", "", false
+	}
+
+	hostPort := parsedURL.Port()
+	if hostPort != "" && !(scheme == "http" && hostPort == "80") && !(scheme == "https" && hostPort == "443") {
+		host = host + ":" + hostPort
+	}
+
+	return scheme, host, true
+}
+
+// Generate the regex used to match a host
+// When includeSubdomains is true any subdomain of the host will also match
+func hostRegex(host string, includeSubdomains bool) string {
+
+	hostName, hostPort := splitHostPort(host)
+
+	regex := "^https?://"
+	if includeSubdomains {
+		regex += `([^/?#]+\.)?`
+	}
+	regex += regexp.QuoteMeta(hostName)
+
+	// Non-default ports are part of the host. Default ports may or may not be present in the URL
+	if hostPort != "" {
+		regex += ":" + hostPort + "[/?#]"
+	} else if includeSubdomains {
+		regex += `(:\d+)?[/?#]`
+	} else {
+		regex += `(:80|:443)?[/?#]`
+	}
+
+	return regex
+}
+
+// Split a normalised host into the host name and port
+func splitHostPort(host string) (string, string) {
+
+	if index := strings.LastIndex(host, ":"); index != -1 && !strings.Contains(host[index:], "]") {
+		return host[:index], host[index+1:]
+	}
+
+	return host, ""
+}
+
+// Classify a host as www, apex (the registrable domain itself) or another subdomain
+func wwwClass(host string) string {
+
+	hostName, _ := splitHostPort(host)
+	if strings.HasPrefix(hostName, "www.") {
+		return "www"
+	}
+	if hostName == registrableDomain(hostName) {
+		return "apex"
+	}
+
+	return "subdomain"
+}
+
+// Identify the registrable domain (eTLD+1) of a host using the public suffix list
+// IP addresses, and hosts which are themselves a public suffix, are returned unchanged
+func registrableDomain(host string) string {
+
+	hostName, _ := splitHostPort(host)
+	if net.ParseIP(strings.Trim(hostName, "[]")) != nil {
+		return hostName
+	}
+
+	domain, err := publicsuffix.EffectiveTLDPlusOne(hostName)
+	if err != nil {
+		return hostName
+	}
+
+	return domain
+}
+
+// Decode any punycode (xn--) labels in a host to Unicode for display
+func decodeHost(host string) string {
+
+	hostName, hostPort := splitHostPort(host)
+	decoded, err := idna.Display.ToUnicode(hostName)
+	if err != nil {
+		return host
+	}
+	if hostPort != "" {
+		decoded += ":" + hostPort
+	}
+
+	return decoded
+}
+
+// Regex to identify which parameter keys are used
+// The URL hygiene issues are collected during the same scan and returned
+func parameterKeys() *urlHygieneIssues {
+
+	//Open the input file
+	file, err := os.Open(urlExtractFile)
+	if err != nil {
+		os.Exit(1)
+	}
+
+	defer func() {
+		if err := file.Close(); err != nil {
+			fmt.Println(red+"Error. parameterKeys. Closing (9):"+reset, err)
+		}
+	}()
+
+	//Create a scanner to read the file line by line
+	scanner := bufio.NewScanner(file)
+
+	//Map to keep track of counts of unique values
+	FolderCounts := make(map[string]int)
+
+	//URL hygiene issues
+	hygiene := newURLHygieneIssues()
+
+	//Variable to keep track of the total number of records processed
+	totalRecords := 0
+
+	//Counter to track the number of records scanned
+	recordCounter := 0
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		totalRecords++
+		recordCounter++
+
+		//Check if the line contains a quotation mark, if yes, skip to the next line
+		if strings.Contains(line, "\"") {
+			continue
+		}
+
+		//Check the URL for hygiene issues
+		hygiene.scanURL(line)
+
+		//Count the URLs with parameters, used by the segment templates
+		urlsScanned++
+		if strings.Contains(line, "?") {
+			urlsWithParameters++
+		}
+
+		//Split the line into substrings using question mark as delimiter
+		parts := strings.Split(line, "?")
+
+		//Iterate over the parts after each question mark
+		for _, part := range parts[1:] {
+			//Find the index of the equals sign
+			equalsIndex := strings.Index(part, "=")
+			if equalsIndex != -1 {
+				//Extract the text between the question mark and the equals sign
+				text := part[:equalsIndex]
+
+				//Trim any leading or trailing whitespace
+				text = strings.TrimSpace(text)
+
+				//Update the count for this value
+				FolderCounts[text]++
+			}
+		}
+	}
+
+	//Subtract 2 in order to account for the two header records which are defaults in Botify URL extracts
+	totalRecords -= 2
+
+	fmt.Printf("\n")
+
+	//Create a slice to hold FolderCount structs
+	var sortedCounts []FolderCount
+
+	//Populate the slice with data from the map
+	for folderName, count := range FolderCounts {
+		sortedCounts = append(sortedCounts, FolderCount{folderName, count})
+	}
+
+	//Sort the slice based on counts
+	sort.Sort(ByCount(sortedCounts))
+
+	//Generate the regex
+	parameterSegment := &segment{Name: "sl_parameter_keys", AnalysisTitle: "parameterKeys URL analysis"}
+	for _, folderValueCount := range sortedCounts {
+		parameterSegment.Values = append(parameterSegment.Values, segmentValue{
+			Label: folderValueCount.Text,
+			Rules: []segmentRule{{"query", "*" + folderValueCount.Text + "=*"}},
+		})
+	}
+	parameterSegment.Values = append(parameterSegment.Values, otherValue())
+
+	//The number of URLs found for each parameter key
+	parameterSegment.Analysis = sortedCounts
+
+	addSegment(parameterSegment)
+
+	return hygiene
+}
+
+// URL hygiene issues found in the URL extract
+type urlHygieneIssues struct {
+	totalURLs         int
+	sessionIDs        int
+	doubleSlashes     int
+	encodedCharacters int
+	upperCase         int
+	longURLs          int
+
+	// URLs grouped by their normalised form. More than one URL in a group signals a duplicate variant
+	trailingSlashGroups  map[string]map[string]bool
+	parameterOrderGroups map[string]map[string]bool
+}
+
+// URLs longer than this number of characters are flagged as long URLs
+var hygieneLongURLLength = 200
+
+// Maximum No. of URLs listed in the trailing slash & reordered parameter rules
+var hygieneMaxListedURLs = 100
+
+// Session ID keys, in the path (;jsessionid=) or the query string
+var sessionIDRegex = `(?i)[?&;](jsessionid|phpsessid|aspsessionid[a-z]*|sessionid|session_id|sid|cfid|cftoken)=`
+var sessionIDPattern = regexp.MustCompile(sessionIDRegex)
+
+// Percent-encoded characters
+var encodedCharacterRegex = `%[0-9A-Fa-f]{2}`
+var encodedCharacterPattern = regexp.MustCompile(encodedCharacterRegex)
+
+func newURLHygieneIssues() *urlHygieneIssues {
+	return &urlHygieneIssues{
+		trailingSlashGroups:  make(map[string]map[string]bool),
+		parameterOrderGroups: make(map[string]map[string]bool),
+	}
+}
+
+// Check a single URL for hygiene issues
+func (hygiene *urlHygieneIssues) scanURL(line string) {
+
+	rawURL := strings.TrimSpace(line)
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil || parsedURL.Host == "" {
+		return
+	}
+	hygiene.totalURLs++
+
+	path := parsedURL.EscapedPath()
+	origin := strings.ToLower(parsedURL.Scheme + "://" + parsedURL.Host)
+
+	if sessionIDPattern.MatchString(rawURL) {
+		hygiene.sessionIDs++
+	}
+	if strings.Contains(path, "//") {
+		hygiene.doubleSlashes++
+	}
+	if encodedCharacterPattern.MatchString(path + "?" + parsedURL.RawQuery) {
+		hygiene.encodedCharacters++
+	}
+	// Ignore the hex digits of encoded characters when checking for upper-case characters
+	if unencodedPath := encodedCharacterPattern.ReplaceAllString(path, ""); strings.ToLower(unencodedPath) != unencodedPath {
+		hygiene.upperCase++
+	}
+	if len(rawURL) > hygieneLongURLLength {
+		hygiene.longURLs++
+	}
+
+	// Trailing slash variants. The root path is excluded
+	if path != "/" && path != "" {
+		key := origin + strings.TrimSuffix(path, "/") + "?" + parsedURL.RawQuery
+		addToURLGroup(hygiene.trailingSlashGroups, key, rawURL)
+	}
+
+	// Parameter order variants. Only URLs with two or more parameters can be reordered
+	parameters := strings.Split(parsedURL.RawQuery, "&")
+	if len(parameters) > 1 {
+		sortedParameters := append([]string(nil), parameters...)
+		sort.Strings(sortedParameters)
+		key := origin + path + "?" + strings.Join(sortedParameters, "&")
+		addToURLGroup(hygiene.parameterOrderGroups, key, rawURL)
+	}
+}
+
+// Add a URL to the group identified by key
+func addToURLGroup(groups map[string]map[string]bool, key string, rawURL string) {
+
+	if groups[key] == nil {
+		groups[key] = make(map[string]bool)
+	}
+	groups[key][rawURL] = true
+}
+
+// Get the URLs belonging to groups containing more than one URL
+func duplicateURLs(groups map[string]map[string]bool) []string {
+
+	var duplicates []string
+	for _, group := range groups {
+		if len(group) < 2 {
+			continue
+		}
+		for rawURL := range group {
+			duplicates = append(duplicates, rawURL)
+		}
+	}
+	sort.Strings(duplicates)
+
+	return duplicates
+}
+
+// Regex to identify duplicate and malformed URL variants
+func urlHygiene(hygiene *urlHygieneIssues) {
+
+	trailingSlashURLs := duplicateURLs(hygiene.trailingSlashGroups)
+	parameterOrderURLs := duplicateURLs(hygiene.parameterOrderGroups)
+
+	hygieneSegment := &segment{
+		Name:          "sl_url_hygiene",
+		Comments:      []string{"The first matching value is used, URLs with several issues are labelled with the first issue found"},
+		AnalysisTitle: "sl_url_hygiene URL analysis",
+	}
+
+	//Generate the regex. Values are only included when the issue has been found in the crawl
+	if hygiene.sessionIDs > 0 {
+		hygieneSegment.Values = append(hygieneSegment.Values, segmentValue{Label: "Session_IDs", Rules: []segmentRule{{"url", "rx:" + sessionIDRegex}}})
+	}
+	if len(trailingSlashURLs) > 0 {
+		hygieneSegment.Values = append(hygieneSegment.Values, listedURLValue("Trailing_Slash_Duplicates", trailingSlashURLs))
+	}
+	if len(parameterOrderURLs) > 0 {
+		hygieneSegment.Values = append(hygieneSegment.Values, listedURLValue("Reordered_Parameters", parameterOrderURLs))
+	}
+	if hygiene.doubleSlashes > 0 {
+		hygieneSegment.Values = append(hygieneSegment.Values, segmentValue{Label: "Double_Slashes", Rules: []segmentRule{{"path", "*//*"}}})
+	}
+	if hygiene.encodedCharacters > 0 {
+		hygieneSegment.Values = append(hygieneSegment.Values, segmentValue{Label: "Encoded_Characters", Rules: []segmentRule{{"url", "rx:" + encodedCharacterRegex}}})
+	}
+	// Encoded characters are matched first, the upper-case hex digits cannot be mistaken for upper-case characters
+	if hygiene.upperCase > 0 {
+		hygieneSegment.Values = append(hygieneSegment.Values, segmentValue{Label: "Upper_Case", Rules: []segmentRule{{"path", "rx:[A-Z]"}}})
+	}
+	if hygiene.longURLs > 0 {
+		hygieneSegment.Values = append(hygieneSegment.Values, segmentValue{Label: "Long_URLs", Rules: []segmentRule{{"url", fmt.Sprintf("rx:^.{%d,}", hygieneLongURLLength+1)}}})
+	}
+	hygieneSegment.Values = append(hygieneSegment.Values, otherValue())
+
+	//The summary table
+	summary := []FolderCount{
+		{"Session IDs", hygiene.sessionIDs},
+		{"Trailing slash duplicates", len(trailingSlashURLs)},
+		{"Reordered parameters", len(parameterOrderURLs)},
+		{"Double slashes", hygiene.doubleSlashes},
+		{"Encoded characters", hygiene.encodedCharacters},
+		{"Upper case characters", hygiene.upperCase},
+		{fmt.Sprintf("Long URLs (over %d characters)", hygieneLongURLLength), hygiene.longURLs},
+	}
+	for _, issueCount := range summary {
+		percentage := 0.0
+		if hygiene.totalURLs > 0 {
+			percentage = float64(issueCount.Count) / float64(hygiene.totalURLs) * 100
+		}
+		hygieneSegment.Analysis = append(hygieneSegment.Analysis, FolderCount{fmt.Sprintf("%s, %.2f%%", issueCount.Text, percentage), issueCount.Count})
+	}
+
+	addSegment(hygieneSegment)
+}
+
+// Generate a value matching a list of exact URLs, limited to hygieneMaxListedURLs
+func listedURLValue(label string, rawURLs []string) segmentValue {
+
+	value := segmentValue{Label: label, Operator: "or"}
+	for i, rawURL := range rawURLs {
+		if i == hygieneMaxListedURLs {
+			value.Comments = append(value.Comments, fmt.Sprintf("%d further URLs not listed", len(rawURLs)-hygieneMaxListedURLs))
+			break
+		}
+		value.Rules = append(value.Rules, segmentRule{"url", rawURL})
+	}
+
+	return value
+}
+
+// File types. Segment the static resources & APIs found in the crawl by type family
+func fileTypes() {
+
+	//Open the input file
+	file, err := os.Open(urlExtractFile)
+	if err != nil {
+		fmt.Println(red+"Error. fileTypes. Cannot open the URL extract:"+reset, err)
+		return
+	}
+
+	defer func() {
+		if err := file.Close(); err != nil {
+			fmt.Println(red+"Error. fileTypes. Closing (17):"+reset, err)
+		}
+	}()
+
+	//Create a scanner to read the file line by line
+	scanner := bufio.NewScanner(file)
+
+	//Maps to keep track of the extensions & API paths found
+	extensionCounts := make(map[string]int)
+	apiPathCounts := make(map[string]int)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		//Check if the line contains a quotation mark, if yes, skip to the next line
+		if strings.Contains(line, "\"") {
+			continue
+		}
+
+		path := urlPath(line)
+
+		//API paths
+		for _, apiPath := range apiPaths {
+			if strings.Contains(path, apiPath) {
+				apiPathCounts[apiPath]++
+				break
+			}
+		}
+
+		//Extension of the last path segment
+		if extension := fileExtension(path); extension != "" {
+			extensionCounts[extension]++
+		}
+	}
+
+	//Map each extension found to its family. Extensions not in the dictionary are grouped as other files
+	familyExtensions := make(map[string][]FolderCount)
+	for _, extensionCount := range sortCounts(extensionCounts) {
+		family := fileTypeFamilyName(extensionCount.Text)
+		if family == "" {
+			if isPageExtension(extensionCount.Text) {
+				continue
+			}
+			family = "Other_Files"
+			if len(familyExtensions[family]) == maxOtherFileExtensions {
+				continue
+			}
+		}
+		familyExtensions[family] = append(familyExtensions[family], extensionCount)
+	}
+
+	//No static resources found in the crawl. Fall back to the full dictionary
+	fallback := len(familyExtensions) == 0 && len(apiPathCounts) == 0
+	if fallback {
+		fmt.Println(yellow + "No static resources found. File type segment generated from the file type dictionary" + reset)
+		for _, family := range fileTypeFamilies {
+			for _, extension := range family.Extensions {
+				familyExtensions[family.Name] = append(familyExtensions[family.Name], FolderCount{extension, 0})
+			}
+		}
+		for _, apiPath := range apiPaths {
+			apiPathCounts[apiPath] = 0
+		}
+	}
+
+	fileTypeSegment := &segment{Name: "sl_file_type", AnalysisTitle: "sl_file_type URL analysis"}
+	if fallback {
+		fileTypeSegment.Comments = append(fileTypeSegment.Comments, "No static resources found in the crawl. Generated from the file type dictionary")
+	}
+
+	//Generate the rules for each family, in dictionary order. APIs are matched by path as well as extension
+	for _, familyName := range append(fileTypeFamilyNames(), "Other_Files") {
+		familyValue := segmentValue{Label: familyName}
+		if familyName == "APIs" {
+			for _, apiPathCount := range sortCounts(apiPathCounts) {
+				familyValue.Rules = append(familyValue.Rules, segmentRule{"path", "*" + apiPathCount.Text + "*"})
+				fileTypeSegment.Analysis = append(fileTypeSegment.Analysis, FolderCount{familyName + ": " + apiPathCount.Text, apiPathCount.Count})
+			}
+		}
+		for _, extensionCount := range familyExtensions[familyName] {
+			familyValue.Rules = append(familyValue.Rules, segmentRule{"path", "*." + extensionCount.Text})
+			fileTypeSegment.Analysis = append(fileTypeSegment.Analysis, FolderCount{familyName + ": ." + extensionCount.Text, extensionCount.Count})
+		}
+
+		if len(familyValue.Rules) == 0 {
+			continue
+		}
+		if len(familyValue.Rules) > 1 {
+			familyValue.Operator = "or"
+		}
+		fileTypeSegment.Values = append(fileTypeSegment.Values, familyValue)
+	}
+	fileTypeSegment.Values = append(fileTypeSegment.Values, otherValue())
+
+	addSegment(fileTypeSegment)
+}
+
+// Get the path of a URL, without the query string or fragment
+func urlPath(rawURL string) string {
+
+	path := strings.TrimSpace(rawURL)
+	if index := strings.IndexAny(path, "?#"); index != -1 {
+		path = path[:index]
+	}
+	if index := strings.Index(path, "://"); index != -1 {
+		path = path[index+3:]
+		if slashIndex := strings.Index(path, "/"); slashIndex != -1 {
+			path = path[slashIndex:]
+		} else {
+			path = "/"
+		}
+	}
+
+	return path
+}
+
+// Get the lower-case extension of the last segment in a path
+// Only short alphanumeric extensions containing at least one letter are considered (e.g. not "/size-1.5")
+func fileExtension(path string) string {
+
+	lastSegment := path[strings.LastIndex(path, "/")+1:]
+	index := strings.LastIndex(lastSegment, ".")
+	if index == -1 {
+		return ""
+	}
+
+	extension := strings.ToLower(lastSegment[index+1:])
+	if !fileExtensionPattern.MatchString(extension) {
+		return ""
+	}
+
+	return extension
+}
+
+var fileExtensionPattern = regexp.MustCompile(`^[a-z0-9]{0,5}[a-z][a-z0-9]{0,5}$`)
+
+// Get the family an extension belongs to. An empty string is returned if the extension is not in the dictionary
+func fileTypeFamilyName(extension string) string {
+
+	for _, family := range fileTypeFamilies {
+		for _, familyExtension := range family.Extensions {
+			if extension == familyExtension {
+				return family.Name
+			}
+		}
+	}
+
+	return ""
+}
+
+// Get the names of the file type families, in dictionary order
+func fileTypeFamilyNames() []string {
+
+	var names []string
+	for _, family := range fileTypeFamilies {
+		names = append(names, family.Name)
+	}
+
+	return names
+}
+
+// Extensions used by HTML pages. These are not static resources
+func isPageExtension(extension string) bool {
+
+	for _, pageExtension := range pageExtensions {
+		if extension == pageExtension {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Get the file type dictionary from the [fileTypes] section of the .ini file
+// Each key is a family name, the value is a comma separated list of extensions
+// Existing families are replaced, new families are added
+func getFileTypes() {
+
+	cfg, err := ini.Load("segmentifyLite.ini")
+	if err != nil || !cfg.HasSection("fileTypes") {
+		fmt.Println(yellow + "Warning: [fileTypes] not found in configuration file. The default file type dictionary will be used." + reset)
+		return
+	}
+
+	for _, key := range cfg.Section("fileTypes").Keys() {
+		values := key.Strings(",")
+
+		switch key.Name() {
+		case "apiPaths":
+			apiPaths = values
+		case "pageExtensions":
+			pageExtensions = values
+		default:
+			found := false
+			for i, family := range fileTypeFamilies {
+				if strings.EqualFold(family.Name, key.Name()) {
+					fileTypeFamilies[i].Extensions = values
+					found = true
+				}
+			}
+			if !found {
+				fileTypeFamilies = append(fileTypeFamilies, fileTypeFamily{key.Name(), values})
+			}
+		}
+	}
+
+	fmt.Printf(green+"File type families: %s\n"+reset, strings.Join(fileTypeFamilyNames(), ", "))
+}
+
+// Values available to the segment templates
+type segmentTemplateData struct {
+	Organisation    string
+	Project         string
+	Version         string
+	Hosts           []string
+	TopFolders      []templateFolder
+	URLCount        int
+	ParameterRatio  float64
+	SFCCDetected    bool
+	ShopifyDetected bool
+	PDPDetected     bool
+}
+
+// A level 1 folder available to the segment templates
+// Label is the normalised (human-readable & unique) label, Folder is the raw folder as found in the URLs, used in the regex
+type templateFolder struct {
+	Label  string
+	Folder string
+}
+
+// The level 1 folders for the segment templates. The same folder found on several hosts is listed once
+func templateFolders(rawFolders []string) []templateFolder {
+
+	var folders []string
+	seenFolders := make(map[string]bool)
+	for _, folder := range rawFolders {
+		if !seenFolders[folder] {
+			seenFolders[folder] = true
+			folders = append(folders, folder)
+		}
+	}
+
+	// No host qualifier, the folders match on any host
+	labels := normaliseLabels(folders, make([]string, len(folders)))
+
+	var topFolders []templateFolder
+	for i, folder := range folders {
+		topFolders = append(topFolders, templateFolder{Label: labels[i], Folder: folder})
+	}
+
+	return topFolders
+}
+
+// Functions available to the segment templates
+var segmentTemplateFuncs = template.FuncMap{
+	"quoteMeta":  regexp.QuoteMeta,
+	"lower":      strings.ToLower,
+	"upper":      strings.ToUpper,
+	"hostRegex":  func(host string) string { return hostRegex(host, false) },
+	"decodeHost": decodeHost,
+}
+
+// Generate the segments defined in the templates folder
+// Templates are processed in file name order. Each template can start with "##" header lines,
+// "## include: <condition>" lines are used to decide if the segment is included (all conditions must be met)
+func segmentTemplates() {
+
+	templateFiles, err := filepath.Glob(filepath.Join(templatesFolder, "*.tmpl"))
+	if err != nil || len(templateFiles) == 0 {
+		fmt.Printf(yellow+"Warning. segmentTemplates. No segment templates found in %s\n"+reset, templatesFolder)
+		return
+	}
+	sort.Strings(templateFiles)
+
+	parameterRatio := 0.0
+	if urlsScanned > 0 {
+		parameterRatio = float64(urlsWithParameters) / float64(urlsScanned)
+	}
+
+	data := segmentTemplateData{
+		Organisation:    organisation,
+		Project:         project,
+		Version:         version,
+		Hosts:           detectedHosts,
+		TopFolders:      templateFolders(topFolders),
+		URLCount:        urlsScanned,
+		ParameterRatio:  parameterRatio,
+		SFCCDetected:    sfccDetected,
+		ShopifyDetected: shopifyDetected,
+		PDPDetected:     generatePDPRegex,
+	}
+
+	for _, templateFile := range templateFiles {
+		content, err := os.ReadFile(templateFile)
+		if err != nil {
+			fmt.Printf(red+"Error. segmentTemplates. Cannot read %s: %v\n"+reset, templateFile, err)
+			continue
+		}
+
+		conditions, body := parseTemplateHeader(string(content))
+
+		included, err := templateIncluded(conditions, data)
+		if err != nil {
+			fmt.Printf(red+"Error. segmentTemplates. %s: %v\n"+reset, templateFile, err)
+			continue
+		}
+		if !included {
+			continue
+		}
+
+		segmentTemplate, err := template.New(filepath.Base(templateFile)).Funcs(segmentTemplateFuncs).Parse(body)
+		if err != nil {
+			fmt.Printf(red+"Error. segmentTemplates. Cannot parse %s: %v\n"+reset, templateFile, err)
+			continue
+		}
+
+		var segmentDSL strings.Builder
+		if err := segmentTemplate.Execute(&segmentDSL, data); err != nil {
+			fmt.Printf(red+"Error. segmentTemplates. Cannot execute %s: %v\n"+reset, templateFile, err)
+			continue
+		}
+
+		//Add the segments defined in the template to the segmentation model
+		templateModel, err := parseBotifyDSL(segmentDSL.String())
+		if err != nil {
+			fmt.Printf(red+"Error. segmentTemplates. Invalid segment in %s: %v\n"+reset, templateFile, err)
+			continue
+		}
+		for _, templateSegment := range templateModel.Segments {
+			addSegment(templateSegment)
+		}
+	}
+}
+
+// Split a template into its "## include:" conditions and the template body
+// Header lines are the "##" lines at the start of the template, they are not included in the output
+func parseTemplateHeader(content string) ([]string, string) {
+
+	var conditions []string
+	lines := strings.SplitAfter(content, "\n")
+
+	headerLines := 0
+	for _, line := range lines {
+		trimmedLine := strings.TrimSpace(line)
+		if !strings.HasPrefix(trimmedLine, "##") {
+			break
+		}
+		headerLines++
+
+		header := strings.TrimSpace(strings.TrimPrefix(trimmedLine, "##"))
+		if strings.HasPrefix(header, "include:") {
+			conditions = append(conditions, strings.TrimSpace(strings.TrimPrefix(header, "include:")))
+		}
+	}
+
+	return conditions, strings.Join(lines[headerLines:], "")
+}
+
+// Evaluate the inclusion conditions of a template
+// Supported conditions are:
+// always
+// never
+// sfcc, shopify or pdp (included if the platform / page type has been detected)
+// <metric> <operator> <number>, where metric is parameterRatio, urlCount or hostCount and operator is >, >=, <, <=, = or !=
+func templateIncluded(conditions []string, data segmentTemplateData) (bool, error) {
+
+	for _, condition := range conditions {
+		fields := strings.Fields(condition)
+
+		switch {
+		case len(fields) == 1:
+			switch strings.ToLower(fields[0]) {
+			case "always":
+				continue
+			case "never":
+				return false, nil
+			case "sfcc":
+				if !data.SFCCDetected {
+					return false, nil
+				}
+			case "shopify":
+				if !data.ShopifyDetected {
+					return false, nil
+				}
+			case "pdp":
+				if !data.PDPDetected {
+					return false, nil
+				}
+			default:
+				return false, fmt.Errorf("unknown include condition %q", condition)
+			}
+
+		case len(fields) == 3:
+			var metric float64
+			switch fields[0] {
+			case "parameterRatio":
+				metric = data.ParameterRatio
+			case "urlCount":
+				metric = float64(data.URLCount)
+			case "hostCount":
+				metric = float64(len(data.Hosts))
+			default:
+				return false, fmt.Errorf("unknown metric in include condition %q", condition)
+			}
+
+			threshold, err := strconv.ParseFloat(fields[2], 64)
+			if err != nil {
+				return false, fmt.Errorf("invalid number in include condition %q", condition)
+			}
+
+			var met bool
+			switch fields[1] {
+			case ">":
+				met = metric > threshold
+			case ">=":
+				met = metric >= threshold
+			case "<":
+				met = metric < threshold
+			case "<=":
+				met = metric <= threshold
+			case "=":
+				met = metric == threshold
+			case "!=":
+				met = metric != threshold
+			default:
+				return false, fmt.Errorf("unknown operator in include condition %q", condition)
+			}
+			if !met {
+				return false, nil
+			}
+
+		default:
+			return false, fmt.Errorf("invalid include condition %q", condition)
+		}
+	}
+
+	return true, nil
+}
+
+// Get the default for omitting the generation timestamp from the .ini file
+func getOmitTimestamp() {
+
+	cfg, err := ini.Load("segmentifyLite.ini")
+	if err != nil || !cfg.Section("").HasKey("omitTimestamp") {
+		return
+	}
+
+	defaultOmitTimestamp, err = cfg.Section("").Key("omitTimestamp").Bool()
+	if err != nil {
+		fmt.Println(red+"Error. getOmitTimestamp. Invalid omitTimestamp value, the timestamp is included:"+reset, err)
+		return
+	}
+	if defaultOmitTimestamp {
+		fmt.Println(green + "The generation timestamp is omitted from the segment files" + reset)
+	}
+}
+
+// Allow the segmentation to be pushed to the Botify project, from the .ini file
+func getAllowPush() {
+
+	cfg, err := ini.Load("segmentifyLite.ini")
+	if err != nil || !cfg.Section("").HasKey("allowPush") {
+		return
+	}
+
+	allowPush, err = cfg.Section("").Key("allowPush").Bool()
+	if err != nil {
+		fmt.Println(red+"Error. getAllowPush. Invalid allowPush value, pushing is disabled:"+reset, err)
+		allowPush = false
+		return
+	}
+	if allowPush {
+		fmt.Println(yellow + "The segmentation can be pushed to the Botify project (allowPush)" + reset)
+	}
+}
+
+// Get the segment templates folder from the .ini file
+func getTemplatesFolder() {
+
+	cfg, err := ini.Load("segmentifyLite.ini")
+	if err != nil || !cfg.Section("").HasKey("templatesFolder") {
+		fmt.Println(yellow + "Warning: 'templatesFolder' not found in configuration file. Will default to ./templates." + reset)
+		return
+	}
+
+	templatesFolder = cfg.Section("").Key("templatesFolder").String()
+	fmt.Printf(green+"Segment templates folder: %s\n"+reset, templatesFolder)
+}
+
+// Get the folder size threshold for level 1 & 2 folders
+func levelThreshold(inputFilename string, slashCount int) (largestValueSize, fivePercentValue int) {
+
+	// Open the input file
+	file, err := os.Open(inputFilename)
+	if err != nil {
+		fmt.Printf(red+"\nError. levelThreshhold. Cannot open input file: %v\n"+reset, err)
+		os.Exit(1)
+	}
+
+	defer func() {
+		if err := file.Close(); err != nil {
+			fmt.Println(red+"Error. levelThreshold. Closing (11):"+reset, err)
+		}
+	}()
+
+	// Create a scanner to read the file line by line
+	scanner := bufio.NewScanner(file)
+
+	// Map to keep track of counts of unique values
+	FolderCounts := make(map[string]int)
+
+	// Variable to keep track of the total number of records processed
+	totalRecords := 0
+
+	// Counter to track the number of records scanned
+	recordCounter := 0
+
+	// Iterate through each line in the file
+	for scanner.Scan() {
+		line := scanner.Text()
+		totalRecords++
+		recordCounter++
+
+		// Check if the line contains a quotation mark, if yes, skip to the next line
+		if strings.Contains(line, "\"") {
+			continue
+		}
+
+		// Split the line into substrings using a forward-slash as delimiter
+		parts := strings.Split(line, "/")
+
+		// Check if there are at least slashCount parts in the URL
+		// See slashCount variable declaration comments for more information
+		if len(parts) >= slashCount {
+			// Extract the text between the forward-slashes
+			text := strings.Join(parts[:slashCount], "/")
+
+			// Trim any leading or trailing whitespace
+			text = strings.TrimSpace(text)
+
+			// Update the count for this value if it's not empty
+			if text != "" {
+				FolderCounts[text]++
+			}
+		}
+	}
+
+	// Subtract 2 in order to account for the two header records which are defaults in URL extract
+	totalRecords -= 2
+
+	// Create a slice to hold FolderCount structs
+	var sortedCounts []FolderCount
+
+	// Populate the slice with data from the map
+	for folderName, count := range FolderCounts {
+		sortedCounts = append(sortedCounts, FolderCount{folderName, count})
+	}
+
+	// Sort the slice based on counts
+	sort.Sort(ByCount(sortedCounts))
+
+	// Get the largest value size
+	if len(sortedCounts) > 0 {
+		largestValueSize = sortedCounts[0].Count
+	}
+
+	// Calculate 5% of the largest value
+	fivePercentValue = int(float64(largestValueSize) * thresholdPercent)
+
+	return largestValueSize, fivePercentValue
+}
+
+// Display the results and finishUp
+func finishUp(sessionID string) {
+
+	// We're done
+	fmt.Println(lineSeparator)
+
+	now := time.Now()
+	formattedTime := now.Format("15:04 02/01/2006")
+	fmt.Println("\nSession ID: " + sessionID)
+	fmt.Println("\nsegmentifyLite: Done at " + formattedTime)
+	fmt.Printf("\n%s%s%s Organisation: %s, Project: %s\n", yellow, sessionID, reset, organisation, project)
+
+	// Make a tidy display
+	fmt.Println()
+	fmt.Println(lineSeparator)
+
+	// Delete the temp. files
+	_ = os.Remove(urlExtractFile)
+	_ = os.Remove(urlCompareFile)
+}
+
+func writeLog(sessionID, organisation, project, statusDescription string) {
+
+	// Define log file name
+	fileName := envSegmentifyLiteLogFolder + "/_segmentifyLite.log"
+
+	// Check if the log file exists
+	fileExists := true
+	if _, err := os.Stat(fileName); os.IsNotExist(err) {
+		fileExists = false
+	}
+
+	// Open or create the log file
+	file, err := os.OpenFile(fileName, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		log.Fatalf(red+"Error. writeLog. Cannot oprn log file: %s"+reset, err)
+	}
+
+	defer func() {
+		if err := file.Close(); err != nil {
+			fmt.Println(red+"Error. writeLog. Closing (13):"+reset, err)
+		}
+	}()
+
+	// Get current time
+	currentTime := time.Now().Format("2006-01-02 15:04:05")
+
+	// Construct log record
+	logRecord := fmt.Sprintf("%s,%s,%s,%s,%s\n",
+		sessionID, currentTime, organisation, project, statusDescription)
+
+	// If the file doesn't exist, write header first
+	if !fileExists {
+		header := "SessionID,Date,Organisation,Project,Status\n"
+		if _, err := file.WriteString(header); err != nil {
+			log.Fatalf(red+"Error. writeLog. Failed to write log header: %s"+reset, err)
+		}
+	}
+
+	// Write log record to file
+	if _, err := file.WriteString(logRecord); err != nil {
+		log.Fatalf(red+"Error. writeLog. Cannot write to log file: %s"+reset, err)
+	}
+}
+
+func generateSessionID(length int) (string, error) {
+	// Generate random sessionID
+	sessionID := make([]byte, length)
+	if _, err := rand.Read(sessionID); err != nil {
+		return "", err
+	}
+
+	// Add to the execution increment
+	sessionIDCounter++
+
+	var builder strings.Builder
+	builder.WriteString(strconv.Itoa(sessionIDCounter))
+	builder.WriteString("-")
+	builder.WriteString(base64.URLEncoding.EncodeToString(sessionID))
+
+	// Convert the builder to a string and return
+	return builder.String(), nil
+}
+
+// Prefix used by the generated segments. Segments without this prefix are treated as manual segments
+var generatedSegmentPrefix = "sl_"
+
+// Merged segmentation & merge report, saved to the cache folder
+var mergedSegmentationFile = "segmentMerged.txt"
+var mergeReportFile = "mergeReport.txt"
+
+// Maximum size of an uploaded existing segmentation
+var maxExistingSegmentationSize int64 = 1 << 20
+
+// Result of the last merge with the project's existing segmentation. nil when no merge was requested
+var segmentMerge *segmentationMerge
+
+// Merge of the generated segments with an existing segmentation
+type segmentationMerge struct {
+	Source    string
+	DSL       string
+	Kept      []string
+	Refreshed []string
+	Added     []string
+	Stale     []string
+	Removed   []string
+	Conflicts []mergeConflict
+}
+
+// A conflict found when merging. The manual segment is always kept
+type mergeConflict struct {
+	Segment string
+	Value   string
+	Detail  string
+}
+
+// A segment of an existing segmentation, kept as the original text
+type segmentBlock struct {
+	Name string
+	Text string
+}
+
+// Get the existing segmentation to merge with, when requested in the form
+// Pasted text is used first, then an uploaded file, otherwise the segmentation is fetched from the project
+func existingSegmentation(r *http.Request) (dsl string, source string, err error) {
+
+	if r.Form.Get("mergeExisting") == "" {
+		return "", "", nil
+	}
+
+	dsl, source, err = pastedOrUploaded(r, "existingSegmentation", "existingSegmentationFile")
+	if err != nil || dsl != "" {
+		return dsl, source, err
+	}
+
+	dsl, err = fetchProjectSegmentation(organisation, project)
+
+	return dsl, "fetched from " + organisation + "/" + project, err
+}
+
+// The text pasted or uploaded in a form. Pasted text is used first. Empty when neither is provided
+func pastedOrUploaded(r *http.Request, textField string, fileField string) (content string, source string, err error) {
+
+	if pasted := r.Form.Get(textField); strings.TrimSpace(pasted) != "" {
+		return pasted, "pasted", nil
+	}
+
+	if r.MultipartForm != nil {
+		file, fileHeader, err := r.FormFile(fileField)
+		if err == nil {
+			defer func() {
+				if err := file.Close(); err != nil {
+					fmt.Println(red+"Error. pastedOrUploaded. Closing (22):"+reset, err)
+				}
+			}()
+			content, err := io.ReadAll(io.LimitReader(file, maxExistingSegmentationSize))
+			if err != nil {
+				return "", "", err
+			}
+			if strings.TrimSpace(string(content)) != "" {
+				return string(content), "uploaded (" + fileHeader.Filename + ")", nil
+			}
+		}
+	}
+
+	return "", "", nil
+}
+
+// Merge the generated segments with the existing segmentation
+// The merged segmentation replaces the regex output file so that it is the one copied to the clipboard & applied to the project
+func mergeWithExisting(existingDSL string, source string, dropStale bool) {
+
+	segmentMerge = mergeSegmentations(existingDSL, source, segmentModel, dropStale)
+
+	for _, staleName := range segmentMerge.Stale {
+		fmt.Printf(yellow+"Warning. Merge. %s is no longer generated, it is kept unchanged\n"+reset, staleName)
+	}
+	for _, conflict := range segmentMerge.Conflicts {
+		fmt.Printf(yellow+"Warning. Merge. %s: %s\n"+reset, conflictLocation(conflict), conflict.Detail)
+	}
+
+	exports := []struct {
+		path    string
+		content string
+	}{
+		{regexOutputFile, segmentMerge.DSL},
+		{cacheFolder + "/" + mergedSegmentationFile, segmentMerge.DSL},
+		{cacheFolder + "/" + mergeReportFile, renderMergeReport(segmentMerge)},
+	}
+
+	for _, export := range exports {
+		if err := os.WriteFile(export.path, []byte(export.content), 0644); err != nil {
+			fmt.Printf(red+"Error. mergeWithExisting. Cannot write %s: %v\n"+reset, export.path, err)
+		}
+	}
+}
+
+// Merge the generated segments with an existing segmentation
+// Manual segments are kept as they are written, existing generated (sl_) segments are replaced by the newly generated ones
+// Generated segments which were not generated again (stale) are kept unchanged, unless dropping them was confirmed
+func mergeSegmentations(existingDSL string, source string, generated *segmentation, dropStale bool) *segmentationMerge {
+
+	merge := &segmentationMerge{Source: source}
+
+	header, blocks := splitSegmentBlocks(existingDSL)
+
+	generatedNames := make(map[string]bool)
+	for _, generatedSegment := range generated.Segments {
+		generatedNames[generatedSegment.Name] = true
+	}
+
+	var manualBlocks []segmentBlock
+	var staleBlocks []segmentBlock
+	existingNames := make(map[string]bool)
+	for _, block := range blocks {
+		if existingNames[block.Name] {
+			merge.Conflicts = append(merge.Conflicts, mergeConflict{block.Name, "", "Segment name used more than once in the existing segmentation"})
+		}
+		existingNames[block.Name] = true
+
+		if !strings.HasPrefix(block.Name, generatedSegmentPrefix) {
+			manualBlocks = append(manualBlocks, block)
+			merge.Kept = append(merge.Kept, block.Name)
+			continue
+		}
+		switch {
+		case generatedNames[block.Name]:
+			merge.Refreshed = append(merge.Refreshed, block.Name)
+		case dropStale:
+			merge.Removed = append(merge.Removed, block.Name)
+		default:
+			staleBlocks = append(staleBlocks, block)
+			merge.Stale = append(merge.Stale, block.Name)
+		}
+	}
+
+	for _, generatedSegment := range generated.Segments {
+		if !existingNames[generatedSegment.Name] {
+			merge.Added = append(merge.Added, generatedSegment.Name)
+		}
+	}
+
+	// Name conflicts & overlapping values between the manual and generated segments
+	for _, block := range manualBlocks {
+		merge.Conflicts = append(merge.Conflicts, segmentNameConflicts(block.Name, generated)...)
+
+		manualModel, err := parseBotifyDSL(block.Text)
+		if err != nil || len(manualModel.Segments) == 0 {
+			merge.Conflicts = append(merge.Conflicts, mergeConflict{block.Name, "", fmt.Sprintf("Cannot be parsed, overlapping values not checked (%v)", err)})
+			continue
+		}
+		merge.Conflicts = append(merge.Conflicts, overlappingValues(manualModel.Segments[0], generated)...)
+	}
+
+	// Header. The merge is recorded as a comment, other comments in the existing header are kept
+	mergedHeader := *generated
+	mergedHeader.Segments = nil
+	mergeComment := "Merged with the existing segmentation (" + source + "). Manual segments are kept as is, " + generatedSegmentPrefix + " segments are refreshed"
+	if len(merge.Stale) > 0 {
+		mergeComment += ". Not generated again, kept unchanged: " + strings.Join(merge.Stale, ", ")
+	}
+	mergedHeader.Comments = append(append([]string{}, generated.Comments...), mergeComment)
+
+	var dsl strings.Builder
+	dsl.WriteString(renderBotifyDSL(&mergedHeader))
+	for _, line := range header {
+		if comment := strings.TrimSpace(line); strings.HasPrefix(comment, "#") && !isGeneratedHeaderComment(comment) {
+			dsl.WriteString(comment + "\n")
+		}
+	}
+	for _, block := range append(manualBlocks, staleBlocks...) {
+		dsl.WriteString("\n\n" + strings.TrimSpace(block.Text) + "\n")
+	}
+	for _, generatedSegment := range generated.Segments {
+		dsl.WriteString(renderSegmentDSL(generatedSegment))
+	}
+	merge.DSL = dsl.String()
+
+	return merge
+}
+
+// Split a segmentation into the lines before the first segment and the text of each segment
+func splitSegmentBlocks(dsl string) (header []string, blocks []segmentBlock) {
+
+	for _, line := range strings.Split(dsl, "\n") {
+		trimmedLine := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmedLine, "[segment:") && strings.HasSuffix(trimmedLine, "]") {
+			blocks = append(blocks, segmentBlock{Name: strings.TrimSuffix(strings.TrimPrefix(trimmedLine, "[segment:"), "]")})
+		}
+		if len(blocks) == 0 {
+			header = append(header, line)
+			continue
+		}
+		blocks[len(blocks)-1].Text += line + "\n"
+	}
+
+	return header, blocks
+}
+
+// Is the comment part of the header written by segmentifyLite
+func isGeneratedHeaderComment(comment string) bool {
+
+	comment = strings.TrimSpace(strings.TrimPrefix(comment, "#"))
+	for _, prefix := range []string{"Regex made with love using segmentifyLite ", "Organisation name: ", "Project name: ", "Generated ", "Merged with the existing segmentation ", "URL filter: "} {
+		if strings.HasPrefix(comment, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Manual segment names which clash with a generated segment name (case differences or the name without the sl_ prefix)
+func segmentNameConflicts(manualName string, generated *segmentation) []mergeConflict {
+
+	var conflicts []mergeConflict
+
+	for _, generatedSegment := range generated.Segments {
+		if strings.EqualFold(manualName, generatedSegment.Name) ||
+			strings.EqualFold(manualName, strings.TrimPrefix(generatedSegment.Name, generatedSegmentPrefix)) {
+			conflicts = append(conflicts, mergeConflict{manualName, "", "Segment name conflicts with the generated segment " + generatedSegment.Name + ". The manual segment is kept"})
+		}
+	}
+
+	return conflicts
+}
+
+// Manual segment values whose rules overlap the rules of a generated value
+// The catch-all & home page values are in most segments and are not reported
+func overlappingValues(manualSegment *segment, generated *segmentation) []mergeConflict {
+
+	var conflicts []mergeConflict
+
+	for _, manualValue := range manualSegment.Values {
+		if isCatchAllValue(manualValue) {
+			continue
+		}
+
+		var overlaps []string
+		for _, generatedSegment := range generated.Segments {
+			for _, generatedValue := range generatedSegment.Values {
+				if isCatchAllValue(generatedValue) || valueRulesText(generatedValue) == valueRulesText(homeValue()) {
+					continue
+				}
+				if valuesOverlap(manualValue, generatedValue) {
+					overlaps = append(overlaps, generatedSegment.Name+"/"+generatedValue.Label)
+				}
+			}
+		}
+
+		if len(overlaps) > 0 {
+			conflicts = append(conflicts, mergeConflict{manualSegment.Name, manualValue.Label, "Rules overlap with " + strings.Join(overlaps, ", ")})
+		}
+	}
+
+	return conflicts
+}
+
+// Do any of the rules of two values match the same URLs
+func valuesOverlap(a segmentValue, b segmentValue) bool {
+
+	for _, aRule := range a.Rules {
+		for _, bRule := range b.Rules {
+			if rulesOverlap(aRule, bRule) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Do two rules match the same URLs
+// Identical rules overlap. Wildcard patterns overlap when the text before the first wildcard of one starts with the other's. Regex rules are only compared as text
+func rulesOverlap(a segmentRule, b segmentRule) bool {
+
+	if a.Field != b.Field {
+		return false
+	}
+	if a.Pattern == b.Pattern {
+		return true
+	}
+	if strings.HasPrefix(a.Pattern, "rx:") || strings.HasPrefix(b.Pattern, "rx:") {
+		return false
+	}
+
+	aPrefix, _, aWildcard := strings.Cut(a.Pattern, "*")
+	bPrefix, _, bWildcard := strings.Cut(b.Pattern, "*")
+
+	switch {
+	case aWildcard && bWildcard:
+		return strings.HasPrefix(aPrefix, bPrefix) || strings.HasPrefix(bPrefix, aPrefix)
+	case aWildcard:
+		return strings.HasPrefix(b.Pattern, aPrefix)
+	case bWildcard:
+		return strings.HasPrefix(a.Pattern, bPrefix)
+	}
+
+	return false
+}
+
+// Segment or segment/value where a conflict was found
+func conflictLocation(conflict mergeConflict) string {
+
+	if conflict.Value == "" {
+		return conflict.Segment
+	}
+
+	return conflict.Segment + "/" + conflict.Value
+}
+
+// Render the merge report
+func renderMergeReport(merge *segmentationMerge) string {
+
+	var report strings.Builder
+
+	report.WriteString(fmt.Sprintf("Merged with the existing segmentation (%s)\n\n", merge.Source))
+	report.WriteString(fmt.Sprintf("Manual segments kept: %s\n", strings.Join(merge.Kept, ", ")))
+	report.WriteString(fmt.Sprintf("Generated segments refreshed: %s\n", strings.Join(merge.Refreshed, ", ")))
+	report.WriteString(fmt.Sprintf("Generated segments added: %s\n", strings.Join(merge.Added, ", ")))
+	report.WriteString(fmt.Sprintf("Generated segments kept unchanged (no longer generated, stale): %s\n", strings.Join(merge.Stale, ", ")))
+	report.WriteString(fmt.Sprintf("Generated segments removed (no longer generated, removal confirmed): %s\n", strings.Join(merge.Removed, ", ")))
+
+	report.WriteString(fmt.Sprintf("\nConflicts: %d\n", len(merge.Conflicts)))
+	for _, conflict := range merge.Conflicts {
+		report.WriteString(fmt.Sprintf("- %s: %s\n", conflictLocation(conflict), conflict.Detail))
+	}
+
+	return report.String()
+}
+
+// Keep a run so that it can be applied to the Botify project. Expired runs and the oldest runs above maxGeneratedRuns are removed
+func keepGeneratedRun(runSessionID string, run generatedRun) {
+
+	for id, keptRun := range generatedRuns {
+		if time.Since(keptRun.generated) > generatedRunTTL {
+			delete(generatedRuns, id)
+		}
+	}
+
+	for len(generatedRuns) >= maxGeneratedRuns {
+		oldestID := ""
+		for id, keptRun := range generatedRuns {
+			if oldestID == "" || keptRun.generated.Before(generatedRuns[oldestID].generated) {
+				oldestID = id
+			}
+		}
+		delete(generatedRuns, oldestID)
+	}
+
+	generatedRuns[runSessionID] = run
+}
+
+// Hash of a segmentation, used to check that the pushed segmentation is the one previewed
+func segmentationHash(dsl string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(dsl)))
+}
+
+// Apply the generated segmentation to the Botify project
+// GET shows the differences with the project's current segmentation and asks for confirmation, POST pushes the segmentation
+// The POST is rejected when the segmentation to push no longer matches the previewed one (hash), e.g. when the project segmentation changed
+func applySegmentationHandler(w http.ResponseWriter, r *http.Request) {
+
+	// Lock the function until it's complete to prevent race conditions
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	err := r.ParseForm()
+	if err != nil {
+		fmt.Println(red+"Error. applySegmentationHandler. Cannot parse form:"+reset, err)
+		return
+	}
+
+	applySessionID := r.Form.Get("session")
+	run, found := generatedRuns[applySessionID]
+	if !found || time.Since(run.generated) > generatedRunTTL {
+		writeToolPage(w, "Segmentation not found", "<p class='error'>The segmentation for this session is no longer available. Generate the segmentation again.</p>")
+		return
+	}
+
+	currentDSL, err := fetchProjectSegmentation(run.organisation, run.project)
+	if err != nil {
+		fmt.Println(red+"Error. applySegmentationHandler. Cannot get the project segmentation:"+reset, err)
+		writeToolPage(w, "Segmentation not available", fmt.Sprintf("<p class='error'>The current segmentation of %s/%s cannot be retrieved: %s</p>", html.EscapeString(run.organisation), html.EscapeString(run.project), html.EscapeString(err.Error())))
+		return
+	}
+
+	applyDSL, note, err := segmentationToApply(run, currentDSL)
+	if err != nil {
+		fmt.Println(red+"Error. applySegmentationHandler. Cannot prepare the segmentation:"+reset, err)
+		writeToolPage(w, "Segmentation not applied", fmt.Sprintf("<p class='error'>%s</p>", html.EscapeString(err.Error())))
+		return
+	}
+
+	// Confirmed. Push the segmentation & record what was pushed
+	if r.Method == http.MethodPost && r.Form.Get("confirm") == "yes" {
+		if !allowPush {
+			w.WriteHeader(http.StatusForbidden)
+			writeToolPage(w, "Segmentation not applied", "<p class='error'>Pushing the segmentation to the project is disabled. Set allowPush=true in segmentifyLite.ini to enable it.</p>")
+			return
+		}
+		if r.Form.Get("hash") != segmentationHash(applyDSL) {
+			w.WriteHeader(http.StatusConflict)
+			writeToolPage(w, "Segmentation not applied", fmt.Sprintf("<p class='error'>The segmentation to apply is not the one reviewed (the project segmentation may have changed). <a href='/apply?session=%s'>Review the changes again</a></p>", url.QueryEscape(applySessionID)))
+			return
+		}
+
+		err := pushProjectSegmentation(run.organisation, run.project, applyDSL)
+		recordPush(applySessionID, run, applyDSL, err)
+		if err != nil {
+			writeToolPage(w, "Segmentation not applied", fmt.Sprintf("<p class='error'>The segmentation could not be applied to %s/%s: %s</p>", html.EscapeString(run.organisation), html.EscapeString(run.project), html.EscapeString(err.Error())))
+			return
+		}
+
+		projectURL := "https://app.botify.com/" + run.organisation + "/" + run.project + "/segmentation"
+		writeToolPage(w, "Segmentation applied", fmt.Sprintf("<p>The segmentation has been applied to %s/%s.</p><p><a href='%s' target='_blank'>Open the segment editor</a></p>", html.EscapeString(run.organisation), html.EscapeString(run.project), projectURL))
+		return
+	}
+
+	// Show the differences with the current project segmentation
+	writeToolPage(w, "Apply segmentation to "+run.organisation+"/"+run.project, applyConfirmationHTML(applySessionID, currentDSL, applyDSL, note))
+}
+
+// The segmentation pushed to the project
+// When the generated segments were not merged in the run they are merged with the project's current segmentation, so that its manual segments are kept
+// An error is returned when manual segments of the project would be dropped
+func segmentationToApply(run generatedRun, currentDSL string) (string, string, error) {
+
+	// The merged segmentation is applied when the generated segments were merged with the existing segmentation
+	mergedDSL, err := os.ReadFile(run.cacheFolder + "/" + mergedSegmentationFile)
+	if err == nil {
+		return string(mergedDSL), "", checkManualSegmentsKept(currentDSL, string(mergedDSL))
+	}
+
+	generatedDSL, err := os.ReadFile(run.cacheFolder + "/" + exportDSLFile)
+	if err != nil {
+		return "", "", fmt.Errorf("the generated segmentation cannot be read, generate the segmentation again (%v)", err)
+	}
+
+	_, currentBlocks := splitSegmentBlocks(currentDSL)
+	hasManualSegments := false
+	for _, block := range currentBlocks {
+		if !strings.HasPrefix(block.Name, generatedSegmentPrefix) {
+			hasManualSegments = true
+		}
+	}
+	if !hasManualSegments {
+		return string(generatedDSL), "", nil
+	}
+
+	generatedModel, err := parseBotifyDSL(string(generatedDSL))
+	if err != nil {
+		return "", "", fmt.Errorf("the generated segmentation cannot be parsed (%v)", err)
+	}
+	merge := mergeSegmentations(currentDSL, "project "+run.organisation+"/"+run.project, generatedModel, false)
+	note := fmt.Sprintf("The generated segments are merged with the project's current segmentation. Manual segments kept: %s", strings.Join(merge.Kept, ", "))
+	if len(merge.Stale) > 0 {
+		note += fmt.Sprintf(". Generated segments no longer generated, kept unchanged: %s", strings.Join(merge.Stale, ", "))
+	}
+
+	return merge.DSL, note, checkManualSegmentsKept(currentDSL, merge.DSL)
+}
+
+// Check that the manual (non sl_) segments of the project are in the segmentation to apply
+func checkManualSegmentsKept(currentDSL string, applyDSL string) error {
+
+	_, applyBlocks := splitSegmentBlocks(applyDSL)
+	applyNames := make(map[string]bool)
+	for _, block := range applyBlocks {
+		applyNames[block.Name] = true
+	}
+
+	var missing []string
+	_, currentBlocks := splitSegmentBlocks(currentDSL)
+	for _, block := range currentBlocks {
+		if !strings.HasPrefix(block.Name, generatedSegmentPrefix) && !applyNames[block.Name] {
+			missing = append(missing, block.Name)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("the manual segments %s of the project are not in the segmentation to apply. Merge with the project's segmentation and generate the segmentation again", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// Generate the confirmation form, listing the differences between the current and generated segmentation
+func applyConfirmationHTML(applySessionID string, currentDSL string, generatedDSL string, note string) string {
+
+	var body strings.Builder
+
+	if note != "" {
+		body.WriteString("<p>" + html.EscapeString(note) + "</p>")
+	}
+
+	generatedModel, _ := parseBotifyDSL(generatedDSL)
+	currentModel, err := parseBotifyDSL(currentDSL)
+	if err != nil {
+		// The current segmentation cannot be compared. Show it as is
+		body.WriteString(fmt.Sprintf("<p class='error'>The current segmentation cannot be compared (%s). It will be replaced by the generated segmentation.</p>", html.EscapeString(err.Error())))
+		body.WriteString("<h3>Current segmentation</h3><pre>" + html.EscapeString(currentDSL) + "</pre>")
+	} else {
+		changes := diffSegmentations(currentModel, generatedModel)
+		if len(changes) == 0 {
+			body.WriteString("<p>The generated segmentation is identical to the current segmentation.</p>")
+		} else {
+			body.WriteString("<h3>Changes to the project segmentation</h3>\n<table>\n<tr><th>Segment</th><th>Value</th><th>Change</th><th>Detail</th></tr>\n")
+			for _, change := range changes {
+				body.WriteString(fmt.Sprintf("<tr class='%s'><td>%s</td><td>%s</td><td>%s</td><td>%s</td></tr>\n",
+					change.Change, html.EscapeString(change.Segment), html.EscapeString(change.Value), change.Change, html.EscapeString(change.Detail)))
+			}
+			body.WriteString("</table>\n")
+		}
+	}
+
+	if !allowPush {
+		body.WriteString("<p class='error'>Pushing the segmentation to the project is disabled. Set allowPush=true in segmentifyLite.ini to enable it.</p>")
+		return body.String()
+	}
+
+	// The hash of the previewed segmentation is checked when the push is confirmed
+	body.WriteString(fmt.Sprintf(`
+<form action="/apply" method="post">
+    <input type="hidden" name="session" value="%s">
+    <input type="hidden" name="hash" value="%s">
+    <input type="hidden" name="confirm" value="yes">
+    <button class="apply-button" type="submit">Apply the generated segmentation</button>
+</form>`, html.EscapeString(applySessionID), segmentationHash(generatedDSL)))
+
+	return body.String()
+}
+
+// A difference between two segmentations
+type segmentChange struct {
+	Segment string
+	Value   string
+	Change  string
+	Detail  string
+}
+
+// Compare two segmentations. Segments are matched by name, values by label
+func diffSegmentations(current *segmentation, generated *segmentation) []segmentChange {
+
+	var changes []segmentChange
+
+	currentSegments := make(map[string]*segment)
+	for _, currentSegment := range current.Segments {
+		currentSegments[currentSegment.Name] = currentSegment
+	}
+	generatedSegments := make(map[string]*segment)
+	for _, generatedSegment := range generated.Segments {
+		generatedSegments[generatedSegment.Name] = generatedSegment
+	}
+
+	for _, generatedSegment := range generated.Segments {
+		currentSegment, found := currentSegments[generatedSegment.Name]
+		if !found {
+			changes = append(changes, segmentChange{generatedSegment.Name, "", "added", fmt.Sprintf("%d values", len(generatedSegment.Values))})
+			continue
+		}
+		changes = append(changes, diffSegmentValues(currentSegment, generatedSegment)...)
+	}
+
+	for _, currentSegment := range current.Segments {
+		if _, found := generatedSegments[currentSegment.Name]; !found {
+			changes = append(changes, segmentChange{currentSegment.Name, "", "removed", fmt.Sprintf("%d values", len(currentSegment.Values))})
+		}
+	}
+
+	return changes
+}
+
+// Compare the values of two versions of a segment
+func diffSegmentValues(currentSegment *segment, generatedSegment *segment) []segmentChange {
+
+	var changes []segmentChange
+
+	currentValues := make(map[string]segmentValue)
+	for _, value := range currentSegment.Values {
+		currentValues[value.Label] = value
+	}
+	generatedValues := make(map[string]bool)
+
+	for _, value := range generatedSegment.Values {
+		generatedValues[value.Label] = true
+		currentValue, found := currentValues[value.Label]
+		switch {
+		case !found:
+			changes = append(changes, segmentChange{generatedSegment.Name, value.Label, "added", valueRulesText(value)})
+		case valueRulesText(currentValue) != valueRulesText(value):
+			changes = append(changes, segmentChange{generatedSegment.Name, value.Label, "changed", valueRulesText(currentValue) + " → " + valueRulesText(value)})
+		}
+	}
+
+	for _, value := range currentSegment.Values {
+		if !generatedValues[value.Label] {
+			changes = append(changes, segmentChange{currentSegment.Name, value.Label, "removed", valueRulesText(value)})
+		}
+	}
+
+	return changes
+}
+
+// The rules of a value as a single line of text
+func valueRulesText(value segmentValue) string {
+
+	var rules []string
+	for _, rule := range value.Rules {
+		rules = append(rules, rule.Field+" "+rule.Pattern)
+	}
+	if value.Operator != "" {
+		return value.Operator + " (" + strings.Join(rules, ", ") + ")"
+	}
+
+	return strings.Join(rules, ", ")
+}
+
+// Get the current segmentation of the Botify project
+func fetchProjectSegmentation(organisation string, project string) (string, error) {
+
+	req, err := http.NewRequest("GET", botifyAPIURL+fmt.Sprintf(segmentationEndpoint, organisation, project), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Add("accept", "application/json")
+	req.Header.Add("Authorization", "token "+envBotifyAPIToken)
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	res, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+
+	defer func() {
+		if err := res.Body.Close(); err != nil {
+			fmt.Println(red+"Error. fetchProjectSegmentation. Closing (19):"+reset, err)
+		}
+	}()
+
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("the Botify API returned %s", res.Status)
+	}
+
+	var responseObject botifySegmentation
+	if err := json.NewDecoder(res.Body).Decode(&responseObject); err != nil {
+		return "", err
+	}
+
+	return responseObject.Segmentation, nil
+}
+
+// Replace the segmentation of the Botify project
+func pushProjectSegmentation(organisation string, project string, dsl string) error {
+
+	payload, err := json.Marshal(botifySegmentation{Segmentation: dsl})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("PUT", botifyAPIURL+fmt.Sprintf(segmentationEndpoint, organisation, project), bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Add("accept", "application/json")
+	req.Header.Add("content-type", "application/json")
+	req.Header.Add("Authorization", "token "+envBotifyAPIToken)
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := res.Body.Close(); err != nil {
+			fmt.Println(red+"Error. pushProjectSegmentation. Closing (20):"+reset, err)
+		}
+	}()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		responseData, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("the Botify API returned %s %s", res.Status, strings.TrimSpace(string(responseData)))
+	}
+
+	return nil
+}
+
+// Record a push to the Botify project
+// The pushed segmentation is saved in the cache folder and every push is logged to _segmentifyLitePushes.log
+func recordPush(applySessionID string, run generatedRun, dsl string, pushErr error) {
+
+	status := "Pushed"
+	if pushErr != nil {
+		status = "Failed: " + strings.ReplaceAll(pushErr.Error(), ",", ";")
+	}
+
+	pushedFile := fmt.Sprintf("%s/pushedSegmentation_%s.txt", run.cacheFolder, time.Now().Format("20060102150405"))
+	if pushErr == nil {
+		if err := os.WriteFile(pushedFile, []byte(dsl), 0644); err != nil {
+			fmt.Println(red+"Error. recordPush. Cannot save the pushed segmentation:"+reset, err)
+		}
+	}
+
+	fileName := envSegmentifyLiteLogFolder + "/_segmentifyLitePushes.log"
+	_, statErr := os.Stat(fileName)
+
+	file, err := os.OpenFile(fileName, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		fmt.Println(red+"Error. recordPush. Cannot open push log:"+reset, err)
+		return
+	}
+
+	defer func() {
+		if err := file.Close(); err != nil {
+			fmt.Println(red+"Error. recordPush. Closing (21):"+reset, err)
+		}
+	}()
+
+	if os.IsNotExist(statErr) {
+		if _, err := file.WriteString("SessionID,Date,Organisation,Project,Segments,SHA256,Status\n"); err != nil {
+			fmt.Println(red+"Error. recordPush. Cannot write push log header:"+reset, err)
+		}
+	}
+
+	segmentCount := 0
+	if pushedModel, err := parseBotifyDSL(dsl); err == nil {
+		segmentCount = len(pushedModel.Segments)
+	}
+
+	logRecord := fmt.Sprintf("%s,%s,%s,%s,%d,%x,%s\n", applySessionID, time.Now().Format("2006-01-02 15:04:05"),
+		run.organisation, run.project, segmentCount, sha256.Sum256([]byte(dsl)), status)
+	if _, err := file.WriteString(logRecord); err != nil {
+		fmt.Println(red+"Error. recordPush. Cannot write to push log:"+reset, err)
+	}
+
+	writeLog(applySessionID, run.organisation, run.project, "Segmentation push. "+status)
+}
+
+// Write a page used by the apply segmentation & history steps
+func writeToolPage(w http.ResponseWriter, title string, body string) {
+
+	htmlContent := fmt.Sprintf(`
+<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>segmentifyLite</title>
+    <style>
+        body {
+            margin: 0;
+            font-family: Arial, sans-serif;
+            background-color: Cornsilk;
+        }
+        .banner {
+            background-color: DeepSkyBlue;
+            color: white;
+            text-align: center;
+            padding: 15px 0;
+        }
+        .banner.top {
+            font-size: 24px;
+        }
+        .content {
+            padding: 20px 40px;
+            color: dimgray;
+        }
+        h2 {
+            color: DeepSkyBlue;
+        }
+        table {
+            border-collapse: collapse;
+            font-size: 14px;
+        }
+        th, td {
+            border: 1px solid LightGray;
+            padding: 4px 8px;
+            text-align: left;
+            vertical-align: top;
+        }
+        tr.added td {
+            background-color: Honeydew;
+        }
+        tr.removed td {
+            background-color: MistyRose;
+        }
+        tr.changed td {
+            background-color: LightYellow;
+        }
+        .error {
+            color: red;
+            font-weight: bold;
+        }
+        .apply-button {
+            margin-top: 20px;
+            padding: 12px 24px;
+            font-size: 18px;
+            color: white;
+            background-color: DeepSkyBlue;
+            border: none;
+            border-radius: 8px;
+            cursor: pointer;
+        }
+        .apply-button:hover {
+            background-color: Green;
+        }
+    </style>
+</head>
+<body>
 
-[segment:sl_parameter_usage]
-@Parameters
-query *=*
+<!-- Top Banner -->
+<header class="banner top">
+    <span>Go_Seo</span><br>
+    <span style="font-size: 20px;">segmentifyLite</span>
+</header>
 
-@Clean
-path /*
+<div class="content">
+<h2>%s</h2>
+%s
+</div>
 
-# ----End of sl_parameter_usage----
-`
+</body>
+</html>`, html.EscapeString(title), body)
 
-	errParameterUsage := insertStaticRegex(parameterUsageRegex)
-	if errParameterUsage != nil {
-		panic(errParameterUsage)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if _, err := w.Write([]byte(htmlContent)); err != nil {
+		fmt.Println(red+"Error. writeToolPage. Cannot write page:"+reset, err)
 	}
-
 }
 
-// Regex to count the number of parameters in the URL
-func noOfParameters() {
-
-	//Number of parameters
-	parameterNoRegex := `
-
-
-[segment:sl_no_of_parameters]
-@Home
-path /
-
-@5_Parameters
-query rx:=(.)+=(.)+=(.)+(.)+(.)+
-
-@4_Parameters
-query rx:=(.)+=(.)+=(.)+(.)+
-
-@3_Parameters
-query rx:=(.)+=(.)+=(.)+
-
-@2_Parameters
-query rx:=(.)+=(.)+
-
-@1_Parameter
-query rx:=(.)+
-
-@~Other
-path /*
-
-# ----End of sl_no_of_parameters----
-`
-
-	errParameterNoRegex := insertStaticRegex(parameterNoRegex)
-	if errParameterNoRegex != nil {
-		panic(errParameterNoRegex)
-	}
-
+// Segmentation history. Every run is saved to <envSegmentifyLiteFolder>/history/<organisation>/<project>
+var historyFolder = "history"
+
+// A run saved to the segmentation history
+type historyRun struct {
+	SessionID    string            `json:"sessionID"`
+	Organisation string            `json:"organisation"`
+	Project      string            `json:"project"`
+	AnalysisSlug string            `json:"analysisSlug"`
+	CompareSlug  string            `json:"compareSlug,omitempty"`
+	Date         string            `json:"date"`
+	URLCount     int               `json:"urlCount"`
+	Platforms    []string          `json:"platforms"`
+	Parameters   historyParameters `json:"parameters"`
+	Segmentation *segmentation     `json:"segmentation"`
 }
 
-// Regex to count the number of folders in the URL
-func noOfFolders() {
-
-	//Number of folders
-	folderNoRegex := `
-[segment:sl_no_of_folders]
-@Home
-path /
-
-@Folders/5
-path rx:^/[^/]+/[^/]+/[^/]+/[^/]+/[^/]+
-
-@Folders/4
-path rx:^/[^/]+/[^/]+/[^/]+/[^/]+
-
-@Folders/3
-path rx:^/[^/]+/[^/]+/[^/]+
-
-@Folders/2
-path rx:^/[^/]+/[^/]+
-
-@Folders/1
-path rx:^/[^/]+
-
-@~Other
-path /*
-
-# ----End of sl_no_of_folders----
-`
-
-	//No. of folders message
-	errFolderNoRegex := insertStaticRegex(folderNoRegex)
-	if errFolderNoRegex != nil {
-		panic(errFolderNoRegex)
-	}
-
+// The parameters used to generate a run
+type historyParameters struct {
+	ThresholdPercent float64 `json:"thresholdPercent"`
+	MaxURLsToProcess int     `json:"maxURLsToProcess"`
+	Level1Threshold  int     `json:"level1Threshold"`
+	Level2Threshold  int     `json:"level2Threshold"`
+	URLFilter        string  `json:"urlFilter,omitempty"`
 }
 
-// SFCC Regex
-func sfccURLs() {
-
-	//SFCC
-	sfccURLs := `
-
+// A difference between two runs
+type historyChange struct {
+	Category string
+	Detail   string
+}
 
-[segment:sl_sfcc]
-@Home
-path /
+// Characters allowed in history folder names. Botify organisation & project slugs only use these
+var historyNamePattern = regexp.MustCompile(`[^A-Za-z0-9_.-]`)
 
-@SFCC
-path */demandware*
+// Returned when the organisation or project cannot be used as a history folder name
+var errInvalidHistoryName = errors.New("invalid organisation or project name")
 
-@~Other
-path /*
+// The history folder of a project. Empty, "." & ".." names are rejected and the folder must be inside the history folder
+func historyPath(historyOrganisation string, historyProject string) (string, error) {
 
-# ----End of sl_sfcc----
-`
+	var names []string
+	for _, name := range []string{historyOrganisation, historyProject} {
+		name = historyNamePattern.ReplaceAllString(name, "_")
+		if name == "" || name == "." || name == ".." {
+			return "", errInvalidHistoryName
+		}
+		names = append(names, name)
+	}
 
-	// SFCC message
-	fmt.Println(purple + "Salesforce Commerce Cloud (Demandware)" + reset)
-	errSfccURLs := insertStaticRegex(sfccURLs)
-	if errSfccURLs != nil {
-		panic(errSfccURLs)
+	historyRoot := filepath.Clean(filepath.Join(envSegmentifyLiteFolder, historyFolder))
+	folder := filepath.Join(historyRoot, names[0], names[1])
+	if !strings.HasPrefix(folder, historyRoot+string(filepath.Separator)) {
+		return "", errInvalidHistoryName
 	}
 
+	return folder, nil
 }
 
-// Shopify Regex
-func shopifyURLs() {
-
-	// Shopify
-	shopifyURLs := `
-[segment:sl_shopify]
-@Home
-path /
-
-@PDP/Products/Variants
-path */products/*
-URL *variant=*
+// Save the generated segmentation, the analysis slug & the parameters used to the project history
+func saveHistory(sessionID string) {
 
-@PDP/Products
-path */products/*
-
-@PLP/Collections
-path */collections/*
-
-@Pages
-path */pages/*
-
-@~Other
-path /*
-# ----End of sl_shopify----
-`
-
-	// Shopify message
-	fmt.Println(purple + "Shopify" + reset)
-	errShopify := insertStaticRegex(shopifyURLs)
-	if errShopify != nil {
-		panic(errShopify)
-	}
-
-}
-
-// Static resources
-func staticResources() {
-
-	// Static resources
-	staticResources := `
-[segment:sl_Static_Resources]  
-@true  
-or (  
-path *.bmp
-path *.css
-path *.doc
-path *.gif
-path *.ief
-path *.jpe
-path *.jpeg
-path *.jpg
-path *.js
-path *.m1v
-path *.mov
-path *.mp2
-path *.mp3
-path *.mp4
-path *.mpa
-path *.mpe
-path *.mpeg
-path *.mpg
-path *.pbm
-path *.pdf
-path *.pgm
-path *.png
-path *.pnm
-path *.ppm
-path *.pps
-path *.ppt
-path *.ps
-path *.qt
-path *.ras
-path *.rgb
-path *.swf
-path *.tif
-path *.tiff
-path *.tsv
-path *.txt
-path *.vcf
-path *.wav
-path *.xbm
-path *.xls
-path *.xml
-path *.xpdl
-path *.xpm
-path *.xwd
-path */api/*
-)
+	var platforms []string
+	if sfccDetected {
+		platforms = append(platforms, "SFCC")
+	}
+	if shopifyDetected {
+		platforms = append(platforms, "Shopify")
+	}
+	if generatePDPRegex {
+		platforms = append(platforms, "PDP")
+	}
 
-@~Other
-path /*
+	run := historyRun{
+		SessionID:    sessionID,
+		Organisation: organisation,
+		Project:      project,
+		AnalysisSlug: analysisSlug,
+		CompareSlug:  compareSlug,
+		Date:         time.Now().Format(time.RFC3339),
+		URLCount:     urlsScanned,
+		Platforms:    platforms,
+		Parameters: historyParameters{
+			ThresholdPercent: thresholdPercent,
+			MaxURLsToProcess: maxURLsToProcess,
+			Level1Threshold:  level1Threshold,
+			Level2Threshold:  level2Threshold,
+			URLFilter:        activeURLFilter.description(),
+		},
+		Segmentation: segmentModel,
+	}
 
-# ----End of sl_static_resources----
-`
+	content, err := json.MarshalIndent(run, "", "  ")
+	if err != nil {
+		fmt.Println(red+"Error. saveHistory. Cannot render JSON:"+reset, err)
+		return
+	}
 
-	errStaticResources := insertStaticRegex(staticResources)
-	if errStaticResources != nil {
-		panic(errStaticResources)
+	folder, err := historyPath(organisation, project)
+	if err != nil {
+		fmt.Println(red+"Error. saveHistory. Cannot save the history:"+reset, err)
+		return
+	}
+	if err := os.MkdirAll(folder, os.ModePerm); err != nil {
+		fmt.Println(red+"Error. saveHistory. Cannot create the history folder:"+reset, err)
+		return
 	}
 
+	// The file name starts with the date so that the runs are listed in order
+	fileName := filepath.Join(folder, time.Now().Format("20060102150405")+"_"+sessionID+".json")
+	if err := os.WriteFile(fileName, content, 0644); err != nil {
+		fmt.Println(red+"Error. saveHistory. Cannot write the history file:"+reset, err)
+	}
 }
 
-// PDP Regex
-func insertPDPRegex() {
-
-	generatePDPRegex := `
-[segment:sl_PDP]  
-@pdp
-path rx:[a-zA-Z0-9\-]+-\d+\.html$
-
-@Other
-path /*
+// Load the history of a project, oldest run first
+func loadHistory(historyOrganisation string, historyProject string) ([]historyRun, error) {
 
-# ----End of sl_PDP segment----
-`
-	errStaticResources := insertStaticRegex(generatePDPRegex)
-	if errStaticResources != nil {
-		panic(errStaticResources)
+	folder, err := historyPath(historyOrganisation, historyProject)
+	if err != nil {
+		return nil, err
 	}
-}
-
-// Get the folder size threshold for level 1 & 2 folders
-func levelThreshold(inputFilename string, slashCount int) (largestValueSize, fivePercentValue int) {
 
-	// Open the input file
-	file, err := os.Open(inputFilename)
+	fileNames, err := filepath.Glob(filepath.Join(folder, "*.json"))
 	if err != nil {
-		fmt.Printf(red+"\nError. levelThreshhold. Cannot open input file: %v\n"+reset, err)
-		os.Exit(1)
+		return nil, err
 	}
+	sort.Strings(fileNames)
 
-	defer func() {
-		if err := file.Close(); err != nil {
-			fmt.Println(red+"Error. levelThreshold. Closing (11):"+reset, err)
+	var runs []historyRun
+	for _, fileName := range fileNames {
+		content, err := os.ReadFile(fileName)
+		if err != nil {
+			fmt.Println(red+"Error. loadHistory. Cannot read the history file:"+reset, err)
+			continue
 		}
-	}()
-
-	// Create a scanner to read the file line by line
-	scanner := bufio.NewScanner(file)
+		var run historyRun
+		if err := json.Unmarshal(content, &run); err != nil {
+			fmt.Println(red+"Error. loadHistory. Cannot unmarshall the history file "+fileName+":"+reset, err)
+			continue
+		}
+		runs = append(runs, run)
+	}
 
-	// Map to keep track of counts of unique values
-	FolderCounts := make(map[string]int)
+	return runs, nil
+}
 
-	// Variable to keep track of the total number of records processed
-	totalRecords := 0
+// Show the history of a project & the differences between two runs
+// The latest two runs are compared unless the "from" & "to" sessions are specified
+func historyHandler(w http.ResponseWriter, r *http.Request) {
 
-	// Counter to track the number of records scanned
-	recordCounter := 0
+	// Lock the function until it's complete to prevent race conditions
+	mutex.Lock()
+	defer mutex.Unlock()
 
-	// Iterate through each line in the file
-	for scanner.Scan() {
-		line := scanner.Text()
-		totalRecords++
-		recordCounter++
+	err := r.ParseForm()
+	if err != nil {
+		fmt.Println(red+"Error. historyHandler. Cannot parse form:"+reset, err)
+		return
+	}
 
-		// Check if the line contains a quotation mark, if yes, skip to the next line
-		if strings.Contains(line, "\"") {
-			continue
-		}
+	historyOrganisation := r.Form.Get("organization")
+	historyProject := r.Form.Get("project")
+	title := "Segmentation history for " + historyOrganisation + "/" + historyProject
 
-		// Split the line into substrings using a forward-slash as delimiter
-		parts := strings.Split(line, "/")
+	runs, err := loadHistory(historyOrganisation, historyProject)
+	if errors.Is(err, errInvalidHistoryName) {
+		w.WriteHeader(http.StatusBadRequest)
+		writeToolPage(w, title, "<p class='error'>Invalid organisation or project name.</p>")
+		return
+	}
+	if err != nil || len(runs) == 0 {
+		writeToolPage(w, title, "<p class='error'>No segmentation history found for this project.</p>")
+		return
+	}
 
-		// Check if there are at least slashCount parts in the URL
-		// See slashCount variable declaration comments for more information
-		if len(parts) >= slashCount {
-			// Extract the text between the forward-slashes
-			text := strings.Join(parts[:slashCount], "/")
+	var body strings.Builder
 
-			// Trim any leading or trailing whitespace
-			text = strings.TrimSpace(text)
+	// The runs, with a form used to choose the two runs to compare
+	body.WriteString("<form action='/history' method='get'>\n")
+	body.WriteString(fmt.Sprintf("<input type='hidden' name='organization' value='%s'>\n<input type='hidden' name='project' value='%s'>\n",
+		html.EscapeString(historyOrganisation), html.EscapeString(historyProject)))
+	body.WriteString("<table>\n<tr><th>From</th><th>To</th><th>Date</th><th>Session</th><th>Analysis</th><th>URLs</th><th>Platforms</th><th>Segments</th></tr>\n")
 
-			// Update the count for this value if it's not empty
-			if text != "" {
-				FolderCounts[text]++
-			}
+	from, to := historyRunsToCompare(runs, r.Form.Get("from"), r.Form.Get("to"))
+	for _, run := range runs {
+		fromChecked, toChecked := "", ""
+		if from != nil && run.SessionID == from.SessionID {
+			fromChecked = " checked"
+		}
+		if to != nil && run.SessionID == to.SessionID {
+			toChecked = " checked"
 		}
+		segmentCount := 0
+		if run.Segmentation != nil {
+			segmentCount = len(run.Segmentation.Segments)
+		}
+		body.WriteString(fmt.Sprintf("<tr><td><input type='radio' name='from' value='%s'%s></td><td><input type='radio' name='to' value='%s'%s></td><td>%s</td><td>%s</td><td>%s</td><td>%d</td><td>%s</td><td>%d</td></tr>\n",
+			html.EscapeString(run.SessionID), fromChecked, html.EscapeString(run.SessionID), toChecked,
+			html.EscapeString(historyDate(run.Date)), html.EscapeString(run.SessionID), html.EscapeString(run.AnalysisSlug),
+			run.URLCount, html.EscapeString(strings.Join(run.Platforms, ", ")), segmentCount))
 	}
+	body.WriteString("</table>\n<button class='apply-button' type='submit'>Compare</button>\n</form>\n")
 
-	// Subtract 2 in order to account for the two header records which are defaults in URL extract
-	totalRecords -= 2
-
-	// Create a slice to hold FolderCount structs
-	var sortedCounts []FolderCount
-
-	// Populate the slice with data from the map
-	for folderName, count := range FolderCounts {
-		sortedCounts = append(sortedCounts, FolderCount{folderName, count})
+	if from == nil || to == nil {
+		body.WriteString("<p>At least two runs are needed to show the differences.</p>")
+		writeToolPage(w, title, body.String())
+		return
 	}
 
-	// Sort the slice based on counts
-	sort.Sort(ByCount(sortedCounts))
+	// The differences between the two runs
+	body.WriteString(fmt.Sprintf("<h3>Changes from %s (%s) to %s (%s)</h3>\n",
+		html.EscapeString(historyDate(from.Date)), html.EscapeString(from.AnalysisSlug), html.EscapeString(historyDate(to.Date)), html.EscapeString(to.AnalysisSlug)))
 
-	// Get the largest value size
-	if len(sortedCounts) > 0 {
-		largestValueSize = sortedCounts[0].Count
+	changes := diffHistoryRuns(from, to)
+	if len(changes) == 0 {
+		body.WriteString("<p>No changes found.</p>")
+	} else {
+		body.WriteString("<table>\n<tr><th>Change</th><th>Detail</th></tr>\n")
+		for _, change := range changes {
+			body.WriteString(fmt.Sprintf("<tr><td>%s</td><td>%s</td></tr>\n", html.EscapeString(change.Category), html.EscapeString(change.Detail)))
+		}
+		body.WriteString("</table>\n")
 	}
 
-	// Calculate 5% of the largest value
-	fivePercentValue = int(float64(largestValueSize) * thresholdPercent)
-
-	return largestValueSize, fivePercentValue
+	writeToolPage(w, title, body.String())
 }
 
-// Display the results and finishUp
-func finishUp(sessionID string) {
-
-	// We're done
-	fmt.Println(lineSeparator)
+// The runs to compare. Defaults to the latest two runs
+func historyRunsToCompare(runs []historyRun, fromSession string, toSession string) (from *historyRun, to *historyRun) {
 
-	now := time.Now()
-	formattedTime := now.Format("15:04 02/01/2006")
-	fmt.Println("\nSession ID: " + sessionID)
-	fmt.Println("\nsegmentifyLite: Done at " + formattedTime)
-	fmt.Printf("\n%s%s%s Organisation: %s, Project: %s\n", yellow, sessionID, reset, organisation, project)
+	for i := range runs {
+		if runs[i].SessionID == fromSession {
+			from = &runs[i]
+		}
+		if runs[i].SessionID == toSession {
+			to = &runs[i]
+		}
+	}
 
-	// Make a tidy display
-	fmt.Println()
-	fmt.Println(lineSeparator)
+	if (from == nil || to == nil) && len(runs) >= 2 {
+		from, to = &runs[len(runs)-2], &runs[len(runs)-1]
+	}
 
-	// Delete the temp. file
-	_ = os.Remove(urlExtractFile)
+	return from, to
 }
 
-// Write the static Regex to the segments file
-func insertStaticRegex(regexText string) error {
+// Format a history date for display
+func historyDate(date string) string {
 
-	//Open the file in append mode, create if it doesn't exist
-	outputFile, err := os.OpenFile(regexOutputFile, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	parsedDate, err := time.Parse(time.RFC3339, date)
 	if err != nil {
-		panic(err)
+		return date
 	}
 
-	defer func() {
-		if err := outputFile.Close(); err != nil {
-			fmt.Println(red+"Error. insertStaticRegex. Closing (12):"+reset, err)
-		}
-	}()
+	return parsedDate.Format("2006-01-02 15:04:05")
+}
 
-	//Create a writer to write to the output file
-	writer := bufio.NewWriter(outputFile)
+// Semantic differences between two runs. Analysis, parameters, thresholds, platforms, folders & other segment values
+func diffHistoryRuns(from *historyRun, to *historyRun) []historyChange {
 
-	_, err = writer.WriteString(regexText)
-	if err != nil {
-		fmt.Printf(red+"\nError. insertStaticRegex. Cannot write to outputfile: %v\n"+reset, err)
-		panic(err)
-	}
+	var changes []historyChange
 
-	//Flush the writer to ensure all data is written to the file
-	err = writer.Flush()
-	if err != nil {
-		fmt.Printf(red+"\nError. insertStaticRegex. Cannot flush writer: %v\n"+reset, err)
-		os.Exit(1)
+	if from.AnalysisSlug != to.AnalysisSlug {
+		changes = append(changes, historyChange{"Analysis", from.AnalysisSlug + " → " + to.AnalysisSlug})
+	}
+	if from.URLCount != to.URLCount {
+		changes = append(changes, historyChange{"URLs analysed", fmt.Sprintf("%d → %d", from.URLCount, to.URLCount)})
 	}
 
-	return err
-}
-
-func writeLog(sessionID, organisation, project, statusDescription string) {
+	// Parameters & thresholds
+	if from.Parameters.ThresholdPercent != to.Parameters.ThresholdPercent {
+		changes = append(changes, historyChange{"Threshold changed", fmt.Sprintf("Threshold percent %g → %g", from.Parameters.ThresholdPercent, to.Parameters.ThresholdPercent)})
+	}
+	if from.Parameters.MaxURLsToProcess != to.Parameters.MaxURLsToProcess {
+		changes = append(changes, historyChange{"Parameter changed", fmt.Sprintf("Max. URLs to process %d → %d", from.Parameters.MaxURLsToProcess, to.Parameters.MaxURLsToProcess)})
+	}
+	if from.Parameters.URLFilter != to.Parameters.URLFilter {
+		changes = append(changes, historyChange{"Parameter changed", fmt.Sprintf("URL filter \"%s\" → \"%s\"", from.Parameters.URLFilter, to.Parameters.URLFilter)})
+	}
+	if from.Parameters.Level1Threshold != to.Parameters.Level1Threshold {
+		changes = append(changes, historyChange{"Threshold changed", fmt.Sprintf("Level 1 folder threshold %d → %d URLs", from.Parameters.Level1Threshold, to.Parameters.Level1Threshold)})
+	}
+	if from.Parameters.Level2Threshold != to.Parameters.Level2Threshold {
+		changes = append(changes, historyChange{"Threshold changed", fmt.Sprintf("Level 2 folder threshold %d → %d URLs", from.Parameters.Level2Threshold, to.Parameters.Level2Threshold)})
+	}
 
-	// Define log file name
-	fileName := envSegmentifyLiteLogFolder + "/_segmentifyLite.log"
+	// Platforms
+	added, removed := diffStrings(from.Platforms, to.Platforms)
+	for _, platform := range added {
+		changes = append(changes, historyChange{"Platform newly detected", platform})
+	}
+	for _, platform := range removed {
+		changes = append(changes, historyChange{"Platform no longer detected", platform})
+	}
 
-	// Check if the log file exists
-	fileExists := true
-	if _, err := os.Stat(fileName); os.IsNotExist(err) {
-		fileExists = false
+	fromModel, toModel := from.Segmentation, to.Segmentation
+	if fromModel == nil || toModel == nil {
+		return changes
 	}
 
-	// Open or create the log file
-	file, err := os.OpenFile(fileName, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if err != nil {
-		log.Fatalf(red+"Error. writeLog. Cannot oprn log file: %s"+reset, err)
+	// Folders
+	for _, folderSegment := range []string{"sl_level1_folders", "sl_level2_folders"} {
+		added, removed := diffStrings(segmentLabels(fromModel, folderSegment), segmentLabels(toModel, folderSegment))
+		for _, folder := range added {
+			changes = append(changes, historyChange{"Folder added (" + folderSegment + ")", folder})
+		}
+		for _, folder := range removed {
+			changes = append(changes, historyChange{"Folder removed (" + folderSegment + ")", folder})
+		}
 	}
 
-	defer func() {
-		if err := file.Close(); err != nil {
-			fmt.Println(red+"Error. writeLog. Closing (13):"+reset, err)
+	// Other segments & values
+	for _, change := range diffSegmentations(fromModel, toModel) {
+		if change.Segment == "sl_level1_folders" || change.Segment == "sl_level2_folders" {
+			continue
 		}
-	}()
+		if change.Value == "" {
+			changes = append(changes, historyChange{"Segment " + change.Change, change.Segment + " (" + change.Detail + ")"})
+			continue
+		}
+		changes = append(changes, historyChange{"Value " + change.Change, change.Segment + "/" + change.Value + ": " + change.Detail})
+	}
 
-	// Get current time
-	currentTime := time.Now().Format("2006-01-02 15:04:05")
+	return changes
+}
 
-	// Construct log record
-	logRecord := fmt.Sprintf("%s,%s,%s,%s,%s\n",
-		sessionID, currentTime, organisation, project, statusDescription)
+// The value labels of a segment, without the home page & catch-all values
+func segmentLabels(model *segmentation, segmentName string) []string {
 
-	// If the file doesn't exist, write header first
-	if !fileExists {
-		header := "SessionID,Date,Organisation,Project,Status\n"
-		if _, err := file.WriteString(header); err != nil {
-			log.Fatalf(red+"Error. writeLog. Failed to write log header: %s"+reset, err)
+	var labels []string
+	for _, modelSegment := range model.Segments {
+		if modelSegment.Name != segmentName {
+			continue
+		}
+		for _, value := range modelSegment.Values {
+			if isCatchAllValue(value) || valueRulesText(value) == valueRulesText(homeValue()) {
+				continue
+			}
+			labels = append(labels, value.Label)
 		}
 	}
 
-	// Write log record to file
-	if _, err := file.WriteString(logRecord); err != nil {
-		log.Fatalf(red+"Error. writeLog. Cannot write to log file: %s"+reset, err)
-	}
+	return labels
 }
 
-func generateSessionID(length int) (string, error) {
-	// Generate random sessionID
-	sessionID := make([]byte, length)
-	if _, err := rand.Read(sessionID); err != nil {
-		return "", err
-	}
-
-	// Add to the execution increment
-	sessionIDCounter++
+// The strings added to & removed from a list
+func diffStrings(from []string, to []string) (added []string, removed []string) {
 
-	var builder strings.Builder
-	builder.WriteString(strconv.Itoa(sessionIDCounter))
-	builder.WriteString("-")
-	builder.WriteString(base64.URLEncoding.EncodeToString(sessionID))
+	fromSet := make(map[string]bool)
+	for _, item := range from {
+		fromSet[item] = true
+	}
+	toSet := make(map[string]bool)
+	for _, item := range to {
+		toSet[item] = true
+		if !fromSet[item] {
+			added = append(added, item)
+		}
+	}
+	for _, item := range from {
+		if !toSet[item] {
+			removed = append(removed, item)
+		}
+	}
 
-	// Convert the builder to a string and return
-	return builder.String(), nil
+	return added, removed
 }
 
 // Generate the HTML pages used to present the segmentation regex
@@ -1479,6 +5286,42 @@ async function copyFileToClipboard() {
 	htmlContent += fmt.Sprintf("<h2 style='color: deepskyblue;'>Segmentation regex generation is complete</h2>\n")
 	htmlContent += fmt.Sprintf("<h3 style='color: dimgray; padding-left: 20px; padding-right: 20px;'>The regex has been copied to the clipboard ready for pasting directly into your Botify project.</h3>\n")
 	htmlContent += fmt.Sprintf("<h4 style='color: dimgray;'><a href='%s' target='_blank'>Click here to open the segment editor for %s</a></h4>\n", projectURL, project)
+	htmlContent += fmt.Sprintf("<h4 style='color: dimgray;'>Download: <a href='%s' download>Botify segmentation</a> | <a href='%s' download>JSON</a> | <a href='%s' download>YAML</a> | <a href='%s' download>URL counts (CSV)</a> | <a href='%s' download>Summary (Markdown)</a></h4>\n",
+		exportDSLFile, exportJSONFile, exportYAMLFile, exportCSVFile, exportMarkdownFile)
+
+	// Links to the segments converted to other tools' formats
+	var converterLinks []string
+	for _, converter := range segmentConverters {
+		converterLinks = append(converterLinks, fmt.Sprintf("<a href='%s' download>%s</a>", converter.fileName, converter.name))
+	}
+	htmlContent += fmt.Sprintf("<h4 style='color: dimgray;'>Convert: %s</h4>\n", strings.Join(converterLinks, " | "))
+
+	// Merge with the existing segmentation. The merged segmentation is the one copied to the clipboard
+	if segmentMerge != nil {
+		htmlContent += fmt.Sprintf("<h4 style='color: dimgray;'>Merged with the existing segmentation (%s). %d manual segments kept, %d stale generated segments kept unchanged, %d conflicts: <a href='%s' download>Merged segmentation</a> | <a href='%s' download>Merge report</a></h4>\n",
+			html.EscapeString(segmentMerge.Source), len(segmentMerge.Kept), len(segmentMerge.Stale), len(segmentMerge.Conflicts), mergedSegmentationFile, mergeReportFile)
+	}
+
+	// Apply the segmentation to the project, after reviewing the changes
+	htmlContent += fmt.Sprintf("<h4 style='color: dimgray;'><a href='%s://%s/apply?session=%s' target='_blank'>Review the changes and apply the segmentation to %s</a></h4>\n", protocol, fullHost, url.QueryEscape(sessionID), project)
+
+	// Folder level deltas when compared with another analysis
+	if compareSlug != "" {
+		htmlContent += fmt.Sprintf("<h4 style='color: dimgray;'>Compared with analysis %s: <a href='%s' download>Folder deltas (CSV)</a></h4>\n", html.EscapeString(compareSlug), crawlDeltaFile)
+	}
+
+	// robots.txt rules blocking the most crawled URLs
+	if robotsEvaluated {
+		htmlContent += fmt.Sprintf("<h4 style='color: dimgray;'>robots.txt: <a href='%s' download>URLs blocked by each rule (CSV)</a></h4>\n", robotsReportFile)
+	}
+
+	// Sitemap presence of each folder
+	if sitemapsCompared {
+		htmlContent += fmt.Sprintf("<h4 style='color: dimgray;'>Sitemaps: <a href='%s' download>Sitemap presence by folder (CSV)</a></h4>\n", sitemapPresenceFile)
+	}
+
+	// Segmentation history, showing the changes since the previous run
+	htmlContent += fmt.Sprintf("<h4 style='color: dimgray;'><a href='%s://%s/history?organization=%s&project=%s' target='_blank'>Segmentation history & changes since the previous run</a></h4>\n", protocol, fullHost, url.QueryEscape(organisation), url.QueryEscape(project))
 	htmlContent += fmt.Sprintf("</div>\n")
 
 	// Save the HTML to a file
@@ -1748,9 +5591,33 @@ func startUp() {
 	// Get the environment variables for token, log & cache folder
 	envBotifyAPIToken, envSegmentifyLiteLogFolder, envSegmentifyLiteFolder, envSegmentifyLiteHostingMode = getEnvVariables()
 
+	// Optional Botify API URL, used to run against a local stand-in of the API
+	if envBotifyAPIURL := os.Getenv("envBotifyAPIURL"); envBotifyAPIURL != "" {
+		botifyAPIURL = strings.TrimSuffix(envBotifyAPIURL, "/")
+		fmt.Println(yellow + "Botify API URL: " + botifyAPIURL + reset)
+	}
+
 	// Get the hostname and port
 	getHostnamePort()
 
+	// Get the file type dictionary
+	getFileTypes()
+
+	// Get the segment templates folder
+	getTemplatesFolder()
+
+	// Get the folder label mapping
+	getLabelMapping()
+
+	// Omit the generation timestamp
+	getOmitTimestamp()
+
+	// Allow the segmentation to be pushed to the project
+	getAllowPush()
+
+	// Get the default URL filters from the command line
+	getURLFilterFlags()
+
 	fmt.Println(green + "\n... waiting for requests\n" + reset)
 }
 
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Apply the generated segmentation to a stand-in of the Botify project segmentation endpoint
+// GET shows the differences, the PUT is only sent when the previewed hash is confirmed
+func TestApplySegmentation(t *testing.T) {
+
+	currentDSL := "[segment:manual]\n@Blog\npath /blog/*\n\n@~Other\npath /*\n\n[segment:sl_host]\n@www.example.com\nurl rx:^https?://www\\.example\\.com(:80|:443)?[/?#]\n"
+	generatedDSL := "# Regex made with love using segmentifyLite v0.3\n\n[segment:sl_host]\n@shop.example.com\nurl rx:^https?://shop\\.example\\.com(:80|:443)?[/?#]\n\n@~Other\npath /*\n"
+
+	var pushed []botifySegmentation
+	var authorization string
+	botify := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/projects/org/proj/segmentation" {
+			http.NotFound(w, r)
+			return
+		}
+		authorization = r.Header.Get("Authorization")
+		switch r.Method {
+		case http.MethodGet:
+			_ = json.NewEncoder(w).Encode(botifySegmentation{Segmentation: currentDSL})
+		case http.MethodPut:
+			var payload botifySegmentation
+			if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			pushed = append(pushed, payload)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))
+	defer botify.Close()
+
+	savedURL, savedToken, savedLogFolder, savedAllowPush := botifyAPIURL, envBotifyAPIToken, envSegmentifyLiteLogFolder, allowPush
+	defer func() {
+		botifyAPIURL, envBotifyAPIToken, envSegmentifyLiteLogFolder, allowPush = savedURL, savedToken, savedLogFolder, savedAllowPush
+		delete(generatedRuns, "test-session")
+	}()
+	botifyAPIURL = botify.URL
+	envBotifyAPIToken = "test-token"
+	envSegmentifyLiteLogFolder = t.TempDir()
+	allowPush = true
+
+	runFolder := t.TempDir()
+	if err := os.WriteFile(runFolder+"/"+exportDSLFile, []byte(generatedDSL), 0644); err != nil {
+		t.Fatal(err)
+	}
+	keepGeneratedRun("test-session", generatedRun{"org", "proj", runFolder, time.Now()})
+
+	// GET. The differences are listed and the manual segment is merged in
+	preview := httptest.NewRecorder()
+	applySegmentationHandler(preview, httptest.NewRequest(http.MethodGet, "/apply?session=test-session", nil))
+	if preview.Code != http.StatusOK {
+		t.Fatalf("GET status = %d, want %d", preview.Code, http.StatusOK)
+	}
+	previewBody := preview.Body.String()
+	for _, want := range []string{"Manual segments kept: manual", "<td>shop.example.com</td><td>added</td>", "<td>www.example.com</td><td>removed</td>"} {
+		if !strings.Contains(previewBody, want) {
+			t.Errorf("preview does not contain %q", want)
+		}
+	}
+	if authorization != "token test-token" {
+		t.Errorf("Authorization = %q, want %q", authorization, "token test-token")
+	}
+	hashMatch := regexp.MustCompile(`name="hash" value="([0-9a-f]{64})"`).FindStringSubmatch(previewBody)
+	if hashMatch == nil {
+		t.Fatal("preview does not contain the segmentation hash")
+	}
+	if len(pushed) != 0 {
+		t.Fatalf("GET pushed the segmentation")
+	}
+
+	post := func(hash string) *httptest.ResponseRecorder {
+		form := url.Values{"session": {"test-session"}, "confirm": {"yes"}, "hash": {hash}}
+		request := httptest.NewRequest(http.MethodPost, "/apply", strings.NewReader(form.Encode()))
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		response := httptest.NewRecorder()
+		applySegmentationHandler(response, request)
+		return response
+	}
+
+	// POST with a hash which is not the previewed one. Nothing is pushed
+	if response := post(strings.Repeat("0", 64)); response.Code != http.StatusConflict || len(pushed) != 0 {
+		t.Fatalf("POST with a stale hash: status = %d, pushes = %d, want %d and no push", response.Code, len(pushed), http.StatusConflict)
+	}
+
+	// POST with the previewed hash. The merged segmentation is pushed
+	response := post(hashMatch[1])
+	if response.Code != http.StatusOK || !strings.Contains(response.Body.String(), "Segmentation applied") {
+		body, _ := io.ReadAll(response.Body)
+		t.Fatalf("POST status = %d, body = %s", response.Code, body)
+	}
+	if len(pushed) != 1 {
+		t.Fatalf("pushes = %d, want 1", len(pushed))
+	}
+	if segmentationHash(pushed[0].Segmentation) != hashMatch[1] {
+		t.Errorf("the pushed segmentation is not the previewed one")
+	}
+	for _, want := range []string{"[segment:manual]", "@shop.example.com"} {
+		if !strings.Contains(pushed[0].Segmentation, want) {
+			t.Errorf("pushed segmentation does not contain %q", want)
+		}
+	}
+	if strings.Contains(pushed[0].Segmentation, "@www.example.com") {
+		t.Errorf("pushed segmentation still contains the previous sl_host value")
+	}
+}
+
+// The rules of the Googlebot group are used, the "*" group otherwise. Groups for the same user agent are combined
+func TestParseRobotsTxt(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{
+			name:    "Googlebot group over *",
+			content: "User-agent: *\nDisallow: /private\n\nUser-agent: Googlebot\nDisallow: /search\nAllow: /search/help\n",
+			want:    []string{"Disallow /search", "Allow /search/help"},
+		},
+		{
+			name:    "* group when there is no Googlebot group",
+			content: "User-agent: Bingbot\nDisallow: /bing\n\nUser-agent: *\nDisallow: /private # comment\n",
+			want:    []string{"Disallow /private"},
+		},
+		{
+			name:    "Googlebot groups merged",
+			content: "User-agent: googlebot\nDisallow: /a\n\nUser-agent: *\nDisallow: /\n\nUser-Agent: Googlebot\nDisallow: /b\n",
+			want:    []string{"Disallow /a", "Disallow /b"},
+		},
+		{
+			name:    "Group shared by several user agents",
+			content: "User-agent: Bingbot\nUser-agent: Googlebot\nDisallow: /shared\n\nUser-agent: *\nDisallow: /\n",
+			want:    []string{"Disallow /shared"},
+		},
+		{
+			name:    "Empty Disallow allows everything",
+			content: "User-agent: Googlebot\nDisallow:\n\nUser-agent: *\nDisallow: /\n",
+			want:    nil,
+		},
+		{
+			name:    "No group",
+			content: "Sitemap: https://www.example.com/sitemap.xml\n",
+			want:    nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got []string
+			for _, rule := range parseRobotsTxt(test.content) {
+				ruleType := "Disallow"
+				if rule.Allow {
+					ruleType = "Allow"
+				}
+				got = append(got, ruleType+" "+rule.Pattern)
+			}
+			if strings.Join(got, ", ") != strings.Join(test.want, ", ") {
+				t.Errorf("rules = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+// The longest matching pattern decides, allow wins a tie. "*" matches any characters & "$" anchors the end
+func TestRobotsDecision(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		content string
+		path    string
+		want    string
+	}{
+		{"No matching rule", "User-agent: *\nDisallow: /private\n", "/public", "allowed"},
+		{"Prefix match", "User-agent: *\nDisallow: /private\n", "/private/page?id=1", "Disallow /private"},
+		{"Wildcard", "User-agent: *\nDisallow: /*.pdf\n", "/docs/file.pdf?download=1", "Disallow /*.pdf"},
+		{"Wildcard without match", "User-agent: *\nDisallow: /*.pdf\n", "/docs/file.html", "allowed"},
+		{"End anchor", "User-agent: *\nDisallow: /*.pdf$\n", "/docs/file.pdf", "Disallow /*.pdf$"},
+		{"End anchor with a query", "User-agent: *\nDisallow: /*.pdf$\n", "/docs/file.pdf?download=1", "allowed"},
+		{"Query pattern", "User-agent: *\nDisallow: /*?sort=\n", "/shoes?sort=price", "Disallow /*?sort="},
+		{"Special characters are literal", "User-agent: *\nDisallow: /a.b+c\n", "/aXb+c", "allowed"},
+		{"Longest match allow", "User-agent: *\nDisallow: /shop\nAllow: /shop/sale\n", "/shop/sale/shoes", "Allow /shop/sale"},
+		{"Longest match disallow", "User-agent: *\nAllow: /shop\nDisallow: /shop/cart\n", "/shop/cart", "Disallow /shop/cart"},
+		{"Allow wins a same length tie", "User-agent: *\nDisallow: /page\nAllow: /page\n", "/page", "Allow /page"},
+		{"Allow wins a same length wildcard tie", "User-agent: *\nDisallow: /*.php\nAllow: /index\n", "/index.php", "Allow /index"},
+		{"Empty Disallow", "User-agent: *\nDisallow:\n", "/anything", "allowed"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := "allowed"
+			if decision := robotsDecision(parseRobotsTxt(test.content), test.path); decision != nil {
+				got = "Disallow " + decision.Pattern
+				if decision.Allow {
+					got = "Allow " + decision.Pattern
+				}
+			}
+			if got != test.want {
+				t.Errorf("decision for %s = %q, want %q", test.path, got, test.want)
+			}
+		})
+	}
+}
+
+// URLs are classified by the first matching value of the robots.txt segment. URLs on other hosts are in ~Other
+func TestRobotsSegment(t *testing.T) {
+
+	savedExtractFile, savedCacheFolder, savedModel := urlExtractFile, cacheFolder, segmentModel
+	defer func() {
+		urlExtractFile, cacheFolder, segmentModel = savedExtractFile, savedCacheFolder, savedModel
+	}()
+	cacheFolder = t.TempDir()
+	urlExtractFile = cacheFolder + "/urls.tmp"
+	segmentModel = &segmentation{}
+
+	urls := "https://www.example.com/\nhttps://www.example.com/shop/cart\nhttps://www.example.com/shop/sale/shoes\nhttps://www.example.com/docs/file.pdf\nhttps://blog.example.com/shop/cart\n"
+	if err := os.WriteFile(urlExtractFile, []byte(urls), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	robotsSegment("User-agent: *\nDisallow: /shop\nAllow: /shop/sale\nDisallow: /*.pdf$\n", "https://www.example.com/robots.txt")
+
+	if len(segmentModel.Segments) != 1 {
+		t.Fatalf("segments = %d, want 1", len(segmentModel.Segments))
+	}
+	robotsSeg := segmentModel.Segments[0]
+
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://www.example.com/", "Allowed"},
+		{"https://www.example.com/shop/cart", "Disallowed /shop"},
+		{"https://www.example.com/shop/sale/shoes", "Allowed /shop/sale"},
+		{"https://www.example.com/docs/file.pdf", "Disallowed /*.pdf$"},
+		{"https://blog.example.com/shop/cart", "~Other"},
+	}
+
+	for _, test := range tests {
+		if got := firstMatchingValue(t, robotsSeg, test.url); got != test.want {
+			t.Errorf("%s is in %q, want %q", test.url, got, test.want)
+		}
+	}
+}
+
+// The label of the first value of a segment matching a URL
+func firstMatchingValue(t *testing.T, seg *segment, rawURL string) string {
+
+	t.Helper()
+	for _, value := range seg.Values {
+		regexes, matchAll, warnings := valueRegexes(value)
+		if len(warnings) > 0 {
+			t.Fatalf("value %s: %v", value.Label, warnings)
+		}
+		matches := 0
+		for _, regex := range regexes {
+			if regexp.MustCompile(regex).MatchString(rawURL) {
+				matches++
+			}
+		}
+		if (matchAll && matches == len(regexes)) || (!matchAll && matches > 0) {
+			return value.Label
+		}
+	}
+
+	return ""
+}
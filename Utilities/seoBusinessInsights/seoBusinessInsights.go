@@ -6,18 +6,25 @@ package main
 import (
 	"bytes"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/csv"
+	"encoding/hex"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"github.com/go-echarts/go-echarts/v2/charts"
 	"github.com/go-echarts/go-echarts/v2/opts"
 	"golang.org/x/text/language"
 	"golang.org/x/text/message"
 	"gopkg.in/ini.v1"
+	"html"
 	"io"
 	"math"
 	"net/http"
 	"os"
+	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -25,7 +32,24 @@ import (
 )
 
 // Version
-var version = "v0.3"
+var version = "v0.4"
+
+// changelog v0.4
+// Typed BQL query builder (collections, periods, dimensions, metrics, filters & sort). Results decoded by field name
+// Non-organic query. The medium dimension is taken from the conversion collection used (conversion.dip for GA4), GA4 orders are counted with transactions as for organic traffic
+// Monthly queries executed concurrently (maxConcurrentQueries in the .ini file). Paused & retried when rate limited
+// BQL response cache (long TTL for closed months, short for the current month). Force refresh option in the form
+// Reporting period (start & end month) & comparison period (previous, same period last year or custom) in the form & command line
+// Comparison values & changes in every chart & KPI table. Comparison totals in the river & gauge subtitles, comparison contribution in the organic & non-organic charts
+// Year-on-year comparison. Same months last year acquired with period_1, last year bars in the charts (whatever the comparison period) & YoY % in the KPI tables
+// Weekly (ISO week) & daily granularity. Compound weekly & daily growth rates (CWGR, CDGR) in the growth badges
+// Seasonal forecast (Holt-Winters) of revenue, visits & orders with 80% & 95% prediction intervals. Revenue forecast computed without integer division
+// Seasonal forecast initialised from the detrended first season. Placeholder charts are written when the history is too short
+// Ranking improvement scenario planner. CTR by position curve fitted on the non-branded keywords, scenario chosen in the form
+// Scenario clicks estimated from the impressions of the top non-branded keywords by impressions & the CTR at the new position
+// Non-branded keyword opportunity report (striking distance, low CTR & revenue opportunity). Sortable table & CSV download
+// Keyword opportunities taken from the scenario planner keywords, the keywords are no longer acquired twice
+// Keyword movers (new, lost, rising & falling) between the previous & the last period, branded & non-branded. CSV download
 
 // changelog v0.3
 // UI updates & refinements (seoBusinessInsights)
@@ -52,6 +76,58 @@ var envInsightsHostingMode string
 // Declare the mutex
 var mutex sync.Mutex
 
+// Botify API URL. Can be pointed to a local stand-in of the API with the envBotifyAPIURL environment variable
+var botifyAPIURL = "https://api.botify.com"
+
+// Maximum number of BQL queries executed at the same time. Set with maxConcurrentQueries in seoBusinessInsights.ini
+var maxConcurrentQueries = 4
+var bqlSlots = make(chan struct{}, maxConcurrentQueries)
+
+// Retries when the Botify API rate limit is reached (HTTP 429). Set with maxQueryRetries in seoBusinessInsights.ini
+var maxQueryRetries = 5
+var queryRetryDelay = 2 * time.Second
+
+// When the rate limit is reached all queries are paused until this time
+var bqlRetryAfter time.Time
+var bqlRetryMutex sync.Mutex
+
+// BQL responses are cached in the insights folder. Closed periods are kept longer than the current month
+// Set with cacheTTLClosedPeriods & cacheTTLCurrentPeriod in seoBusinessInsights.ini
+var cacheTTLClosed = 30 * 24 * time.Hour
+var cacheTTLCurrent = time.Hour
+
+// A period is closed once it ended more than cacheClosedAfterDays days ago. Analytics & Search Console data can still change in the days after
+// Set with cacheClosedAfterDays in seoBusinessInsights.ini
+var cacheClosedAfterDays = 7
+
+// Ignore the cached responses & execute all queries. Set with the "Force refresh" option in the form
+var forceRefresh bool
+
+// Reporting & comparison period. The defaults are set on the command line & can be changed in the form
+var defaultPeriod = reportingPeriod{Comparison: "none"}
+var activePeriod = reportingPeriod{Comparison: "none"}
+
+// Maximum number of weekly & daily periods in the reporting period
+var maxWeeklyPeriods = 53
+var maxDailyPeriods = 92
+
+// Comparison period insights, keyed by the start date of the paired reporting month
+var comparisonByMonth map[string]monthlyInsights
+var comparisonNameByMonth map[string]string
+
+// Comparison period insights aligned with the reporting months, the comparison totals & the period label
+var comparisonSeries []monthlyInsights
+var comparisonMonthNames []string
+var comparisonTotals periodTotals
+
+// The same months last year (period_1), keyed by the start date of the reporting month & the totals
+var lastYearByMonth map[string]monthlyInsights
+var lastYearTotals periodTotals
+var comparisonLabel string
+
+// Non-organic revenue, orders & visits for the comparison period. Used for the organic & non-organic contribution
+var comparisonNonOrganic revenueMetrics
+
 // Colours, symbols etc
 var purple = "\033[0;35m"
 var green = "\033[0;32m"
@@ -64,6 +140,10 @@ var clearScreen = "\033[H\033[2J"
 var lineSeparator = "█" + strings.Repeat("█", 130)
 
 // KPI Specific colours
+var kpiColourComparison = "Silver"
+var kpiColourComparisonAlt = "LightSteelBlue"
+var kpiColourLastYear = "DarkGray"
+var kpiColourLastYearAlt = "LightSlateGray"
 var kpiColourRevenue = "Coral"
 var kpiColourVisits = "Green"
 var kpiColourVisitsPerOrder = "DarkGoldenRod"
@@ -182,6 +262,9 @@ var maxVisitsPerOrder int
 // No. of months processed
 var noOfMonths int
 
+// No. of periods (months, weeks or days) requested, before the periods without revenue are removed
+var requestedPeriods int
+
 // Start and end date of the period
 var firstStartDatePeriod string
 var lastEndDatePeriod string
@@ -223,6 +306,124 @@ var forecastMaxVisits = 10000000
 var forecastVisitIncrements []int
 var forecastVisitIncrementsString []string
 
+// seasonalForecast is the forecast of a KPI for the next periods with the 80% & 95% prediction intervals
+type seasonalForecast struct {
+	KPI          string
+	Method       string
+	HistoryNames []string
+	History      []float64
+	Names        []string
+	Forecast     []float64
+	Lower80      []float64
+	Upper80      []float64
+	Lower95      []float64
+	Upper95      []float64
+}
+
+// Number of periods forecast (3 to 12, forecastPeriods in the .ini file)
+var forecastPeriods = 12
+
+// Seasonal forecasts for revenue, visits & orders
+var seasonalForecasts []seasonalForecast
+
+// Smoothing parameters tested when fitting the forecast & the z values of the 80% & 95% prediction intervals
+var smoothingGrid = []float64{0.1, 0.2, 0.3, 0.4, 0.5, 0.6, 0.7, 0.8, 0.9}
+var z80 = 1.2816
+var z95 = 1.96
+
+// ctrScenario is the estimated performance of the non-branded keywords for a ranking improvement scenario
+type ctrScenario struct {
+	Name           string
+	PositionGain   int
+	ImpressionGain float64
+	Clicks         float64
+	Visits         float64
+	Orders         float64
+	Revenue        float64
+}
+
+// Scenario chosen in the form. Keywords in positions 4 to 10 move up by scenarioPositionGain, impressions grow by scenarioImpressionGain %
+var defaultScenarioPositionGain = 3
+var defaultScenarioImpressionGain = 10.0
+var scenarioPositionGain = defaultScenarioPositionGain
+var scenarioImpressionGain = defaultScenarioImpressionGain
+var scenarioMinPosition = 4.0
+var scenarioMaxPosition = 10.0
+
+// CTR by position curve (CTR = a * position^b). A typical curve is used when the project data cannot be fitted
+var defaultCTRCurveA = 0.3
+var defaultCTRCurveB = -1.0
+var ctrCurveA float64
+var ctrCurveB float64
+var ctrCurveFitted bool
+var ctrCurveMaxPosition = 20
+
+// scenarioKeyword is a non-branded keyword of the last period. Used to fit the CTR curve & estimate the scenarios
+type scenarioKeyword struct {
+	Keyword     string
+	Impressions int
+	Clicks      int
+	AvgPosition float64
+}
+
+// Top non-branded keywords by impressions in the last period, keywords without clicks included
+// Also used for the opportunity report. The number acquired is set with opportunityKeywords in the .ini file
+var scenarioKeywords []scenarioKeyword
+var scenarioKeywordsLimit = 1000
+
+// Estimated scenarios. The first is the current performance, the second the scenario chosen in the form
+var scenarios []ctrScenario
+
+// keywordOpportunity is a non-branded keyword with its estimated opportunity
+type keywordOpportunity struct {
+	Keyword            string
+	Impressions        int
+	Clicks             int
+	CTR                float64
+	AvgPosition        float64
+	ExpectedCTR        float64
+	StrikingDistance   bool
+	LowCTR             bool
+	ClicksOpportunity  float64
+	RevenueOpportunity float64
+}
+
+// No. of keyword opportunities displayed in the table
+var maxOpportunitiesDisplayed = 200
+
+// Striking distance positions, the position they are valued at & the minimum impressions (top quartile, computed)
+var strikingDistanceMin = 4.0
+var strikingDistanceMax = 20.0
+var strikingDistanceTarget = 3.0
+var strikingDistanceImpressions int
+
+// A CTR below this ratio of the expected CTR for the position is flagged as low
+var lowCTRRatio = 0.7
+
+// Non-branded keyword opportunities, largest revenue opportunity first
+var keywordOpportunities []keywordOpportunity
+
+// keywordMover is a keyword's clicks & position in the last period & the previous period
+// Movement is "New", "Lost", "Rising" or "Falling"
+type keywordMover struct {
+	Keyword             string
+	Branded             bool
+	Movement            string
+	Clicks              int
+	PreviousClicks      int
+	AvgPosition         float64
+	PreviousAvgPosition float64
+}
+
+// No. of keywords acquired for each period (moversKeywords in the .ini file) & the no. of top movers displayed for each movement
+var moversKeywords = 500
+var noTopMovers = 10
+
+// Keyword movers & the periods compared
+var keywordMoversBranded []keywordMover
+var keywordMoversNonBranded []keywordMover
+var keywordMoversLabel string
+
 // Project currency
 var currencyCode string
 var currencySymbol string
@@ -260,37 +461,107 @@ type botifyResponseData struct {
 	} `json:"results"`
 }
 
-// keywordsData struct used to store Keywords dimensions and metrics
-type keywordsData struct {
-	Results []struct {
-		Dimensions []interface{} `json:"dimensions"`
-		Metrics    []*float64    `json:"metrics,omitempty"`
-	} `json:"results"`
-}
-
 // analyticsID is used to identify which analytics tool is in use
 type analyticsIDData struct {
 	ID                 string `json:"id"`
 	AnalyticsDateStart string `json:"date_start"`
 }
 
-// The Result struct is used to store the revenue, orders and visits
-type Result struct {
-	Dimensions []interface{} `json:"dimensions"`
-	Metrics    []float64     `json:"metrics"`
+// bqlQuery is a BQL query. Marshalled with encoding/json when sent to the Botify query API
+type bqlQuery struct {
+	Collections []string   `json:"collections"`
+	Periods     [][]string `json:"periods"`
+	Query       bqlBody    `json:"query"`
+}
+
+// bqlBody contains the dimensions, metrics, filters & sort order of the query
+type bqlBody struct {
+	Dimensions []string   `json:"dimensions"`
+	Metrics    []string   `json:"metrics"`
+	Filters    *bqlFilter `json:"filters,omitempty"`
+	Sort       []bqlSort  `json:"sort,omitempty"`
+}
+
+// bqlFilter is a predicate on a field, or a combination of filters (and, or, not)
+type bqlFilter struct {
+	Field     string
+	Predicate string
+	Value     interface{}
+	And       []bqlFilter
+	Or        []bqlFilter
+	Not       *bqlFilter
 }
-type Response struct {
-	Results []Result `json:"results"`
+
+// bqlSort sorts on the dimension or metric at the specified index
+type bqlSort struct {
+	Index int    `json:"index"`
+	Type  string `json:"type"`
+	Order string `json:"order"`
 }
 
-// searchConsole is used to acquire the non-brand insights
-type searchConsoleData struct {
+// bqlResponse is the raw response. Metrics are null when no data is available
+type bqlResponse struct {
 	Results []struct {
 		Dimensions []interface{} `json:"dimensions"`
-		Metrics    []float64     `json:"metrics"`
+		Metrics    []*float64    `json:"metrics"`
 	} `json:"results"`
 }
 
+// bqlRow is a result with the dimensions & metrics keyed by the field names used in the query
+type bqlRow struct {
+	Dimensions map[string]interface{}
+	Metrics    map[string]float64
+}
+
+// revenueMetrics is used to store the revenue, orders and visits
+type revenueMetrics struct {
+	Orders  int
+	Revenue int
+	Visits  int
+}
+
+// searchConsoleMetrics is used to store the non-brand insights
+type searchConsoleMetrics struct {
+	Impressions int
+	Clicks      int
+	CTR         float64
+	AvgPosition float64
+}
+
+// reportingPeriod is the reporting period (start & end month, YYYY-MM) and the comparison period
+// Comparison is "none", "previous", "lastYear" or "custom" (CompareStartMonth to CompareEndMonth)
+// Granularity is "monthly" (default), "weekly" (ISO weeks) or "daily"
+type reportingPeriod struct {
+	StartMonth        string
+	EndMonth          string
+	Comparison        string
+	CompareStartMonth string
+	CompareEndMonth   string
+	Granularity       string
+}
+
+// periodTotals is used to store the totals & averages of a period
+type periodTotals struct {
+	Revenue        int
+	Visits         int
+	Orders         int
+	AvgOrderValue  int
+	AvgVisitValue  float64
+	VisitsPerOrder int
+	Impressions    int
+	Clicks         int
+	CTR            float64
+	AvgPosition    float64
+}
+
+// keywordMetrics is used to store the keyword dimension and metrics
+type keywordMetrics struct {
+	Keyword     string
+	Clicks      int
+	AvgPosition float64
+	CTR         float64
+}
+
 var company string
 
 func main() {
@@ -317,6 +588,9 @@ func main() {
 		}
 		organization = r.Form.Get("organization")
 		project = r.Form.Get("project")
+		forceRefresh = r.Form.Get("forceRefresh") == "yes"
+		scenarioPositionGain, scenarioImpressionGain = scenarioFromForm(r)
+		activePeriod = periodFromForm(r)
 
 		// Generate a session ID used for grouping log entries
 		sessionID, err = generateSessionID(8)
@@ -367,6 +641,30 @@ func main() {
 			return
 		}
 
+		// A Botify API query failed
+		if dataStatus == "errorQueryFailed" {
+			writeLog(sessionID, organization, project, "-", "Botify API query failed")
+			generateErrorPage("A Botify API query failed. The API may be unavailable or the rate limit reached, try again later (" + organization + "/" + project + ")")
+			http.Redirect(w, r, insightsCacheFolder+"/"+"go_seo_BusinessInsights_error.html", http.StatusFound)
+			return
+		}
+
+		// The reporting or comparison period is invalid
+		if dataStatus == "errorInvalidPeriod" {
+			writeLog(sessionID, organization, project, "-", "Invalid reporting period")
+			generateErrorPage("The reporting period is invalid. " + activePeriod.validate().Error() + " (" + organization + "/" + project + ")")
+			http.Redirect(w, r, insightsCacheFolder+"/"+"go_seo_BusinessInsights_error.html", http.StatusFound)
+			return
+		}
+
+		// No analytics data is available for the reporting period
+		if dataStatus == "errorNoDataForPeriod" {
+			writeLog(sessionID, organization, project, "-", "No data for the reporting period")
+			generateErrorPage("No analytics data is available for the reporting period " + activePeriod.description() + " (" + organization + "/" + project + ")")
+			http.Redirect(w, r, insightsCacheFolder+"/"+"go_seo_BusinessInsights_error.html", http.StatusFound)
+			return
+		}
+
 		// Engagement analytics has not been configured
 		if dataStatus == "errorNoKWFound" {
 			writeLog(sessionID, organization, project, "-", "No keywords data found")
@@ -447,6 +745,21 @@ func businessInsightsDashboard(sessionID string) {
 	// Forecast narrative
 	textForecastNarrative()
 
+	// Seasonal forecast charts & table
+	lineSeasonalForecast()
+	tableSeasonalForecast()
+
+	// Ranking improvement scenario planner
+	lineCTRCurve()
+	tableScenarioPlanner()
+
+	// Keyword opportunity report
+	tableKeywordOpportunities()
+
+	// Keyword movers - Branded & non-branded
+	textKeywordMovers(true)
+	textKeywordMovers(false)
+
 	// Non-organic comparison
 	barOrganic()
 
@@ -490,6 +803,15 @@ func getBusinessInsights(sessionID string) string {
 	insightsCacheFolder = envInsightsFolder + "/" + sessionID + organization
 	createInsightsCacheFolder(insightsCacheFolder)
 
+	// Check the reporting & comparison period
+	if err := activePeriod.validate(); err != nil {
+		fmt.Println(red+"Error. getBusinessInsights. Invalid reporting period:"+reset, err)
+		return "errorInvalidPeriod"
+	}
+	if description := activePeriod.description(); description != "" {
+		fmt.Printf("%s%s%s Reporting period: %s\n", yellow, sessionID, reset, description)
+	}
+
 	// Get the currency used
 	getCurrencyStatus := getCurrencyCompany()
 	if getCurrencyStatus == "errorNoProjectFound" {
@@ -522,26 +844,29 @@ func getBusinessInsights(sessionID string) string {
 
 	// Get the date ranges
 	dateRanges := calculateDateRanges(analyticsDateStart)
+	if len(dateRanges.Ranges) == 0 {
+		fmt.Println(red+"Error. getBusinessInsights. No analytics data for the reporting period", organization+"/"+project+reset)
+		return "errorNoDataForPeriod"
+	}
+	requestedPeriods = len(dateRanges.Ranges)
 
 	var firstStartDate, lastEndDate time.Time
 
 	// Initialize the first start date and last end date
-	firstStartDate = dateRanges.MonthlyRanges[0][0]
-	lastEndDate = dateRanges.MonthlyRanges[0][1]
+	firstStartDate = dateRanges.Ranges[0][0]
+	lastEndDate = dateRanges.Ranges[0][1]
 
 	// Populate the slice with string versions of the dates for use in the BQL
 	// This is where we convert the date to YYYMMDD format
-	for _, dateRange := range dateRanges.MonthlyRanges {
+	for _, dateRange := range dateRanges.Ranges {
 
 		startMonthDate := dateRange[0].Format("20060102")
 		endMonthDate := dateRange[1].Format("20060102")
 		startMonthDates = append(startMonthDates, startMonthDate)
 		endMonthDates = append(endMonthDates, endMonthDate)
 
-		// Get the month name
-		startDate, _ := time.Parse("20060102", startMonthDate)
-		startMthName := startDate.Format("January 2006")
-		startMonthNames = append(startMonthNames, startMthName)
+		// Get the period name (month, ISO week or day)
+		startMonthNames = append(startMonthNames, periodLabel(dateRange[0]))
 
 		// Get the first and end date. Used when calculating non-organic revenue for the whole period
 		if dateRange[0].Before(firstStartDate) {
@@ -566,6 +891,11 @@ func getBusinessInsights(sessionID string) string {
 	getRevenueAndSearchConsoleDataStatus := getRevenueAndSearchConsoleData(analyticsID, startMonthDates, endMonthDates, sessionID)
 
 	// Error checking
+	// Exit if a query failed
+	if getRevenueAndSearchConsoleDataStatus == "errorQueryFailed" {
+		writeLog(sessionID, organization, project, analyticsID, "Botify API query failed")
+		return getRevenueAndSearchConsoleDataStatus
+	}
 	// Exit if Engagement Analytics has not been configured
 	if getRevenueAndSearchConsoleDataStatus == "errorNoEAFound" {
 		writeLog(sessionID, organization, project, analyticsID, "EngagementAnalytics not configured")
@@ -579,6 +909,12 @@ func getBusinessInsights(sessionID string) string {
 
 	writeLog(sessionID, organization, project, analyticsID, "Revenue data acquired")
 
+	// Get the insights for the comparison period
+	if activePeriod.comparing() {
+		getComparisonData(analyticsID)
+		writeLog(sessionID, organization, project, analyticsID, "Comparison data acquired")
+	}
+
 	// Get the keywords data
 	// Get last months' date range
 	kwStartDate := startMonthDates[len(startMonthDates)-1]
@@ -596,16 +932,36 @@ func getBusinessInsights(sessionID string) string {
 
 	writeLog(sessionID, organization, project, analyticsID, "Keyword data acquired")
 
+	// Get the keywords for the CTR curve, the scenarios & the opportunity report
+	generateScenarioKeywordsBQL(kwStartDate, kwEndDate)
+
+	// Get the keyword movers (last period vs. the previous period)
+	getKeywordMovers(kwStartDate, kwEndDate)
+
 	seoScImpressions, seoScClicks, seoScAvgPosition, seoScCTR, seoRevenue, seoVisits, seoOrders, seoOrderValue, seoVisitValue, seoVisitsPerOrder, startMonthDates, endMonthDates, startMonthNames = cleanInsights(seoScImpressions, seoScClicks, seoScAvgPosition, seoScCTR, seoRevenue, seoVisits, seoOrders, seoOrderValue, seoVisitValue, seoVisitsPerOrder, startMonthDates, endMonthDates, startMonthNames)
 
+	// Pair the comparison & last year months with the remaining months
+	alignLastYear()
+	if activePeriod.comparing() {
+		alignComparison()
+	}
+
 	// Calculate the CMGR values
 	calculateCMGR(sessionID)
 
 	// Calculate the forecast
 	forecastDataCompute()
+	seasonalForecastCompute()
+
+	// Ranking improvement scenarios
+	scenarioPlannerCompute()
+
+	// Keyword opportunities. Uses the CTR curve fitted on the same keywords for the scenarios
+	keywordOpportunitiesCompute()
 
 	println()
-	println(green+"No. of months: "+reset, noOfMonths)
+	_, unitPlural := granularityUnit()
+	println(green+"No. of "+unitPlural+": "+reset, noOfMonths)
 
 	return "success"
 }
@@ -639,11 +995,29 @@ func resetMetrics() {
 	organicPerformanceValues = nil
 	nonOrganicPerformanceCategory = nil
 	nonOrganicPerformanceValues = nil
+	comparisonByMonth = nil
+	comparisonNameByMonth = nil
+	comparisonSeries = nil
+	comparisonMonthNames = nil
+	comparisonTotals = periodTotals{}
+	comparisonNonOrganic = revenueMetrics{}
+	lastYearByMonth = nil
+	lastYearTotals = periodTotals{}
+	comparisonLabel = ""
 
 	// Reset integers and floats
 	metricsVisitsOrganic = 0
 	metricsRevenueOrganic = 0
 	metricsOrdersOrganic = 0
+	metricsRevenueNonOrganic = 0
+	metricsOrdersNonOrganic = 0
+	metricsVisitsNonOrganic = 0
+	metricsRevenueNonOrganicPC = 0.00
+	metricsOrdersNonOrganicPC = 0.00
+	metricsVisitsNonOrganicPC = 0.00
+	totalAverageVisitValueNonOrganic = 0.00
+	totalAverageOrderValueNonOrganic = 0.00
+	totalAverageVisitsPerOrderNonOrganic = 0.00
 	cmgrRevenue = 0.00
 	cmgrVisits = 0.00
 	cmgrVisitValue = 0.00
@@ -669,16 +1043,33 @@ func getRevenueAndSearchConsoleData(analyticsID string, startMonthDates []string
 	visitsDataIssue = false
 	ordersDataIssue = false
 
-	// Get monthly insights
+	// Get monthly insights & the same months last year. The queries for all months are executed concurrently
+	insights, lastYearInsights := fetchMonthlyInsights(analyticsID, startMonthDates, endMonthDates, true)
+	lastYearByMonth = make(map[string]monthlyInsights)
+	for i, startMonthDate := range startMonthDates {
+		lastYearByMonth[startMonthDate] = lastYearInsights[i]
+	}
+
 	for i := range startMonthDates {
 
-		getRevenueAndSearchConsoleDataStatus := ""
-		metricsOrders, metricsRevenue, metricsVisits, avgOrderValue, avgVisitValue, getRevenueAndSearchConsoleDataStatus = generateRevenueBQLOrganic(analyticsID, startMonthDates[i], endMonthDates[i])
+		getRevenueAndSearchConsoleDataStatus := insights[i].RevenueStatus
+		metricsOrders = insights[i].Orders
+		metricsRevenue = insights[i].Revenue
+		metricsVisits = insights[i].Visits
+		avgOrderValue = insights[i].AvgOrderValue
+		avgVisitValue = insights[i].AvgVisitValue
 
-		getSearchDataStatus := ""
-		scImpressions, scClicks, scCTR, scAvgPosition, getSearchDataStatus = generateSearchConsoleBQL(startMonthDates[i], endMonthDates[i])
+		getSearchDataStatus := insights[i].SearchStatus
+		scImpressions = insights[i].SearchConsole.Impressions
+		scClicks = insights[i].SearchConsole.Clicks
+		scCTR = insights[i].SearchConsole.CTR
+		scAvgPosition = insights[i].SearchConsole.AvgPosition
 
 		// Error checking
+		// A query failed (API error or the API could not be reached)
+		if getRevenueAndSearchConsoleDataStatus == "errorQueryFailed" || getSearchDataStatus == "errorQueryFailed" {
+			return "errorQueryFailed"
+		}
 		// No engagement analytics found
 		if getRevenueAndSearchConsoleDataStatus == "errorNoEAFound" {
 			return getRevenueAndSearchConsoleDataStatus
@@ -752,7 +1143,11 @@ func getRevenueAndSearchConsoleData(analyticsID string, startMonthDates []string
 	}
 
 	// Get the revenue for the non-organic traffic for the period
-	metricsRevenueNonOrganic, metricsOrdersNonOrganic, metricsVisitsNonOrganic = generateRevenueBQLNonOrganic(analyticsID, firstStartDatePeriod, lastEndDatePeriod)
+	var nonOrganicStatus string
+	metricsRevenueNonOrganic, metricsOrdersNonOrganic, metricsVisitsNonOrganic, nonOrganicStatus = generateRevenueBQLNonOrganic(analyticsID, firstStartDatePeriod, lastEndDatePeriod)
+	if nonOrganicStatus == "errorQueryFailed" {
+		return nonOrganicStatus
+	}
 
 	// Calculate the average visits per order
 	totalVisitsPerOrder := 0
@@ -856,1427 +1251,3389 @@ func getRevenueAndSearchConsoleData(analyticsID string, startMonthDates []string
 	return "success"
 }
 
-// Get the keywords data
-func getKeywordsCloudData(startMonthDates string, endMonthDates string) string {
+// Monthly revenue & Search Console insights
+type monthlyInsights struct {
+	Orders        int
+	Revenue       int
+	Visits        int
+	AvgOrderValue int
+	AvgVisitValue float64
+	RevenueStatus string
+	SearchConsole searchConsoleMetrics
+	SearchStatus  string
+}
 
-	var getKeywordsDataStatus = ""
+// The insights for a month, with the average order & visit value
+func newMonthlyInsights(revenue revenueMetrics, revenueStatus string, searchConsole searchConsoleMetrics, searchStatus string) monthlyInsights {
 
-	// Branded keywords
-	noKeywordsFound = generateKeywordsCloudBQL(startMonthDates, endMonthDates, "true")
-	if noKeywordsFound == 0 {
-		getKeywordsDataStatus = "errorNoKWFound"
+	month := monthlyInsights{
+		Orders:        revenue.Orders,
+		Revenue:       revenue.Revenue,
+		Visits:        revenue.Visits,
+		RevenueStatus: revenueStatus,
+		SearchConsole: searchConsole,
+		SearchStatus:  searchStatus,
 	}
 
-	// Non-branded keywords
-	noKeywordsFound = generateKeywordsCloudBQL(startMonthDates, endMonthDates, "false")
-	if noKeywordsFound == 0 {
-		getKeywordsDataStatus = "errorNoKWFound"
+	// Compute the average Order value
+	// Check division by zero
+	if month.Orders != 0 {
+		month.AvgOrderValue = month.Revenue / month.Orders
 	}
 
-	return getKeywordsDataStatus
+	// Calculate avgVisitValue only if the visits is not zero
+	if month.Visits != 0 {
+		month.AvgVisitValue = float64(month.Revenue) / float64(month.Visits)
+	}
+
+	return month
 }
 
-// Execute the BQL to acquire the keywords data
-func generateKeywordsCloudBQL(startDate string, endDate string, brandedFlag string) int {
+// Execute the monthly revenue & Search Console queries concurrently. The insights are returned in the same order as the months
+// With yearOnYear the same month last year is acquired in the same query (period_1)
+func fetchMonthlyInsights(analyticsID string, startMonthDates []string, endMonthDates []string, yearOnYear bool) ([]monthlyInsights, []monthlyInsights) {
 
-	// Get the keyword data. Define the BQL
-	bqlCloudKeywords := fmt.Sprintf(`
-{
-    "collections": [
-        "search_console_by_property"
-    ],
-    "periods": [
-        [
-            %s,
-            %s
-        ]
-    ],
-    "query": {
-        "dimensions": [
-            "keyword"
-        ],
-        "metrics": [
-            "search_console_by_property.period_0.count_clicks",
-            "search_console_by_property.period_0.avg_position",
-            "search_console_by_property.period_0.ctr"
-        ],
-        "sort": [
-            {
-                "index": 0,
-                "type": "metrics",
-                "order": "desc"
-            }
-        ],
-        "filters": {
-            "and": [
-                {
-                    "field": "keyword_meta.branded",
-                    "predicate": "eq",
-                    "value": %s
-                }
-            ]
-        }
-    }
-}`, startDate, endDate, brandedFlag)
+	revenue := make([][]revenueMetrics, len(startMonthDates))
+	revenueStatus := make([]string, len(startMonthDates))
+	searchConsole := make([][]searchConsoleMetrics, len(startMonthDates))
+	searchStatus := make([]string, len(startMonthDates))
 
-	// Get the keyword data
-	responseGetKeywords := executeBQL(noKeywordsInCloud, bqlCloudKeywords)
+	// The number of queries executed at the same time is limited in executeBQL
+	var wg sync.WaitGroup
+	for i := range startMonthDates {
+		periods := [][2]string{{startMonthDates[i], endMonthDates[i]}}
+		if yearOnYear {
+			periods = append(periods, [2]string{lastYearDate(startMonthDates[i]), lastYearDate(endMonthDates[i])})
+		}
 
-	// Unmarshal JSON data into KeywordsData struct
-	var response keywordsData
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			revenue[i], revenueStatus[i] = generateRevenueBQLOrganic(analyticsID, periods)
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			searchConsole[i], searchStatus[i] = generateSearchConsoleBQL(periods)
+		}(i)
+	}
+	wg.Wait()
 
-	err := json.Unmarshal(responseGetKeywords, &response)
-	if err != nil {
-		fmt.Printf(red+"Error. generateKeywordsCloudBQL. Cannot unmarshal the JSON: %v"+reset, err)
+	// Assemble the months in order
+	insights := make([]monthlyInsights, len(startMonthDates))
+	var lastYearInsights []monthlyInsights
+	for i := range startMonthDates {
+		insights[i] = newMonthlyInsights(revenue[i][0], revenueStatus[i], searchConsole[i][0], searchStatus[i])
+		if yearOnYear {
+			lastYearStatus := revenueStatus[i]
+			if revenue[i][1].Revenue == 0 && revenue[i][1].Visits == 0 {
+				lastYearStatus = "noData"
+			}
+			lastYearInsights = append(lastYearInsights, newMonthlyInsights(revenue[i][1], lastYearStatus, searchConsole[i][1], searchStatus[i]))
+		}
 	}
 
-	noKeywordsFound := len(response.Results)
+	return insights, lastYearInsights
+}
 
-	// Load the response into the slices - branded keywords
-	if brandedFlag == "true" {
-		for _, result := range response.Results {
-			if len(result.Dimensions) >= 1 && len(result.Metrics) >= 3 {
-				kwKeywords = append(kwKeywords, result.Dimensions[0].(string))
-				kwCountClicks = append(kwCountClicks, int(*result.Metrics[0]))
-				kwMetricsAvgPosition = append(kwMetricsAvgPosition, *result.Metrics[1])
-				kwMetricsCTR = append(kwMetricsCTR, *result.Metrics[2])
-			}
+// Get the default reporting & comparison period from the command line
+func getPeriodFlags() {
+
+	flag.StringVar(&defaultPeriod.StartMonth, "startMonth", "", "First month of the reporting period (YYYY-MM). The last 12 full months are used if empty")
+	flag.StringVar(&defaultPeriod.EndMonth, "endMonth", "", "Last month of the reporting period (YYYY-MM)")
+	flag.StringVar(&defaultPeriod.Comparison, "compare", "none", "Comparison period: none, previous, lastYear or custom")
+	flag.StringVar(&defaultPeriod.CompareStartMonth, "compareStartMonth", "", "First month of the custom comparison period (YYYY-MM)")
+	flag.StringVar(&defaultPeriod.CompareEndMonth, "compareEndMonth", "", "Last month of the custom comparison period (YYYY-MM)")
+	flag.StringVar(&defaultPeriod.Granularity, "granularity", "monthly", "Granularity of the insights: monthly, weekly (ISO weeks) or daily")
+	flag.Parse()
+
+	if err := defaultPeriod.validate(); err != nil {
+		fmt.Println(red+"Error. getPeriodFlags. Invalid reporting period. The last 12 full months will be used:"+reset, err)
+		defaultPeriod = reportingPeriod{Comparison: "none"}
+	}
+
+	if description := defaultPeriod.description(); description != "" {
+		fmt.Println(yellow + "Default reporting period: " + description + reset)
+	}
+}
+
+// The reporting & comparison period chosen in the form. The command line defaults are used for empty fields
+func periodFromForm(r *http.Request) reportingPeriod {
+
+	period := defaultPeriod
+	if startMonth := r.Form.Get("startMonth"); startMonth != "" {
+		period.StartMonth = startMonth
+	}
+	if endMonth := r.Form.Get("endMonth"); endMonth != "" {
+		period.EndMonth = endMonth
+	}
+	if comparison := r.Form.Get("comparison"); comparison != "" {
+		period.Comparison = comparison
+	}
+	if compareStartMonth := r.Form.Get("compareStartMonth"); compareStartMonth != "" {
+		period.CompareStartMonth = compareStartMonth
+	}
+	if compareEndMonth := r.Form.Get("compareEndMonth"); compareEndMonth != "" {
+		period.CompareEndMonth = compareEndMonth
+	}
+	if granularity := r.Form.Get("granularity"); granularity != "" {
+		period.Granularity = granularity
+	}
+
+	return period
+}
+
+// Check the months & the comparison type
+func (p reportingPeriod) validate() error {
+
+	if (p.StartMonth == "") != (p.EndMonth == "") {
+		return fmt.Errorf("both the start and end month are required")
+	}
+	if p.StartMonth != "" {
+		startMonth, errStart := time.Parse("2006-01", p.StartMonth)
+		endMonth, errEnd := time.Parse("2006-01", p.EndMonth)
+		if errStart != nil || errEnd != nil {
+			return fmt.Errorf("the start and end month must be in the format YYYY-MM")
+		}
+		if endMonth.Before(startMonth) {
+			return fmt.Errorf("the end month is before the start month")
 		}
 	}
 
-	// Load the response into the slices - non-branded keywords
-	if brandedFlag == "false" {
-		for _, result := range response.Results {
-			if len(result.Dimensions) >= 1 && len(result.Metrics) >= 3 {
-				kwKeywordsNonBranded = append(kwKeywordsNonBranded, result.Dimensions[0].(string))
-				kwCountClicksNonBranded = append(kwCountClicksNonBranded, int(*result.Metrics[0]))
-				kwAvgPositionNonBranded = append(kwAvgPositionNonBranded, *result.Metrics[1])
-				kwCTRNonBranded = append(kwCTRNonBranded, *result.Metrics[2])
-			}
+	switch p.Comparison {
+	case "", "none", "previous", "lastYear":
+	case "custom":
+		startMonth, errStart := time.Parse("2006-01", p.CompareStartMonth)
+		endMonth, errEnd := time.Parse("2006-01", p.CompareEndMonth)
+		if errStart != nil || errEnd != nil {
+			return fmt.Errorf("the comparison start and end month must be in the format YYYY-MM")
+		}
+		if endMonth.Before(startMonth) {
+			return fmt.Errorf("the comparison end month is before the comparison start month")
 		}
+	default:
+		return fmt.Errorf("unknown comparison period %q", p.Comparison)
 	}
-	return noKeywordsFound
+
+	switch p.Granularity {
+	case "", "monthly", "weekly", "daily":
+	default:
+		return fmt.Errorf("unknown granularity %q", p.Granularity)
+	}
+
+	return nil
 }
 
-// Execute the BQL for the specified date range
-func generateRevenueBQLOrganic(analyticsID string, startDate string, endDate string) (int, int, int, int, float64, string) {
+// Is a comparison period selected?
+func (p reportingPeriod) comparing() bool {
+	return p.Comparison != "" && p.Comparison != "none"
+}
 
-	// GA4
-	conversionCollection := "conversion.dip"
-	conversionTransactionField := "transactions"
-	// Support for Adobe
-	if analyticsID == "visits.adobe" {
-		conversionCollection = "conversion"
-		conversionTransactionField = "orders"
+// Description of the chosen period. Empty for the last 12 months without comparison, monthly
+func (p reportingPeriod) description() string {
+
+	var parts []string
+	if p.Granularity == "weekly" || p.Granularity == "daily" {
+		parts = append(parts, p.Granularity)
+	}
+	if p.StartMonth != "" {
+		parts = append(parts, p.StartMonth+" to "+p.EndMonth)
+	}
+	switch p.Comparison {
+	case "previous":
+		parts = append(parts, "compared with the previous period")
+	case "lastYear":
+		parts = append(parts, "compared with the same period last year")
+	case "custom":
+		parts = append(parts, "compared with "+p.CompareStartMonth+" to "+p.CompareEndMonth)
 	}
 
-	// Get the revenue, no. Orders and visits
-	bqlRevTrans := fmt.Sprintf(`
-{
-    "collections": [
-        "%s",
-        "%s"
-    ],
-    "periods": [
-        [
-            "%s",
-            "%s"
-        ]
-    ],
-    "query": {
-        "dimensions": [],
-        "metrics": [
-            "%s.period_0.%s",
-            "%s.period_0.revenue",
-            "%s.period_0.nb"
-        ],
-        "filters": {
-            "and": [
-                {
-                    "field": "%s.period_0.medium",
-                    "predicate": "eq",
-                    "value": "organic"
-                },
-                {
-                    "field": "%s.period_0.medium",
-                    "predicate": "eq",
-                    "value": "organic"
-                }
-            ]
-        }
-    }
-}`,
-		conversionCollection,
-		analyticsID,
-		startDate,
-		endDate,
-		conversionCollection,
-		conversionTransactionField,
-		conversionCollection,
-		analyticsID,
-		conversionCollection,
-		analyticsID)
+	return strings.Join(parts, ", ")
+}
 
-	// Get the revenue and transaction data
-	revenueData := executeBQL(0, bqlRevTrans)
+// Monthly date ranges for the chosen start & end month, newest month first
+// The period is limited to the months with analytics data, up to the last full month
+func monthlyDateRanges(startMonth time.Time, endMonth time.Time, analyticsStartDate time.Time) [][2]time.Time {
 
-	// Unmarshal the JSON data into the struct
-	var response Response
-	err := json.Unmarshal(revenueData, &response)
-	if err != nil {
-		fmt.Printf(red+"Error. generateRevenueBQLOrganic. Cannot unmarshal the JSON: %v"+reset, err)
+	currentYear, currentMonth, _ := time.Now().Date()
+	lastFullMonth := time.Date(currentYear, currentMonth-1, 1, 0, 0, 0, 0, time.UTC)
+	if endMonth.After(lastFullMonth) {
+		fmt.Println(yellow + "The reporting period ends with the last full month: " + lastFullMonth.Format("January 2006") + reset)
+		endMonth = lastFullMonth
 	}
 
-	var metricsOrders = 0
-	var metricsRevenue = 0
-	var metricsVisits = 0
-	var avgOrderValue = 0
-	var avgVisitValue = 0.00
+	var dateRanges [][2]time.Time
+	for month := endMonth; !month.Before(startMonth); month = month.AddDate(0, -1, 0) {
+		startDate := month
+		endDate := month.AddDate(0, 1, -1)
+		if endDate.Before(analyticsStartDate) {
+			break
+		}
+		// The first month with analytics data may be incomplete
+		if startDate.Before(analyticsStartDate) {
+			startDate = analyticsStartDate
+		}
+		dateRanges = append(dateRanges, [2]time.Time{startDate, endDate})
+	}
 
-	// Check if any data has been returned from the API. Count the number of elements in the response.Results slice
-	responseCount := len(response.Results)
+	return dateRanges
+}
 
-	if responseCount == 0 {
-		fmt.Println(red+"Error. generateRevenueBQLOrganic. Engagement analytics with visits, revenue & transactions (orders) has not been configured for the specified project ", organization+"/"+project+reset)
-		getRevenueAndSearchConsoleDataStatus := "errorNoEAFound"
-		return 0, 0, 0, 0, 0.0, getRevenueAndSearchConsoleDataStatus
-	} else {
-		metricsOrders = int(response.Results[0].Metrics[0])
-		metricsRevenue = int(response.Results[0].Metrics[1])
-		metricsVisits = int(response.Results[0].Metrics[2])
-		// Compute the average Order value
-		// Check division by zero
-		if metricsOrders != 0 {
-			avgOrderValue = metricsRevenue / metricsOrders
+// The comparison periods, paired with the reporting periods (oldest first)
+// A custom comparison period shorter than the reporting period is paired with the oldest periods only
+func comparisonMonths(period reportingPeriod, startMonthDates []string) ([]string, []string, []string) {
+
+	var compareStarts, compareEnds, compareNames []string
+
+	var customStart, customEnd time.Time
+	if period.Comparison == "custom" {
+		customStart, _ = time.Parse("2006-01", period.CompareStartMonth)
+		customEnd, _ = time.Parse("2006-01", period.CompareEndMonth)
+		customEnd = customEnd.AddDate(0, 1, -1)
+		// Weekly comparison periods start on a Monday
+		if period.Granularity == "weekly" {
+			customStart = customStart.AddDate(0, 0, -(int(customStart.Weekday())+6)%7)
+		}
+	}
+
+	// Monthly, weekly or daily periods
+	months, days := 1, 0
+	switch period.Granularity {
+	case "weekly":
+		months, days = 0, 7
+	case "daily":
+		months, days = 0, 1
+	}
+
+	for i, startMonthDate := range startMonthDates {
+		startDate, err := time.Parse("20060102", startMonthDate)
+		if err != nil {
+			fmt.Println(red+"Error. comparisonMonths. Cannot parse the start date:"+reset, err)
+			break
+		}
+		// Full periods are compared. The first period may start with the analytics start date
+		switch period.Granularity {
+		case "weekly":
+			startDate = startDate.AddDate(0, 0, -(int(startDate.Weekday())+6)%7)
+		case "daily":
+		default:
+			startDate = time.Date(startDate.Year(), startDate.Month(), 1, 0, 0, 0, 0, time.UTC)
 		}
 
-		// Calculate avgVisitValue only if metricsVisits is not zero
-		if metricsVisits != 0 {
-			avgVisitValue = float64(metricsRevenue) / float64(metricsVisits)
+		n := len(startMonthDates)
+		var compareDate time.Time
+		switch period.Comparison {
+		case "previous":
+			compareDate = startDate.AddDate(0, -n*months, -n*days)
+		case "lastYear":
+			compareDate, _ = time.Parse("20060102", lastYearDate(startDate.Format("20060102")))
+		case "custom":
+			compareDate = customStart.AddDate(0, i*months, i*days)
+			if compareDate.After(customEnd) {
+				return compareStarts, compareEnds, compareNames
+			}
+		default:
+			return compareStarts, compareEnds, compareNames
 		}
+
+		compareStarts = append(compareStarts, compareDate.Format("20060102"))
+		compareEnds = append(compareEnds, compareDate.AddDate(0, months, days-1).Format("20060102"))
+		compareNames = append(compareNames, periodLabel(compareDate))
 	}
-	getRevenueAndSearchConsoleDataStatus := "success"
-	return metricsOrders, metricsRevenue, metricsVisits, avgOrderValue, avgVisitValue, getRevenueAndSearchConsoleDataStatus
+
+	return compareStarts, compareEnds, compareNames
 }
 
-// Execute the BQL for the specified date range
-func generateRevenueBQLNonOrganic(analyticsID string, firstStartDatePeriod string, lastEndDatePeriod string) (int, int, int) {
+// Get the revenue & Search Console insights for the comparison months
+func getComparisonData(analyticsID string) {
 
-	// GA4
-	conversionCollection := "conversion.dip"
-	// Support for Adobe
-	if analyticsID == "visits.adobe" {
-		conversionCollection = "conversion"
-	}
+	comparisonByMonth = make(map[string]monthlyInsights)
+	comparisonNameByMonth = make(map[string]string)
 
-	// Get the revenue, no. Orders and visits
-	bqlRevTransAllChannels := fmt.Sprintf(`
-{
-    "collections": [
-        "%s",
-        "%s"
-    ],
-    "periods": [
-        [
-            "%s",
-            "%s"
-        ]
-    ],
-    "query": {
-        "dimensions": [
-            "conversion.period_0.medium"
-        ],
-        "metrics": [
-            "%s.period_0.revenue",
-            "%s.period_0.orders",
-            "%s.period_0.nb"
-        ],
-        "filters": {
-            "not": 
-                {
-                    "field": "%s.period_0.medium",
-                    "predicate": "eq",
-                    "value": "organic"
-                }
-        }
-    }
-}`,
-		conversionCollection,
-		analyticsID,
-		firstStartDatePeriod,
-		lastEndDatePeriod,
-		conversionCollection,
-		conversionCollection,
-		analyticsID,
-		conversionCollection)
+	compareStarts, compareEnds, compareNames := comparisonMonths(activePeriod, startMonthDates)
 
-	// Get the revenue and visits data for all channels
-	revenueData := executeBQL(0, bqlRevTransAllChannels)
+	// The same months last year have already been acquired (period_1)
+	var insights []monthlyInsights
+	if activePeriod.Comparison == "lastYear" {
+		for _, startMonthDate := range startMonthDates {
+			insights = append(insights, lastYearByMonth[startMonthDate])
+		}
+	} else {
+		insights, _ = fetchMonthlyInsights(analyticsID, compareStarts, compareEnds, false)
+	}
 
-	// Unmarshal the JSON data into the struct
-	var response Response
-	err := json.Unmarshal(revenueData, &response)
-	if err != nil {
-		fmt.Printf(red+"Error. generateRevenueBQLOrganic. Cannot unmarshal the JSON: %v"+reset, err)
+	for i := range compareNames {
+		comparisonByMonth[startMonthDates[i]] = insights[i]
+		comparisonNameByMonth[startMonthDates[i]] = compareNames[i]
 	}
 
-	// Get the number of elements in the slice (aka the number of mediums)
-	mediumCount := len(response.Results)
+	if len(compareNames) > 0 {
+		comparisonLabel = compareNames[0] + " to " + compareNames[len(compareNames)-1]
 
-	for i := 0; i < mediumCount; i++ {
-		metricsRevenueNonOrganic += int(response.Results[i].Metrics[0])
-		metricsOrdersNonOrganic += int(response.Results[i].Metrics[1])
-		metricsVisitsNonOrganic += int(response.Results[i].Metrics[2])
+		// Non-organic traffic for the organic & non-organic contribution charts
+		nonOrganic, err := nonOrganicRevenue(analyticsID, compareStarts[0], compareEnds[len(compareEnds)-1])
+		if err != nil {
+			fmt.Printf(red+"Error. getComparisonData. The non-organic query failed: %v\n"+reset, err)
+		}
+		comparisonNonOrganic = nonOrganic
 	}
+}
 
-	// Calculate the percentages. The percentages represent the non-organic contribution
-	// Revenue
-	total := metricsRevenueOrganic + metricsRevenueNonOrganic
-	metricsRevenueNonOrganicPC = (float64(metricsRevenueNonOrganic) / float64(total)) * 100
-
-	// Orders
-	total = metricsOrdersOrganic + metricsOrdersNonOrganic
-	metricsOrdersNonOrganicPC = (float64(metricsOrdersNonOrganic) / float64(total)) * 100
+// Align the comparison insights with the reporting months (after months without revenue have been removed) & compute the totals
+func alignComparison() {
 
-	// Visits
-	total = metricsVisitsOrganic + metricsVisitsNonOrganic
-	metricsVisitsNonOrganicPC = (float64(metricsVisitsNonOrganic) / float64(total)) * 100
+	comparisonSeries = nil
+	comparisonMonthNames = nil
 
-	// Populate the category (X-Axis) slice used for the non-organic bar chart
-	nonOrganicPerformanceCategory = append(nonOrganicPerformanceCategory, "Revenue")
-	nonOrganicPerformanceCategory = append(nonOrganicPerformanceCategory, "Orders")
-	nonOrganicPerformanceCategory = append(nonOrganicPerformanceCategory, "Visits")
-	// Populate the values (Y-axis) slice used for the non-organic bar chart
-	nonOrganicPerformanceValues = append(nonOrganicPerformanceValues, int(metricsRevenueNonOrganicPC))
-	nonOrganicPerformanceValues = append(nonOrganicPerformanceValues, int(metricsOrdersNonOrganicPC))
-	nonOrganicPerformanceValues = append(nonOrganicPerformanceValues, int(metricsVisitsNonOrganicPC))
+	var paired []monthlyInsights
+	for _, startMonthDate := range startMonthDates {
+		insights, ok := comparisonByMonth[startMonthDate]
+		comparisonSeries = append(comparisonSeries, insights)
+		comparisonMonthNames = append(comparisonMonthNames, comparisonNameByMonth[startMonthDate])
+		// Months without analytics data are not included in the totals
+		if ok && insights.RevenueStatus == "success" {
+			paired = append(paired, insights)
+		}
+	}
 
-	// RPV
-	totalAverageVisitValueNonOrganic = float64(metricsRevenueNonOrganic) / float64(metricsVisitsNonOrganic)
-	// AOV
-	totalAverageOrderValueNonOrganic = float64(metricsRevenueNonOrganic) / float64(metricsOrdersNonOrganic)
-	// Visits per order
-	totalAverageVisitsPerOrderNonOrganic = float64(metricsVisitsNonOrganic) / float64(metricsOrdersNonOrganic)
-
-	// Visits per order
-	return metricsRevenueNonOrganic, metricsOrdersNonOrganic, metricsVisitsNonOrganic
-}
-
-func generateSearchConsoleBQL(startDate string, endDate string) (int, int, float64, float64, string) {
-
-	// Get non brand insights
-	bqlSearchConsole := fmt.Sprintf(`
-{
-    "collections": [
-        "search_console_by_property"
-    ],
-    "periods": [
-        [
-            "%s",
-            "%s"
-        ]
-    ],
-    "query": {
-        "dimensions": [],
-        "metrics": [
-            "search_console_by_property.period_0.not_branded.count_impressions",
-            "search_console_by_property.period_0.not_branded.count_clicks",
-            "search_console_by_property.period_0.not_branded.ctr",
-            "search_console_by_property.period_0.not_branded.avg_position"
-        ]
-    }
-}`,
-		startDate,
-		endDate)
+	comparisonTotals = totalsFromInsights(paired)
+}
 
-	// get the revenue and transaction
-	responseRevenueData := executeBQL(0, bqlSearchConsole)
+// The totals for the same months last year (after months without revenue have been removed)
+func alignLastYear() {
 
-	// Unmarshal the JSON data into the struct
-	var response searchConsoleData
-	err := json.Unmarshal(responseRevenueData, &response)
-	if err != nil {
-		fmt.Printf(red+"Error. generateSearchConsoleBQL. Cannot unmarshal the JSON: %v"+reset, err)
+	var lastYear []monthlyInsights
+	for _, startMonthDate := range startMonthDates {
+		if insights, ok := lastYearByMonth[startMonthDate]; ok && insights.RevenueStatus == "success" {
+			lastYear = append(lastYear, insights)
+		}
 	}
 
-	// Check if any data has been returned from the API. Count the number of elements in the response.Results slice
-	responseCount := len(response.Results)
+	lastYearTotals = totalsFromInsights(lastYear)
+}
 
-	if responseCount == 0 {
-		fmt.Println(red+"Error. generateSearchConsoleBQL. Analytics integration has not been configured for the specified project ", organization+"/"+project+reset)
-		fmt.Println(startDate)
-		fmt.Println(endDate)
+// The name of the period for the granularity, singular & plural
+func granularityUnit() (string, string) {
 
-		getSearchDataStatus := "errorNoGAFound"
-		return 0, 0, 0, 0, getSearchDataStatus
-	} else {
-		scImpressions = int(response.Results[0].Metrics[0])
-		scClicks = int(response.Results[0].Metrics[1])
-		scCTR = response.Results[0].Metrics[2]
-		scAvgPosition = response.Results[0].Metrics[3]
+	switch activePeriod.Granularity {
+	case "weekly":
+		return "week", "weeks"
+	case "daily":
+		return "day", "days"
 	}
-	getSearchDataStatus := "success"
+	return "month", "months"
+}
+
+// The compound growth rate matching the granularity (CMGR, CWGR or CDGR) & its full name
+func growthRateName() (string, string) {
 
-	return scImpressions, scClicks, scCTR, scAvgPosition, getSearchDataStatus
+	switch activePeriod.Granularity {
+	case "weekly":
+		return "CWGR", "Compound Weekly Growth Rate"
+	case "daily":
+		return "CDGR", "Compound Daily Growth Rate"
+	}
+	return "CMGR", "Compound Monthly Growth Rate"
 }
 
-// Header for the broadsheet
-func headerNotes() {
+// The label of a period in the charts & tables, e.g. January 2024, 2024-W05 or Mon 05 Feb 2024
+func periodLabel(startDate time.Time) string {
 
-	currentTime := time.Now()
-	currentDate := currentTime.Format("02 January 2006")
-	currentTimeFormatted := currentTime.Format("15:04")
+	switch activePeriod.Granularity {
+	case "weekly":
+		year, week := startDate.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	case "daily":
+		return startDate.Format("Mon 02 Jan 2006")
+	}
+	return startDate.Format("January 2006")
+}
 
-	htmlDataIssue := ""
-	// If any issues have been found in the data (i.e. mlissing data) generate the HTML for inclusion in the header
-	if revenueDataIssue || visitsDataIssue || ordersDataIssue {
-		htmlDataIssue = generateDataIssueHTML(revenueDataIssue, visitsDataIssue, ordersDataIssue)
+// Weekly (ISO weeks, Monday to Sunday) or daily date ranges, newest first
+// The last 13 full weeks or 30 full days are used unless a start & end month have been chosen
+func subMonthlyDateRanges(analyticsStartDate time.Time) [][2]time.Time {
+
+	currentYear, currentMonth, currentDay := time.Now().Date()
+	today := time.Date(currentYear, currentMonth, currentDay, 0, 0, 0, 0, time.UTC)
+
+	// The last full period
+	lastEndDate := today.AddDate(0, 0, -1)
+	bucketDays := 1
+	maxPeriods := maxDailyPeriods
+	defaultPeriods := 30
+	if activePeriod.Granularity == "weekly" {
+		// Sunday of the previous ISO week
+		daysSinceMonday := (int(today.Weekday()) + 6) % 7
+		lastEndDate = today.AddDate(0, 0, -daysSinceMonday-1)
+		bucketDays = 7
+		maxPeriods = maxWeeklyPeriods
+		defaultPeriods = 13
 	}
 
-	htmlContent := `
-<!DOCTYPE html>
-<html>
-<head>
-    <style>
-        body {
-            font-family: Arial, sans-serif;
-            margin: 0;
-            height: 100pv;
-            overflow: hidden;
-        }
-        .content {
+	firstStartDate := lastEndDate.AddDate(0, 0, -defaultPeriods*bucketDays+1)
+	if activePeriod.StartMonth != "" {
+		startMonth, _ := time.Parse("2006-01", activePeriod.StartMonth)
+		endMonth, _ := time.Parse("2006-01", activePeriod.EndMonth)
+		firstStartDate = startMonth
+		if endOfMonth := endMonth.AddDate(0, 1, -1); endOfMonth.Before(lastEndDate) {
+			lastEndDate = endOfMonth
+		}
+		// Full ISO weeks, from the Monday of the first week to the last Sunday of the period
+		if activePeriod.Granularity == "weekly" {
+			firstStartDate = firstStartDate.AddDate(0, 0, -(int(firstStartDate.Weekday())+6)%7)
+			lastEndDate = lastEndDate.AddDate(0, 0, -int(lastEndDate.Weekday()))
+		}
+	}
+
+	var dateRanges [][2]time.Time
+	for endDate := lastEndDate; !endDate.Before(firstStartDate) && !endDate.Before(analyticsStartDate); endDate = endDate.AddDate(0, 0, -bucketDays) {
+		startDate := endDate.AddDate(0, 0, -bucketDays+1)
+		// The first period may be incomplete
+		if startDate.Before(firstStartDate) {
+			startDate = firstStartDate
+		}
+		if startDate.Before(analyticsStartDate) {
+			startDate = analyticsStartDate
+		}
+		dateRanges = append(dateRanges, [2]time.Time{startDate, endDate})
+		if len(dateRanges) == maxPeriods {
+			fmt.Printf(yellow+"The reporting period is limited to the last %d %s periods\n"+reset, maxPeriods, activePeriod.Granularity)
+			break
+		}
+	}
+
+	return dateRanges
+}
+
+// The same period last year (YYYYMMDD). 52 weeks earlier for weekly & daily periods to keep the same day of the week
+func lastYearDate(date string) string {
+
+	if activePeriod.Granularity == "weekly" || activePeriod.Granularity == "daily" {
+		day, err := time.Parse("20060102", date)
+		if err != nil {
+			fmt.Println(red+"Error. lastYearDate. Cannot parse the date:"+reset, err)
+			return date
+		}
+		return day.AddDate(0, 0, -364).Format("20060102")
+	}
+
+	return sameDayLastYear(date)
+}
+
+// The same day one year earlier (YYYYMMDD). 29 February becomes 28 February
+func sameDayLastYear(date string) string {
+
+	day, err := time.Parse("20060102", date)
+	if err != nil {
+		fmt.Println(red+"Error. sameDayLastYear. Cannot parse the date:"+reset, err)
+		return date
+	}
+
+	lastYear := time.Date(day.Year()-1, day.Month(), 1, 0, 0, 0, 0, time.UTC)
+	lastDayOfMonth := lastYear.AddDate(0, 1, -1).Day()
+	if day.Day() > lastDayOfMonth {
+		return lastYear.AddDate(0, 0, lastDayOfMonth-1).Format("20060102")
+	}
+
+	return lastYear.AddDate(0, 0, day.Day()-1).Format("20060102")
+}
+
+// The change compared with last year, e.g. +5.2%. A dash when there is no data for last year
+func yoyChange(current float64, lastYear float64) string {
+
+	if lastYear == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%+.1f%%", (current-lastYear)/lastYear*100)
+}
+
+// The year-on-year change displayed below a KPI, when the comparison period is not already last year
+func yoyHTML(current float64, lastYear float64) string {
+
+	if activePeriod.Comparison == "lastYear" {
+		return ""
+	}
+	return `<div style="font-size: 20px; color: grey;">YoY ` + yoyChange(current, lastYear) + `</div>`
+}
+
+// The totals & averages for a period, computed in the same way as the reporting period totals
+func totalsFromInsights(insights []monthlyInsights) periodTotals {
+
+	var totals periodTotals
+	if len(insights) == 0 {
+		return totals
+	}
+
+	var totalOrderValue, totalVisitsPerOrder int
+	var totalVisitValue, totalCTR, totalAvgPosition float64
+	for _, month := range insights {
+		totals.Revenue += month.Revenue
+		totals.Visits += month.Visits
+		totals.Orders += month.Orders
+		totals.Impressions += month.SearchConsole.Impressions
+		totals.Clicks += month.SearchConsole.Clicks
+		totalOrderValue += month.AvgOrderValue
+		totalVisitValue += math.Round(month.AvgVisitValue*100) / 100
+		if month.Orders != 0 {
+			totalVisitsPerOrder += month.Visits / month.Orders
+		}
+		totalCTR += month.SearchConsole.CTR
+		totalAvgPosition += month.SearchConsole.AvgPosition
+	}
+
+	months := len(insights)
+	totals.AvgOrderValue = totalOrderValue / months
+	totals.AvgVisitValue = totalVisitValue / float64(months)
+	totals.VisitsPerOrder = totalVisitsPerOrder / months
+	totals.CTR = totalCTR / float64(months)
+	totals.AvgPosition = totalAvgPosition / float64(months)
+
+	return totals
+}
+
+// The comparison value & the change, displayed below a KPI. Empty when no comparison period is selected
+// For KPIs where lower is better (e.g. the average position) a decrease is shown in green
+func comparisonHTML(current float64, comparison float64, formattedComparison string, higherIsBetter bool) string {
+
+	if !activePeriod.comparing() {
+		return ""
+	}
+
+	change := "-"
+	colour := "grey"
+	if comparison != 0 {
+		delta := (current - comparison) / comparison * 100
+		change = fmt.Sprintf("%+.1f%%", delta)
+		if (delta > 0) == higherIsBetter && delta != 0 {
+			colour = "green"
+		} else if delta != 0 {
+			colour = "red"
+		}
+	}
+
+	return `<div style="font-size: 20px; color: grey;">vs ` + formattedComparison + ` <span style="color: ` + colour + `;">` + change + `</span></div>`
+}
+
+// The suffix of the comparison series names in the charts
+func comparisonSeriesSuffix() string {
+
+	switch activePeriod.Comparison {
+	case "lastYear":
+		return " - last year"
+	case "previous":
+		return " - previous period"
+	}
+	return " - comparison"
+}
+
+// The organic & non-organic contribution (%) of revenue, orders & visits in the comparison period
+// nil when there is no comparison or the comparison period has no data
+func comparisonContribution() ([]int, []int) {
+
+	if !activePeriod.comparing() {
+		return nil, nil
+	}
+
+	organic := []int{comparisonTotals.Revenue, comparisonTotals.Orders, comparisonTotals.Visits}
+	nonOrganic := []int{comparisonNonOrganic.Revenue, comparisonNonOrganic.Orders, comparisonNonOrganic.Visits}
+
+	organicContribution := make([]int, len(organic))
+	nonOrganicContribution := make([]int, len(organic))
+	for i := range organic {
+		total := organic[i] + nonOrganic[i]
+		if total == 0 {
+			return nil, nil
+		}
+		organicContribution[i] = int(float64(organic[i]) / float64(total) * 100)
+		nonOrganicContribution[i] = int(float64(nonOrganic[i]) / float64(total) * 100)
+	}
+
+	return organicContribution, nonOrganicContribution
+}
+
+// The comparison values & changes shown in a chart subtitle, e.g. "Compared with January 2023 to December 2023: revenue 1,200 (+5.2%)"
+// Empty when no comparison period is selected
+func comparisonSubtitle(kpis ...string) string {
+
+	if !activePeriod.comparing() || comparisonLabel == "" {
+		return ""
+	}
+
+	return "\nCompared with " + comparisonLabel + ": " + strings.Join(kpis, ", ")
+}
+
+// A KPI of the comparison subtitle. The comparison value & the change
+func comparisonKPI(name string, current float64, comparison float64, formattedComparison string) string {
+	return name + " " + formattedComparison + " (" + yoyChange(current, comparison) + ")"
+}
+
+// Comparison series for the charts, aligned with the reporting months
+func comparisonBarItems(value func(monthlyInsights) float64) []opts.BarData {
+
+	items := make([]opts.BarData, len(comparisonSeries))
+	for i, month := range comparisonSeries {
+		items[i] = opts.BarData{Value: value(month), Name: comparisonMonthNames[i]}
+	}
+	return items
+}
+
+// Last year series for the charts, aligned with the reporting months. Months without data last year are left empty
+func lastYearBarItems(value func(monthlyInsights) float64) []opts.BarData {
+
+	items := make([]opts.BarData, len(startMonthDates))
+	for i, startMonthDate := range startMonthDates {
+		lastYearStartDate, _ := time.Parse("20060102", lastYearDate(startMonthDate))
+		items[i] = opts.BarData{Value: "-", Name: periodLabel(lastYearStartDate)}
+		if month, ok := lastYearByMonth[startMonthDate]; ok && month.RevenueStatus == "success" {
+			items[i].Value = value(month)
+		}
+	}
+	return items
+}
+
+// Is a comparison series added to the charts? Last year is always shown, it is not repeated as the comparison period
+func comparisonChartSeries() bool {
+	return activePeriod.comparing() && activePeriod.Comparison != "lastYear"
+}
+
+func comparisonLineItems(value func(monthlyInsights) float64) []opts.LineData {
+
+	items := make([]opts.LineData, len(comparisonSeries))
+	for i, month := range comparisonSeries {
+		items[i] = opts.LineData{Value: value(month), Name: comparisonMonthNames[i]}
+	}
+	return items
+}
+
+// Get the keywords data
+func getKeywordsCloudData(startMonthDates string, endMonthDates string) string {
+
+	var getKeywordsDataStatus = ""
+
+	// Branded keywords
+	noKeywordsFound = generateKeywordsCloudBQL(startMonthDates, endMonthDates, "true")
+	if noKeywordsFound == 0 {
+		getKeywordsDataStatus = "errorNoKWFound"
+	}
+
+	// Non-branded keywords
+	noKeywordsFound = generateKeywordsCloudBQL(startMonthDates, endMonthDates, "false")
+	if noKeywordsFound == 0 {
+		getKeywordsDataStatus = "errorNoKWFound"
+	}
+
+	return getKeywordsDataStatus
+}
+
+// Execute the BQL to acquire the keywords data
+func generateKeywordsCloudBQL(startDate string, endDate string, brandedFlag string) int {
+
+	// Get the keyword data. Define the BQL
+	collection := "search_console_by_property"
+	bqlCloudKeywords := newBQLQuery(startDate, endDate, collection).
+		dimensions("keyword").
+		metrics(
+			bqlField(collection, 0, "count_clicks"),
+			bqlField(collection, 0, "avg_position"),
+			bqlField(collection, 0, "ctr")).
+		sortBy("metrics", 0, "desc").
+		filter(bqlAnd(bqlEq("keyword_meta.branded", brandedFlag == "true")))
+
+	// Get the keyword data
+	rows, err := runBQL(noKeywordsInCloud, bqlCloudKeywords)
+	if err != nil {
+		fmt.Printf(red+"Error. generateKeywordsCloudBQL. The query failed: %v\n"+reset, err)
+	}
+
+	noKeywordsFound := len(rows)
+
+	for _, row := range rows {
+		keyword, ok := row.Dimensions["keyword"].(string)
+		if !ok {
+			continue
+		}
+		kw := keywordMetrics{
+			Keyword:     keyword,
+			Clicks:      int(row.Metrics[bqlField(collection, 0, "count_clicks")]),
+			AvgPosition: row.Metrics[bqlField(collection, 0, "avg_position")],
+			CTR:         row.Metrics[bqlField(collection, 0, "ctr")],
+		}
+
+		// Load the response into the slices - branded keywords
+		if brandedFlag == "true" {
+			kwKeywords = append(kwKeywords, kw.Keyword)
+			kwCountClicks = append(kwCountClicks, kw.Clicks)
+			kwMetricsAvgPosition = append(kwMetricsAvgPosition, kw.AvgPosition)
+			kwMetricsCTR = append(kwMetricsCTR, kw.CTR)
+		}
+
+		// Load the response into the slices - non-branded keywords
+		if brandedFlag == "false" {
+			kwKeywordsNonBranded = append(kwKeywordsNonBranded, kw.Keyword)
+			kwCountClicksNonBranded = append(kwCountClicksNonBranded, kw.Clicks)
+			kwAvgPositionNonBranded = append(kwAvgPositionNonBranded, kw.AvgPosition)
+			kwCTRNonBranded = append(kwCTRNonBranded, kw.CTR)
+		}
+	}
+	return noKeywordsFound
+}
+
+// Execute the BQL for the specified date ranges. The first date range is period_0, the next period_1 etc.
+func generateRevenueBQLOrganic(analyticsID string, periods [][2]string) ([]revenueMetrics, string) {
+
+	conversionCollection, conversionTransactionField := conversionFields(analyticsID)
+
+	// Get the revenue, no. Orders and visits for each period
+	bqlRevTrans := newBQLQuery(periods[0][0], periods[0][1], conversionCollection, analyticsID)
+	var organicFilters []bqlFilter
+	for i, period := range periods {
+		if i > 0 {
+			bqlRevTrans.period(period[0], period[1])
+		}
+		bqlRevTrans.metrics(
+			bqlField(conversionCollection, i, conversionTransactionField),
+			bqlField(conversionCollection, i, "revenue"),
+			bqlField(analyticsID, i, "nb"))
+		organicFilters = append(organicFilters,
+			bqlEq(bqlField(conversionCollection, i, "medium"), "organic"),
+			bqlEq(bqlField(analyticsID, i, "medium"), "organic"))
+	}
+	bqlRevTrans.filter(bqlAnd(organicFilters...))
+
+	// Get the revenue and transaction data
+	rows, err := runBQL(0, bqlRevTrans)
+	if err != nil {
+		fmt.Printf(red+"Error. generateRevenueBQLOrganic. The query failed: %v\n"+reset, err)
+		return make([]revenueMetrics, len(periods)), "errorQueryFailed"
+	}
+
+	// The organic filters of every period must match. When a later period (e.g. last year) has no data nothing is returned,
+	// the first period is then acquired on its own & the other periods are left empty
+	if len(rows) == 0 && len(periods) > 1 {
+		revenue, status := generateRevenueBQLOrganic(analyticsID, periods[:1])
+		return append(revenue, make([]revenueMetrics, len(periods)-1)...), status
+	}
+
+	// Check if any data has been returned from the API
+	if len(rows) == 0 {
+		fmt.Println(red+"Error. generateRevenueBQLOrganic. Engagement analytics with visits, revenue & transactions (orders) has not been configured for the specified project ", organization+"/"+project+reset)
+		getRevenueAndSearchConsoleDataStatus := "errorNoEAFound"
+		return make([]revenueMetrics, len(periods)), getRevenueAndSearchConsoleDataStatus
+	}
+
+	revenue := make([]revenueMetrics, len(periods))
+	for i := range periods {
+		revenue[i] = revenueMetrics{
+			Orders:  int(rows[0].Metrics[bqlField(conversionCollection, i, conversionTransactionField)]),
+			Revenue: int(rows[0].Metrics[bqlField(conversionCollection, i, "revenue")]),
+			Visits:  int(rows[0].Metrics[bqlField(analyticsID, i, "nb")]),
+		}
+	}
+
+	getRevenueAndSearchConsoleDataStatus := "success"
+	return revenue, getRevenueAndSearchConsoleDataStatus
+}
+
+// Execute the BQL for the specified date range
+func generateRevenueBQLNonOrganic(analyticsID string, firstStartDatePeriod string, lastEndDatePeriod string) (int, int, int, string) {
+
+	nonOrganic, err := nonOrganicRevenue(analyticsID, firstStartDatePeriod, lastEndDatePeriod)
+	if err != nil {
+		fmt.Printf(red+"Error. generateRevenueBQLNonOrganic. The query failed: %v\n"+reset, err)
+		return 0, 0, 0, "errorQueryFailed"
+	}
+	metricsRevenueNonOrganic = nonOrganic.Revenue
+	metricsOrdersNonOrganic = nonOrganic.Orders
+	metricsVisitsNonOrganic = nonOrganic.Visits
+
+	// Calculate the percentages. The percentages represent the non-organic contribution
+	// Revenue
+	if total := metricsRevenueOrganic + metricsRevenueNonOrganic; total > 0 {
+		metricsRevenueNonOrganicPC = (float64(metricsRevenueNonOrganic) / float64(total)) * 100
+	}
+
+	// Orders
+	if total := metricsOrdersOrganic + metricsOrdersNonOrganic; total > 0 {
+		metricsOrdersNonOrganicPC = (float64(metricsOrdersNonOrganic) / float64(total)) * 100
+	}
+
+	// Visits
+	if total := metricsVisitsOrganic + metricsVisitsNonOrganic; total > 0 {
+		metricsVisitsNonOrganicPC = (float64(metricsVisitsNonOrganic) / float64(total)) * 100
+	}
+
+	// Populate the category (X-Axis) slice used for the non-organic bar chart
+	nonOrganicPerformanceCategory = append(nonOrganicPerformanceCategory, "Revenue")
+	nonOrganicPerformanceCategory = append(nonOrganicPerformanceCategory, "Orders")
+	nonOrganicPerformanceCategory = append(nonOrganicPerformanceCategory, "Visits")
+	// Populate the values (Y-axis) slice used for the non-organic bar chart
+	nonOrganicPerformanceValues = append(nonOrganicPerformanceValues, int(metricsRevenueNonOrganicPC))
+	nonOrganicPerformanceValues = append(nonOrganicPerformanceValues, int(metricsOrdersNonOrganicPC))
+	nonOrganicPerformanceValues = append(nonOrganicPerformanceValues, int(metricsVisitsNonOrganicPC))
+
+	// RPV
+	if metricsVisitsNonOrganic > 0 {
+		totalAverageVisitValueNonOrganic = float64(metricsRevenueNonOrganic) / float64(metricsVisitsNonOrganic)
+	}
+	// AOV & visits per order
+	if metricsOrdersNonOrganic > 0 {
+		totalAverageOrderValueNonOrganic = float64(metricsRevenueNonOrganic) / float64(metricsOrdersNonOrganic)
+		totalAverageVisitsPerOrderNonOrganic = float64(metricsVisitsNonOrganic) / float64(metricsOrdersNonOrganic)
+	}
+
+	return metricsRevenueNonOrganic, metricsOrdersNonOrganic, metricsVisitsNonOrganic, "success"
+}
+
+// The non-organic revenue, orders & visits for a date range. The mediums other than organic are summed
+func nonOrganicRevenue(analyticsID string, startDate string, endDate string) (revenueMetrics, error) {
+
+	conversionCollection, conversionTransactionField := conversionFields(analyticsID)
+
+	// Get the revenue, no. Orders and visits
+	revenueField := bqlField(conversionCollection, 0, "revenue")
+	ordersField := bqlField(conversionCollection, 0, conversionTransactionField)
+	visitsField := bqlField(analyticsID, 0, "nb")
+	bqlRevTransAllChannels := newBQLQuery(startDate, endDate, conversionCollection, analyticsID).
+		dimensions(bqlField(conversionCollection, 0, "medium")).
+		metrics(revenueField, ordersField, visitsField).
+		filter(bqlNot(bqlEq(bqlField(conversionCollection, 0, "medium"), "organic")))
+
+	// Get the revenue and visits data for all channels
+	rows, err := runBQL(0, bqlRevTransAllChannels)
+	if err != nil {
+		return revenueMetrics{}, err
+	}
+
+	// One row per medium
+	var nonOrganic revenueMetrics
+	for _, row := range rows {
+		nonOrganic.Orders += int(row.Metrics[ordersField])
+		nonOrganic.Revenue += int(row.Metrics[revenueField])
+		nonOrganic.Visits += int(row.Metrics[visitsField])
+	}
+
+	return nonOrganic, nil
+}
+
+// Execute the BQL for the specified date ranges. The first date range is period_0, the next period_1 etc.
+func generateSearchConsoleBQL(periods [][2]string) ([]searchConsoleMetrics, string) {
+
+	// Get non brand insights for each period
+	collection := "search_console_by_property"
+	bqlSearchConsole := newBQLQuery(periods[0][0], periods[0][1], collection)
+	for i, period := range periods {
+		if i > 0 {
+			bqlSearchConsole.period(period[0], period[1])
+		}
+		bqlSearchConsole.metrics(
+			bqlField(collection, i, "not_branded.count_impressions"),
+			bqlField(collection, i, "not_branded.count_clicks"),
+			bqlField(collection, i, "not_branded.ctr"),
+			bqlField(collection, i, "not_branded.avg_position"))
+	}
+
+	// get the revenue and transaction
+	rows, err := runBQL(0, bqlSearchConsole)
+	if err != nil {
+		fmt.Printf(red+"Error. generateSearchConsoleBQL. The query failed: %v\n"+reset, err)
+		return make([]searchConsoleMetrics, len(periods)), "errorQueryFailed"
+	}
+
+	// Check if any data has been returned from the API
+	if len(rows) == 0 {
+		fmt.Println(red+"Error. generateSearchConsoleBQL. Analytics integration has not been configured for the specified project ", organization+"/"+project+reset)
+		fmt.Println(periods[0][0])
+		fmt.Println(periods[0][1])
+
+		getSearchDataStatus := "errorNoGAFound"
+		return make([]searchConsoleMetrics, len(periods)), getSearchDataStatus
+	}
+
+	searchConsole := make([]searchConsoleMetrics, len(periods))
+	for i := range periods {
+		searchConsole[i] = searchConsoleMetrics{
+			Impressions: int(rows[0].Metrics[bqlField(collection, i, "not_branded.count_impressions")]),
+			Clicks:      int(rows[0].Metrics[bqlField(collection, i, "not_branded.count_clicks")]),
+			CTR:         rows[0].Metrics[bqlField(collection, i, "not_branded.ctr")],
+			AvgPosition: rows[0].Metrics[bqlField(collection, i, "not_branded.avg_position")],
+		}
+	}
+	getSearchDataStatus := "success"
+
+	return searchConsole, getSearchDataStatus
+}
+
+// The conversion collection & the transaction field. GA4 by default, Adobe if integrated
+func conversionFields(analyticsID string) (string, string) {
+
+	// Support for Adobe
+	if analyticsID == "visits.adobe" {
+		return "conversion", "orders"
+	}
+	return "conversion.dip", "transactions"
+}
+
+// Create a BQL query for a date range (period_0) on the specified collections
+func newBQLQuery(startDate string, endDate string, collections ...string) *bqlQuery {
+
+	return &bqlQuery{
+		Collections: collections,
+		Periods:     [][]string{{startDate, endDate}},
+		Query: bqlBody{
+			Dimensions: []string{},
+			Metrics:    []string{},
+		},
+	}
+}
+
+// Add a comparison period (period_1, period_2 ...) to the query
+func (q *bqlQuery) period(startDate string, endDate string) *bqlQuery {
+	q.Periods = append(q.Periods, []string{startDate, endDate})
+	return q
+}
+
+// Add dimensions to the query
+func (q *bqlQuery) dimensions(dimensions ...string) *bqlQuery {
+	q.Query.Dimensions = append(q.Query.Dimensions, dimensions...)
+	return q
+}
+
+// Add metrics to the query
+func (q *bqlQuery) metrics(metrics ...string) *bqlQuery {
+	q.Query.Metrics = append(q.Query.Metrics, metrics...)
+	return q
+}
+
+// Set the query filter. Combine filters with bqlAnd, bqlOr & bqlNot
+func (q *bqlQuery) filter(filter bqlFilter) *bqlQuery {
+	q.Query.Filters = &filter
+	return q
+}
+
+// Sort on a dimension or metric ("dimensions" or "metrics") identified by its index
+func (q *bqlQuery) sortBy(sortType string, index int, order string) *bqlQuery {
+	q.Query.Sort = append(q.Query.Sort, bqlSort{Index: index, Type: sortType, Order: order})
+	return q
+}
+
+// The name of a field in a period, e.g. conversion.dip.period_0.revenue
+func bqlField(collection string, period int, field string) string {
+	return collection + ".period_" + strconv.Itoa(period) + "." + field
+}
+
+// Filter on a field equal to a value
+func bqlEq(field string, value interface{}) bqlFilter {
+	return bqlFilter{Field: field, Predicate: "eq", Value: value}
+}
+
+// All filters must match
+func bqlAnd(filters ...bqlFilter) bqlFilter {
+	return bqlFilter{And: filters}
+}
+
+// At least one of the filters must match
+func bqlOr(filters ...bqlFilter) bqlFilter {
+	return bqlFilter{Or: filters}
+}
+
+// The filter must not match
+func bqlNot(filter bqlFilter) bqlFilter {
+	return bqlFilter{Not: &filter}
+}
+
+// A BQL filter is either a predicate on a field or a combination (and, or, not) of filters
+func (f bqlFilter) MarshalJSON() ([]byte, error) {
+
+	switch {
+	case f.And != nil:
+		return json.Marshal(map[string][]bqlFilter{"and": f.And})
+	case f.Or != nil:
+		return json.Marshal(map[string][]bqlFilter{"or": f.Or})
+	case f.Not != nil:
+		return json.Marshal(map[string]*bqlFilter{"not": f.Not})
+	}
+
+	return json.Marshal(struct {
+		Field     string      `json:"field"`
+		Predicate string      `json:"predicate"`
+		Value     interface{} `json:"value"`
+	}{f.Field, f.Predicate, f.Value})
+}
+
+// Map the positional dimensions & metrics of each result to the names used in the query
+func (q *bqlQuery) decode(responseData []byte) ([]bqlRow, error) {
+
+	var response bqlResponse
+	if err := json.Unmarshal(responseData, &response); err != nil {
+		return nil, err
+	}
+
+	rows := make([]bqlRow, 0, len(response.Results))
+	for _, result := range response.Results {
+		row := bqlRow{
+			Dimensions: make(map[string]interface{}),
+			Metrics:    make(map[string]float64),
+		}
+		for i, dimension := range q.Query.Dimensions {
+			if i < len(result.Dimensions) {
+				row.Dimensions[dimension] = result.Dimensions[i]
+			}
+		}
+		// Metrics with no value (null) are not included
+		for i, metric := range q.Query.Metrics {
+			if i < len(result.Metrics) && result.Metrics[i] != nil {
+				row.Metrics[metric] = *result.Metrics[i]
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// Execute the BQL & decode the results
+func runBQL(returnSize int, query *bqlQuery) ([]bqlRow, error) {
+
+	responseData, err := executeBQL(returnSize, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return query.decode(responseData)
+}
+
+// The reporting & comparison period shown in the header
+func periodHeaderHTML() string {
+
+	if len(startMonthNames) == 0 {
+		return ""
+	}
+
+	htmlPeriod := `<br><span class="header-font">Reporting period: <strong>` + startMonthNames[0] + ` to ` + startMonthNames[len(startMonthNames)-1] + `</strong></span>`
+	if activePeriod.comparing() && comparisonLabel != "" {
+		htmlPeriod += `<br><span class="header-font">Compared with: <strong>` + comparisonLabel + `</strong></span>`
+	}
+
+	return htmlPeriod
+}
+
+// Header for the broadsheet
+func headerNotes() {
+
+	currentTime := time.Now()
+	currentDate := currentTime.Format("02 January 2006")
+	currentTimeFormatted := currentTime.Format("15:04")
+
+	_, unitPlural := granularityUnit()
+
+	htmlDataIssue := ""
+	// If any issues have been found in the data (i.e. mlissing data) generate the HTML for inclusion in the header
+	if revenueDataIssue || visitsDataIssue || ordersDataIssue {
+		htmlDataIssue = generateDataIssueHTML(revenueDataIssue, visitsDataIssue, ordersDataIssue)
+	}
+
+	htmlContent := `
+<!DOCTYPE html>
+<html>
+<head>
+    <style>
+        body {
+            font-family: Arial, sans-serif;
+            margin: 0;
+            height: 100pv;
+            overflow: hidden;
+        }
+        .content {
             color: gray;
             font-size: 18px;
         }
-		.header-font {
-            font-size: 18px;  
+		.header-font {
+            font-size: 18px;  
+        }
+		.right-justify {
+            text-align: right;
+            display: block; 
+        }
+		.deepskyblue {
+            color: DeepSkyBlue;
+        }
+        .darkgrey {
+            color: #00796b;
+        }
+    </style>
+</head>
+<body>
+    <div class="content">
+	<span class="header-font right-justify">
+        <span class="deepskyblue">Version:</span>
+        <span class="darkgrey">` + fmt.Sprintf("%s", version) + `</span>
+    </span> 
+	<span class="header-font right-justify">
+        <span class="deepskyblue">Session:</span>
+        <span class="darkgrey">` + fmt.Sprintf("%s", sessionID) + `</span>
+    </span>
+	<span class="header-font">The following insights are based on the previous ` + fmt.Sprintf("%d", noOfMonths) + ` ` + unitPlural + `.</span>
+	` + periodHeaderHTML() + `
+		<span class="header-font">Access the Botify project <a href="` + projectURL + `" target="_blank">here</a></span> (` + organization + `)
+        <br>
+        <br>
+        <span class="header-font">Click the chart title to view the chart in a new window.</span>
+        <br>
+		<br>
+			<span class="header-font">This broadsheet for <strong style="color: DeepSkyBlue;">` + organization + "/" + project + `</strong> was generated on ` + currentDate + ` at ` + currentTimeFormatted + `</span>
+		<br>
+		` + htmlDataIssue + `
+    </div>
+</body>
+</html>
+`
+	// Save the HTML to a file
+	saveHTML(htmlContent, "/go_seo_Header.html")
+}
+
+// If data issue have been detected generate the HTML to include in the header
+func generateDataIssueHTML(revenueDataIssue bool, visitsDataIssue bool, ordersDataIssue bool) string {
+
+	htmlDataIssue := "<br>"
+
+	_, unitPlural := granularityUnit()
+	htmlDataIssue += "<span style=\"color: red;\">Warning: Less than " + fmt.Sprintf("%d", requestedPeriods) + " " + unitPlural + " valid data has been found for "
+
+	// Check which variables are true and include them in the HTML content
+	if revenueDataIssue {
+		htmlDataIssue += "revenue, "
+	}
+	if visitsDataIssue {
+		htmlDataIssue += "visits, "
+	}
+	if ordersDataIssue {
+		htmlDataIssue += "orders, "
+	}
+
+	htmlDataIssue += "</br>"
+
+	// Trim the trailing comma and space
+	htmlDataIssue = htmlDataIssue[:len(htmlDataIssue)-2] + ". </span>"
+
+	return htmlDataIssue
+}
+
+// CMGR Badges
+func badgeCMGR() {
+
+	cmgrRevenue32 := float32(cmgrRevenue)
+	cmgrVisits32 := float32(cmgrVisits)
+	cmgrVisitValue32 := float32(cmgrVisitValue)
+	cmgrOrderValue32 := float32(cmgrOrderValue)
+	cmgrOrderValueValue32 := float32(cmgrOrderValueValue)
+
+	// Generate the badges
+	insightsCacheFolderTrimmed := strings.TrimPrefix(insightsCacheFolder, ".")
+
+	// URL to full screen badge display
+	clickURL := protocol + "://" + fullHost + insightsCacheFolderTrimmed + "/go_seo_CMGRRevenue.html"
+	generateLiquidBadge("Revenue", cmgrRevenue32, clickURL, "Revenue growth over the period")
+
+	clickURL = protocol + "://" + fullHost + insightsCacheFolderTrimmed + "/go_seo_CMGRVisits.html"
+	generateLiquidBadge("Visits", cmgrVisits32, clickURL, "Visits growth")
+
+	clickURL = protocol + "://" + fullHost + insightsCacheFolderTrimmed + "/go_seo_CMGRVisitValue.html"
+	generateLiquidBadge("Visit Value", cmgrVisitValue32, clickURL, "Visit value (RPV)")
+
+	clickURL = protocol + "://" + fullHost + insightsCacheFolderTrimmed + "/go_seo_CMGROrders.html"
+	generateLiquidBadge("Orders", cmgrOrderValue32, clickURL, "Order volume")
+
+	clickURL = protocol + "://" + fullHost + insightsCacheFolderTrimmed + "/go_seo_CMGROrderValue.html"
+	generateLiquidBadge("Order Value", cmgrOrderValueValue32, clickURL, "Order value (AOV)")
+}
+
+// Total Visits, Orders & Revenue
+func tableVisitsOrdersRevenue() {
+
+	formatInteger := message.NewPrinter(language.English)
+
+	totalVisitsFormatted := formatInteger.Sprintf("%d", metricsVisitsOrganic)
+	totalOrdersFormatted := formatInteger.Sprintf("%d", metricsOrdersOrganic)
+	totalRevenueFormatted := formatInteger.Sprintf("%d", metricsRevenueOrganic)
+
+	totalAverageOrderValueFormatted := formatInteger.Sprintf("%d", totalAverageOrderValueOrganic)
+	totalAverageVisitsPerOrderFormatted := formatInteger.Sprintf("%d", totalAverageVisitsPerOrder)
+	totalAverageVisitValueFormatted := fmt.Sprintf("%.2f", totalAverageVisitValue)
+
+	// Comparison period values & changes (if a comparison period has been selected)
+	compareRevenue := comparisonHTML(float64(metricsRevenueOrganic), float64(comparisonTotals.Revenue), currencySymbol+formatInteger.Sprintf("%d", comparisonTotals.Revenue), true)
+	compareVisits := comparisonHTML(float64(metricsVisitsOrganic), float64(comparisonTotals.Visits), formatInteger.Sprintf("%d", comparisonTotals.Visits), true)
+	compareVisitValue := comparisonHTML(totalAverageVisitValue, comparisonTotals.AvgVisitValue, fmt.Sprintf("%.2f", comparisonTotals.AvgVisitValue), true)
+	compareOrders := comparisonHTML(float64(metricsOrdersOrganic), float64(comparisonTotals.Orders), formatInteger.Sprintf("%d", comparisonTotals.Orders), true)
+	compareOrderValue := comparisonHTML(float64(totalAverageOrderValueOrganic), float64(comparisonTotals.AvgOrderValue), currencySymbol+formatInteger.Sprintf("%d", comparisonTotals.AvgOrderValue), true)
+	compareVisitsPerOrder := comparisonHTML(float64(totalAverageVisitsPerOrder), float64(comparisonTotals.VisitsPerOrder), formatInteger.Sprintf("%d", comparisonTotals.VisitsPerOrder), false)
+
+	// Year-on-year changes
+	compareRevenue += yoyHTML(float64(metricsRevenueOrganic), float64(lastYearTotals.Revenue))
+	compareVisits += yoyHTML(float64(metricsVisitsOrganic), float64(lastYearTotals.Visits))
+	compareVisitValue += yoyHTML(totalAverageVisitValue, lastYearTotals.AvgVisitValue)
+	compareOrders += yoyHTML(float64(metricsOrdersOrganic), float64(lastYearTotals.Orders))
+	compareOrderValue += yoyHTML(float64(totalAverageOrderValueOrganic), float64(lastYearTotals.AvgOrderValue))
+	compareVisitsPerOrder += yoyHTML(float64(totalAverageVisitsPerOrder), float64(lastYearTotals.VisitsPerOrder))
+
+	htmlContent := `
+<!DOCTYPE html>
+<html>
+<head>
+    <style>
+        body {
+            font-family: Arial, sans-serif;
+            color: #333;
+            display: flex;
+            justify-content: center;
+            align-items: center;
+            margin: 0;
+            height: 100vh;
+            background-color: #f4f4f4; 
+        }
+        .container {
+            display: flex;
+            flex-direction: column; 
+            justify-content: center;
+            align-items: center;
+            width: 100%;
+            height: 100%;
+            padding: 20px;
+            box-sizing: border-box;
+        }
+        .header {
+            font-size: 30px;
+            font-weight: bold;
+            color: Grey;
+            margin-bottom: 20px;
+            text-align: center;
+        }
+        .wrapper {
+            width: 100%;
+            max-width: 1200px;
+            padding: 20px;
+            border-radius: 8px;
+            background-color: #fff; 
+            box-shadow: 0 4px 8px rgba(0,0,0,0.1); 
+        }
+        table {
+            width: 100%;
+            border-collapse: collapse;
+            text-align: center;
+        }
+        th, td {
+            font-size: 35px;
+            padding: 10px;
+        }
+        th {
+            color: #555;
+            font-weight: 600;
+        }
+        td {
+            color: #00796b;
+        }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="wrapper">
+            <table>
+                <tr>
+					<th style="color: teal; padding-right: 120px;">Organic</th>
+                    <th style="color: deepskyblue;">Revenue</th>
+                    <th style="color: deepskyblue;">Visits</th>
+                    <th style="color: deepskyblue;">RPV</th>
+                </tr>
+                <tr>
+                    <td>` + fmt.Sprintf("%s", "") + `</td>
+                    <td>` + fmt.Sprintf("%s%s", currencySymbol, totalRevenueFormatted) + compareRevenue + `</td>
+                    <td>` + fmt.Sprintf("%s", totalVisitsFormatted) + compareVisits + `</td>
+                    <td>` + fmt.Sprintf("%s", totalAverageVisitValueFormatted) + compareVisitValue + `</td>
+                </tr>
+                <tr>
+                    <td>` + fmt.Sprintf("%s", "") + `</td>
+                    <th style="color: deepskyblue;">Orders</th>
+                    <th style="color: deepskyblue;">AOV</th>
+                    <th style="color: deepskyblue;">Visits Per Order</th>
+                </tr>
+                <tr>
+                    <td>` + fmt.Sprintf("%s", "") + `</td>
+                    <td>` + fmt.Sprintf("%s", totalOrdersFormatted) + compareOrders + `</td>
+                    <td>` + fmt.Sprintf("%s%s", currencySymbol, totalAverageOrderValueFormatted) + compareOrderValue + `</td>
+                    <td>` + fmt.Sprintf("%s", totalAverageVisitsPerOrderFormatted) + compareVisitsPerOrder + `</td>
+                </tr>
+            </table>
+        </div>
+    </div>
+</body>
+</html>
+`
+	// Save the HTML to a file
+	saveHTML(htmlContent, "/go_seo_TotalsOrganic.html")
+}
+
+// Non-branded performance
+func tableNonBrandedPerformance() {
+
+	formatInteger := message.NewPrinter(language.English)
+
+	scImpressionsTotalFormatted := formatInteger.Sprintf("%d", scImpressionsTotal)
+	scClicksTotalFormatted := formatInteger.Sprintf("%d", scClicksTotal)
+	scAvgPositionTotalFormatted := fmt.Sprintf("%.2f", scAvgPositionTotal)
+	scCTRTotalFormatted := fmt.Sprintf("%.2f", scCTRTotal)
+
+	// Comparison period values & changes (if a comparison period has been selected)
+	compareImpressions := comparisonHTML(float64(scImpressionsTotal), float64(comparisonTotals.Impressions), formatInteger.Sprintf("%d", comparisonTotals.Impressions), true)
+	compareClicks := comparisonHTML(float64(scClicksTotal), float64(comparisonTotals.Clicks), formatInteger.Sprintf("%d", comparisonTotals.Clicks), true)
+	compareAvgPosition := comparisonHTML(scAvgPositionTotal, comparisonTotals.AvgPosition, fmt.Sprintf("%.2f", comparisonTotals.AvgPosition), false)
+	compareCTR := comparisonHTML(scCTRTotal, comparisonTotals.CTR, fmt.Sprintf("%.2f", comparisonTotals.CTR), true)
+
+	// Year-on-year changes
+	compareImpressions += yoyHTML(float64(scImpressionsTotal), float64(lastYearTotals.Impressions))
+	compareClicks += yoyHTML(float64(scClicksTotal), float64(lastYearTotals.Clicks))
+	compareAvgPosition += yoyHTML(scAvgPositionTotal, lastYearTotals.AvgPosition)
+	compareCTR += yoyHTML(scCTRTotal, lastYearTotals.CTR)
+
+	htmlContent := `
+<!DOCTYPE html>
+<html>
+<head>
+    <style>
+        body {
+            font-family: Arial, sans-serif;
+            color: #333;
+            display: flex;
+            justify-content: center;
+            align-items: center;
+            margin: 0;
+            height: 100vh;
+            background-color: #f4f4f4; 
+        }
+        .container {
+            display: flex;
+            flex-direction: column; 
+            justify-content: center;
+            align-items: center;
+            width: 100%;
+            height: 100%;
+            padding: 20px;
+            box-sizing: border-box;
+        }
+        .header {
+            font-size: 30px;
+            font-weight: bold;
+            color: Grey;
+            margin-bottom: 20px;
+            text-align: center;
+        }
+        .wrapper {
+            width: 100%;
+            max-width: 1200px;
+            padding: 20px;
+            border-radius: 8px;
+            background-color: #fff; 
+            box-shadow: 0 4px 8px rgba(0,0,0,0.1); 
+        }
+        table {
+            width: 100%;
+            border-collapse: collapse;
+            text-align: center;
+        }
+        th, td {
+            font-size: 35px;
+            padding: 10px;
+        }
+        th {
+            color: #555;
+            font-weight: 600;
+        }
+        td {
+            color: #00796b;
+        }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="wrapper">
+            <table>
+                <tr>                            
+					<th style="color: teal;">Non Brand Keyword Performance</th>
+                    <th style="color: deepskyblue;">Impressions</th>
+                    <th style="color: deepskyblue;">Clicks</th>
+                    <th style="color: deepskyblue;">Avg. Position</th>
+                    <th style="color: deepskyblue;">Avg. CTR</th>
+                </tr>
+				<tr>
+                    <td>` + fmt.Sprintf("%s", "") + `</td>
+                    <td>` + fmt.Sprintf("%s", scImpressionsTotalFormatted) + compareImpressions + `</td>
+                    <td>` + fmt.Sprintf("%s", scClicksTotalFormatted) + compareClicks + `</td>
+                    <td>` + fmt.Sprintf("%s", scAvgPositionTotalFormatted) + compareAvgPosition + `</td>
+                    <td>` + fmt.Sprintf("%s", scCTRTotalFormatted) + compareCTR + `</td>
+                </tr>
+            </table>
+        </div>
+    </div>
+</body>
+</html>
+`
+	// Save the HTML to a file
+	saveHTML(htmlContent, "/go_seo_TotalsNonBrandedPerformance.html")
+}
+
+// Bar chart. Revenue and Visits
+func barRevenueVisits() {
+
+	// Generate the URL to the chart. Used to display the chart full screen when the header is clicked
+	insightsCacheFolderTrimmed := strings.TrimPrefix(insightsCacheFolder, ".")
+	clickURL := protocol + "://" + fullHost + insightsCacheFolderTrimmed + "/go_seo_RevenueVisitsBar.html"
+
+	bar := charts.NewBar()
+	bar.SetGlobalOptions(
+		charts.WithTitleOpts(opts.Title{
+			Title:    "Revenue & visits (click for full screen)",
+			Subtitle: "How many organic visits does the site attract and what is the generated revenue from those visits?",
+			Link:     clickURL,
+		}),
+		charts.WithLegendOpts(opts.Legend{Right: "80px"}),
+		charts.WithDataZoomOpts(opts.DataZoom{
+			Type:  "slider",
+			Start: 1,
+			End:   100,
+		}),
+		charts.WithInitializationOpts(opts.Initialization{
+			Width:     chartDefaultWidth,
+			Height:    chartDefaultHeight,
+			PageTitle: "Revenue & visits",
+		}),
+		charts.WithColorsOpts(opts.Colors{kpiColourVisits, kpiColourRevenue, kpiColourLastYear, kpiColourLastYearAlt, kpiColourComparison, kpiColourComparisonAlt}),
+	)
+
+	barDataRevenue := generateBarItems(seoRevenue)
+	barDataVisits := generateBarItems(seoVisits)
+
+	var seriesWithCurrency = "Revenue (" + currencySymbol + ")"
+
+	bar.SetXAxis(startMonthNames).
+		AddSeries(seriesWithCurrency, barDataRevenue).
+		AddSeries("Visits", barDataVisits).
+		SetSeriesOptions(
+			charts.WithMarkPointNameTypeItemOpts(
+				opts.MarkPointNameTypeItem{Name: "Highest", Type: "max", ItemStyle: &opts.ItemStyle{Color: "rgb(144, 238, 144)"}},
+				opts.MarkPointNameTypeItem{Name: "Lowest", Type: "min", ItemStyle: &opts.ItemStyle{Color: "rgb(255, 55, 55)"}},
+			),
+			charts.WithMarkPointStyleOpts(
+				opts.MarkPointStyle{
+					SymbolSize: 90,
+				}),
+		)
+
+	// Same months last year
+	bar.AddSeries(seriesWithCurrency+" - last year", lastYearBarItems(func(month monthlyInsights) float64 { return float64(month.Revenue) })).
+		AddSeries("Visits - last year", lastYearBarItems(func(month monthlyInsights) float64 { return float64(month.Visits) }))
+
+	// Comparison period
+	if comparisonChartSeries() {
+		bar.AddSeries(seriesWithCurrency+comparisonSeriesSuffix(), comparisonBarItems(func(month monthlyInsights) float64 { return float64(month.Revenue) })).
+			AddSeries("Visits"+comparisonSeriesSuffix(), comparisonBarItems(func(month monthlyInsights) float64 { return float64(month.Visits) }))
+	}
+
+	var f *os.File
+	var err error
+
+	// Assign 'f' here
+	f, err = os.Create(insightsCacheFolder + "/go_seo_RevenueVisitsBar.html")
+	if err != nil {
+		fmt.Printf(red+"Error. barRevenueVisits. Cannot create go_seo_RevenueVisitsBar.html: %v\n"+reset, err)
+		return
+	}
+
+	// Render the chart to the file
+	_ = bar.Render(f)
+}
+
+// Visits per order line chart
+func lineVisitsPerOrder() {
+
+	// Generate the URL to the chart. Used to display the chart full screen when the header is clicked
+	insightsCacheFolderTrimmed := strings.TrimPrefix(insightsCacheFolder, ".")
+	clickURL := protocol + "://" + fullHost + insightsCacheFolderTrimmed + "/go_seo_VisitsPerOrderLine.html"
+
+	line := charts.NewLine()
+	line.SetGlobalOptions(
+		charts.WithTitleOpts(opts.Title{
+			Title:    "Visits per order (click for full screen)",
+			Subtitle: "High number of visits per order indicates poor quality traffic or conversion inefficiency.",
+			Link:     clickURL,
+		}),
+		charts.WithDataZoomOpts(opts.DataZoom{
+			Type:  "slider",
+			Start: 0,
+			End:   100,
+		}),
+		charts.WithInitializationOpts(opts.Initialization{
+			Width:     chartDefaultWidth,
+			Height:    chartDefaultHeight,
+			PageTitle: "Average visits per order",
+		}),
+
+		charts.WithColorsOpts(opts.Colors{kpiColourVisitsPerOrder, kpiColourComparison}),
+		// Only show the legend when comparing
+		charts.WithLegendOpts(opts.Legend{Show: opts.Bool(activePeriod.comparing())}),
+	)
+
+	lineVisitsPerOrderValue := generateLineItems(seoVisitsPerOrder)
+
+	line.SetXAxis(startMonthNames).AddSeries("Visits per order", lineVisitsPerOrderValue).SetSeriesOptions(
+		charts.WithAreaStyleOpts(opts.AreaStyle{
+			Color: "lightSkyBlue",
+		}),
+		charts.WithLineChartOpts(opts.LineChart{
+			Smooth: opts.Bool(true),
+		}),
+		charts.WithMarkPointNameTypeItemOpts(
+			opts.MarkPointNameTypeItem{Name: "Highest No. of visits", Type: "max", ItemStyle: &opts.ItemStyle{Color: "rgb(255, 55, 55)"}},
+			opts.MarkPointNameTypeItem{Name: "Lowest  No. of visits", Type: "min", ItemStyle: &opts.ItemStyle{Color: "rgb(144, 238, 144)"}},
+		),
+		charts.WithMarkPointStyleOpts(
+			opts.MarkPointStyle{
+				// the size of the markLine symbol
+				SymbolSize: 90,
+			}),
+	)
+
+	// Comparison period
+	if activePeriod.comparing() {
+		line.AddSeries("Visits per order"+comparisonSeriesSuffix(), comparisonLineItems(func(month monthlyInsights) float64 {
+			if month.Orders == 0 {
+				return 0
+			}
+			return float64(month.Visits / month.Orders)
+		}))
+	}
+
+	f, _ := os.Create(insightsCacheFolder + "/go_seo_VisitsPerOrderLine.html")
+
+	_ = line.Render(f)
+}
+
+// Function to generate line chart items from an array of float64 values
+func generateLineItems(visitsPerOrder []int) []opts.LineData {
+
+	items := make([]opts.LineData, len(visitsPerOrder))
+	for i, val := range visitsPerOrder {
+		items[i] = opts.LineData{Value: val}
+	}
+	return items
+}
+
+// Visit value bar chart
+func barVisitValue() {
+
+	// Generate the URL to the chart. Used to display the chart full screen when the header is clicked
+	insightsCacheFolderTrimmed := strings.TrimPrefix(insightsCacheFolder, ".")
+	clickURL := protocol + "://" + fullHost + insightsCacheFolderTrimmed + "/go_seo_VisitsValueBar.html"
+
+	bar := charts.NewBar()
+	bar.SetGlobalOptions(charts.WithTitleOpts(opts.Title{
+		Title:    "Revenue per visit - RPV (click for full screen)",
+		Subtitle: "A high organic visit value is a strong indicator of the effectiveness and profitability of the site's organic traffic.",
+		Link:     clickURL,
+	}),
+		charts.WithLegendOpts(opts.Legend{Right: "80px"}),
+		charts.WithDataZoomOpts(opts.DataZoom{
+			Type:  "slider",
+			Start: 0,
+			End:   100,
+		}),
+		charts.WithInitializationOpts(opts.Initialization{
+			Width:     chartDefaultWidth,
+			Height:    chartDefaultHeight,
+			PageTitle: "Organic visit value",
+		}),
+		charts.WithColorsOpts(opts.Colors{kpiColourOrganicVisitValue, kpiColourLastYear, kpiColourComparison}),
+	)
+
+	barDataVisitValue := generateBarItemsFloat(seoVisitValue)
+
+	bar.SetXAxis(startMonthNames).
+		AddSeries("Organic visit value", barDataVisitValue).
+		SetSeriesOptions(charts.WithMarkLineNameTypeItemOpts(
+			opts.MarkLineNameTypeItem{Name: "Lowest visit value", Type: "min"},
+			opts.MarkLineNameTypeItem{Name: "Highest visit value", Type: "max"},
+			opts.MarkLineNameTypeItem{Name: "Average visit value", Type: "average"},
+		),
+			charts.WithMarkLineStyleOpts(
+				opts.MarkLineStyle{
+					Label:     &opts.Label{FontSize: 15},
+					LineStyle: &opts.LineStyle{Color: "rgb(255, 128, 0)", Width: 3, Opacity: .7, Type: "dotted"},
+				},
+			),
+		)
+
+	// Same months last year
+	bar.AddSeries("Organic visit value - last year", lastYearBarItems(func(month monthlyInsights) float64 { return math.Round(month.AvgVisitValue*100) / 100 }))
+
+	// Comparison period
+	if comparisonChartSeries() {
+		bar.AddSeries("Organic visit value"+comparisonSeriesSuffix(), comparisonBarItems(func(month monthlyInsights) float64 { return math.Round(month.AvgVisitValue*100) / 100 }))
+	}
+
+	f, _ := os.Create(insightsCacheFolder + "/go_seo_VisitsValueBar.html")
+
+	_ = bar.Render(f)
+}
+
+// Order volume bar chart
+func barOrders() {
+
+	// Generate the URL to the chart. Used to display the chart full screen when the header is clicked
+	insightsCacheFolderTrimmed := strings.TrimPrefix(insightsCacheFolder, ".")
+	clickURL := protocol + "://" + fullHost + insightsCacheFolderTrimmed + "/go_seo_OrdersBar.html"
+
+	bar := charts.NewBar()
+	bar.SetGlobalOptions(charts.WithTitleOpts(opts.Title{
+		Title:    "Order volume (click for full screen)",
+		Subtitle: "Order volume placed during an organic visit.",
+		Link:     clickURL,
+	}),
+		charts.WithLegendOpts(opts.Legend{Right: "80px"}),
+		charts.WithDataZoomOpts(opts.DataZoom{
+			Type:  "slider",
+			Start: 1,
+			End:   100,
+		}),
+		charts.WithInitializationOpts(opts.Initialization{
+			Width:     chartDefaultWidth,
+			Height:    chartDefaultHeight,
+			PageTitle: "Order volume",
+		}),
+		charts.WithColorsOpts(opts.Colors{kpiColourNoOfOrders, kpiColourLastYear, kpiColourComparison}),
+	)
+
+	barDataOrders := generateBarItems(seoOrders)
+
+	bar.SetXAxis(startMonthNames).
+		AddSeries("Orders", barDataOrders).
+		SetSeriesOptions(charts.WithMarkLineNameTypeItemOpts(
+			opts.MarkLineNameTypeItem{Name: "Lowest No. orders", Type: "min"},
+			opts.MarkLineNameTypeItem{Name: "Highest No. orders", Type: "max"},
+			opts.MarkLineNameTypeItem{Name: "Average No. orders", Type: "average"},
+		),
+			charts.WithMarkLineStyleOpts(
+				opts.MarkLineStyle{
+					Label:     &opts.Label{FontSize: 15},
+					LineStyle: &opts.LineStyle{Color: "rgb(255, 128, 0)", Width: 3, Opacity: .7, Type: "dotted"},
+				},
+			),
+		)
+
+	// Same months last year
+	bar.AddSeries("Orders - last year", lastYearBarItems(func(month monthlyInsights) float64 { return float64(month.Orders) }))
+
+	// Comparison period
+	if comparisonChartSeries() {
+		bar.AddSeries("Orders"+comparisonSeriesSuffix(), comparisonBarItems(func(month monthlyInsights) float64 { return float64(month.Orders) }))
+	}
+
+	f, _ := os.Create(insightsCacheFolder + "/go_seo_OrdersBar.html")
+
+	_ = bar.Render(f)
+}
+
+// Order value bar chart
+func barOrderValue() {
+
+	// Generate the URL to the chart. Used to display the chart full screen when the header is clicked
+	insightsCacheFolderTrimmed := strings.TrimPrefix(insightsCacheFolder, ".")
+	clickURL := protocol + "://" + fullHost + insightsCacheFolderTrimmed + "/go_seo_OrderValueBar.html"
+
+	bar := charts.NewBar()
+
+	bar.SetGlobalOptions(charts.WithTitleOpts(opts.Title{
+		Title:    "Order value - AOV (click for full screen)",
+		Subtitle: "The average value of an order placed during a visit from an organic source. A higher value reflects effective SEO strategies driving quality traffic.",
+		Link:     clickURL,
+	}),
+		charts.WithLegendOpts(opts.Legend{Right: "80px"}),
+		charts.WithDataZoomOpts(opts.DataZoom{
+			Type:  "slider",
+			Start: 1,
+			End:   100,
+		}),
+		charts.WithInitializationOpts(opts.Initialization{
+			Width:     chartDefaultWidth,
+			Height:    chartDefaultHeight,
+			PageTitle: "Average order value",
+		}),
+		charts.WithColorsOpts(opts.Colors{kpiColourOrderValue, kpiColourLastYear, kpiColourComparison}),
+	)
+
+	barDataOrderValue := generateBarItems(seoOrderValue)
+
+	bar.SetXAxis(startMonthNames).
+		AddSeries("Order value", barDataOrderValue).
+		SetSeriesOptions(charts.WithMarkLineNameTypeItemOpts(
+			opts.MarkLineNameTypeItem{Name: "Lowest order value", Type: "min"},
+			opts.MarkLineNameTypeItem{Name: "Highest order value", Type: "max"},
+			opts.MarkLineNameTypeItem{Name: "Average order value", Type: "average"},
+		),
+			charts.WithMarkLineStyleOpts(
+				opts.MarkLineStyle{
+					Label:     &opts.Label{FontSize: 15},
+					LineStyle: &opts.LineStyle{Color: "rgb(255, 128, 0)", Width: 3, Opacity: .7, Type: "dotted"},
+				},
+			),
+		)
+
+	// Same months last year
+	bar.AddSeries("Order value - last year", lastYearBarItems(func(month monthlyInsights) float64 { return float64(month.AvgOrderValue) }))
+
+	// Comparison period
+	if comparisonChartSeries() {
+		bar.AddSeries("Order value"+comparisonSeriesSuffix(), comparisonBarItems(func(month monthlyInsights) float64 { return float64(month.AvgOrderValue) }))
+	}
+
+	f, _ := os.Create(insightsCacheFolder + "/go_seo_OrderValueBar.html")
+
+	_ = bar.Render(f)
+}
+
+// Function to generate BarData items from an array of integers
+func generateBarItems(revenue []int) []opts.BarData {
+
+	items := make([]opts.BarData, len(revenue))
+	for i, val := range revenue {
+		items[i] = opts.BarData{Value: val}
+	}
+	return items
+}
+
+// Function to generate BarData items from an array of float64 values
+func generateBarItemsFloat(revenue []float64) []opts.BarData {
+
+	items := make([]opts.BarData, len(revenue))
+	for i, val := range revenue {
+		items[i] = opts.BarData{Value: val}
+	}
+	return items
+}
+
+// CMGR badges (CWGR & CDGR for weekly & daily insights)
+func generateLiquidBadge(badgeKPI string, badgeKPIValue float32, clickURL string, title string) {
+
+	badgeKPIValueCalc := badgeKPIValue * 100
+
+	growthRate, _ := growthRateName()
+	subTitle := fmt.Sprintf("Compound growth %s. Rounded from %.2f%%", growthRate, badgeKPIValueCalc)
+
+	liquid := charts.NewLiquid()
+
+	liquid.SetGlobalOptions(
+		charts.WithInitializationOpts(opts.Initialization{
+			PageTitle: badgeKPI + " growth (" + growthRate + ")",
+			Width:     badgeDefaultWidth,
+			Height:    badgeDefaultHeight,
+		}),
+		charts.WithTitleOpts(opts.Title{
+			Title:    title,
+			Subtitle: subTitle,
+			Link:     clickURL,
+		}),
+	)
+
+	liquid.AddSeries("", genLiquidItems([]float32{badgeKPIValue})).
+		SetSeriesOptions(
+			charts.WithLabelOpts(opts.Label{
+				Show: opts.Bool(true),
+			}),
+
+			charts.WithLiquidChartOpts(opts.LiquidChart{
+				IsWaveAnimation: opts.Bool(true),
+				Shape:           "roundRect",
+			}),
+		)
+
+	// Removing spaces from badgeKPI to ensure a clean URL for the HTML is generated.
+	badgeKPI = strings.ReplaceAll(badgeKPI, " ", "")
+	badgeFileName := fmt.Sprintf("/go_seo_CMGR%s.html", badgeKPI)
+	f, _ := os.Create(insightsCacheFolder + badgeFileName)
+
+	_ = liquid.Render(f)
+}
+
+// Get data for the liquid badge
+func genLiquidItems(data []float32) []opts.LiquidData {
+
+	items := make([]opts.LiquidData, 0)
+	for i := 0; i < len(data); i++ {
+		items = append(items, opts.LiquidData{Value: data[i]})
+	}
+	return items
+}
+
+// Branded and non-branded wordclouds
+func wordcloudBrandedNonBranded(brandedMode bool) {
+
+	var clickURL string
+	var pageTitle string
+
+	if brandedMode {
+		// Generate the URL to the chart. Used to display the chart full screen when the header is clicked
+		insightsCacheFolderTrimmed := strings.TrimPrefix(insightsCacheFolder, ".")
+		clickURL = protocol + "://" + fullHost + insightsCacheFolderTrimmed + "/go_seo_WordcloudBranded.html"
+		pageTitle = "Branded wordcloud"
+	}
+	if !brandedMode {
+		// Generate the URL to the chart. Used to display the chart full screen when the header is clicked
+		insightsCacheFolderTrimmed := strings.TrimPrefix(insightsCacheFolder, ".")
+		clickURL = protocol + "://" + fullHost + insightsCacheFolderTrimmed + "/go_seo_WordcloudNonBranded.html"
+		pageTitle = "Non Branded wordcloud"
+	}
+
+	wordcloud := charts.NewWordCloud()
+
+	wordcloud.SetGlobalOptions(
+		charts.WithInitializationOpts(opts.Initialization{
+			Width:     wordcloudDefaultWidth,
+			Height:    wordcloudDefaultHeight,
+			PageTitle: pageTitle,
+		}),
+		charts.WithLegendOpts(opts.Legend{Show: opts.Bool(false)}),
+		charts.WithTitleOpts(opts.Title{
+			Link: clickURL,
+		}))
+
+	// Generate the branded wordcloud
+	if brandedMode {
+		wordcloud.AddSeries("Clicks", generateWCData(kwKeywords, kwCountClicks)).
+			SetSeriesOptions(
+				charts.WithWorldCloudChartOpts(
+					opts.WordCloudChart{
+						SizeRange: []float32{10, 150},
+						Shape:     "circle",
+					}),
+			)
+	}
+
+	// Generate the non-branded wordcloud
+	if !brandedMode {
+		wordcloud.AddSeries("Clicks", generateWCDataNonBranded(kwKeywordsNonBranded, kwCountClicksNonBranded)).
+			SetSeriesOptions(
+				charts.WithWorldCloudChartOpts(
+					opts.WordCloudChart{
+						SizeRange: []float32{10, 150},
+						Shape:     "circle",
+					}),
+			)
+	}
+
+	if brandedMode {
+		f, _ := os.Create(insightsCacheFolder + "/go_seo_WordcloudBranded.html")
+		_ = wordcloud.Render(f)
+	}
+
+	if !brandedMode {
+		f, _ := os.Create(insightsCacheFolder + "/go_seo_WordcloudNonBranded.html")
+		_ = wordcloud.Render(f)
+	}
+
+}
+
+// Generate the data for the wordcloud - Branded
+func generateWCData(kwKeywords []string, kwCountClicks []int) (items []opts.WordCloudData) {
+
+	items = make([]opts.WordCloudData, 0)
+	// Iterate over kwKeywords and kwCountClicks slices starting from index 1
+	// We start at index 1 because the top keyword is generally significantly higher performing than the following keywords and will distort the wordcloud if included
+	for i := 1; i < len(kwKeywords); i++ {
+		// Check if index is valid for kwCountClicks slice
+		if i < len(kwCountClicks) {
+			// Append WordCloudData struct with keyword and corresponding count
+			items = append(items, opts.WordCloudData{Name: kwKeywords[i], Value: kwCountClicks[i]})
+		}
+	}
+	return items
+}
+
+// Generate the data for the wordcloud - Non-branded
+func generateWCDataNonBranded(kwKeywordsNonBranded []string, kwCountClicksNonBranded []int) (items []opts.WordCloudData) {
+
+	items = make([]opts.WordCloudData, 0)
+	// Iterate over kwKeywords and kwCountClicks slices starting from index 1
+	// We start at index 1 because the top keyword is generally significantly higher performing than the following keywords and will distort the wordcloud if included
+	for i := 1; i < len(kwKeywordsNonBranded); i++ {
+		// Check if index is valid for kwCountClicks slice
+		if i < len(kwCountClicksNonBranded) {
+			// Append WordCloudData struct with keyword and corresponding count
+			items = append(items, opts.WordCloudData{Name: kwKeywordsNonBranded[i], Value: kwCountClicksNonBranded[i]})
+		}
+	}
+	return items
+}
+
+// River chart for revenue and visits
+func riverRevenueVisits() {
+
+	// Generate the URL to the chart. Used to display the chart full screen when the header is clicked
+	insightsCacheFolderTrimmed := strings.TrimPrefix(insightsCacheFolder, ".")
+	clickURL := protocol + "://" + fullHost + insightsCacheFolderTrimmed + "/go_seo_VisitsRevenueRiver.html"
+
+	river := charts.NewThemeRiver()
+
+	// The comparison period totals & changes
+	formatInteger := message.NewPrinter(language.English)
+	comparison := comparisonSubtitle(
+		comparisonKPI("revenue", float64(metricsRevenueOrganic), float64(comparisonTotals.Revenue), currencySymbol+formatInteger.Sprintf("%d", comparisonTotals.Revenue)),
+		comparisonKPI("visits", float64(metricsVisitsOrganic), float64(comparisonTotals.Visits), formatInteger.Sprintf("%d", comparisonTotals.Visits)))
+
+	river.SetGlobalOptions(
+		charts.WithTitleOpts(opts.Title{
+			Title:    "Revenue & visits (click for full screen)",
+			Subtitle: "Insights into the fluctuations in organic visitors to a site and the corresponding revenue generation." + comparison,
+			Link:     clickURL}),
+		charts.WithSingleAxisOpts(opts.SingleAxis{
+			Type:   "time",
+			Bottom: "10%",
+		}),
+		charts.WithYAxisOpts(opts.YAxis{
+			Type: "time",
+		}),
+		charts.WithTooltipOpts(opts.Tooltip{
+			Trigger: "axis",
+		}),
+		// Increase the canvas size
+		charts.WithInitializationOpts(opts.Initialization{
+			Width:     chartDefaultWidth,
+			Height:    chartDefaultHeight,
+			PageTitle: "Revenue & visits",
+		}),
+
+		charts.WithColorsOpts(opts.Colors{kpiColourVisits, kpiColourRevenue}),
+		// disable show the legend
+		charts.WithLegendOpts(opts.Legend{Show: opts.Bool(false)}),
+	)
+
+	// Populate ThemeRiverData slice
+	var themeRiverData []opts.ThemeRiverData
+
+	// Add the Revenue data
+	// The date is formatted from YYYYMMDD to YYYY/MM/DD
+	for i, date := range startMonthDates {
+		parsedDate, err := time.Parse("20060102", date)
+		if err != nil {
+			fmt.Printf(red+"Error. riverRevenueVisits. Error parsing date: %v\n"+reset, err)
+			break
+		}
+		formattedDate := parsedDate.Format("2006/01/02")
+		themeRiverData = append(themeRiverData, opts.ThemeRiverData{
+			Date:  formattedDate,
+			Value: float64(seoRevenue[i]),
+			Name:  "Revenue",
+		})
+	}
+
+	// Add the Visits data
+	// The date is formatted from YYYYMMDD to YYYY/MM/DD
+	for i, date := range startMonthDates {
+		parsedDate, err := time.Parse("20060102", date)
+		if err != nil {
+			fmt.Printf(red+"Error. riverRevenueVisits. Error parsing date: %v\n"+reset, err)
+			break
+		}
+		formattedDate := parsedDate.Format("2006/01/02")
+		themeRiverData = append(themeRiverData, opts.ThemeRiverData{
+			Date:  formattedDate,
+			Value: float64(seoVisits[i]),
+			Name:  "Visits",
+		})
+	}
+
+	river.AddSeries("themeRiver", themeRiverData)
+
+	f, _ := os.Create(insightsCacheFolder + "/go_seo_VisitsRevenueRiver.html")
+
+	_ = river.Render(f)
+}
+
+func gaugeVisitsPerOrder() {
+
+	gauge := charts.NewGauge()
+
+	insightsCacheFolderTrimmed = strings.TrimPrefix(insightsCacheFolder, ".")
+	clickURL := protocol + "://" + fullHost + insightsCacheFolderTrimmed + "/go_seo_VisitsPerOrderGauge.html"
+
+	setMinMax := charts.WithSeriesOpts(func(s *charts.SingleSeries) {
+		s.Min = minVisitsPerOrder
+		s.Max = maxVisitsPerOrder
+	})
+
+	// The comparison period average & change
+	comparison := comparisonSubtitle(
+		comparisonKPI("average", float64(totalAverageVisitsPerOrder), float64(comparisonTotals.VisitsPerOrder), fmt.Sprintf("%d", comparisonTotals.VisitsPerOrder)))
+
+	gauge.SetGlobalOptions(
+		charts.WithTitleOpts(opts.Title{
+			Title:    "Lowest, average & highest organic visits per order",
+			Subtitle: strings.TrimPrefix(comparison, "\n"),
+			Link:     clickURL}),
+
+		charts.WithInitializationOpts(opts.Initialization{
+			Width:     gaugeDefaultWidth,
+			Height:    gaugeDefaultHeight,
+			PageTitle: "Visits per order",
+		}),
+	)
+	gauge.AddSeries("",
+		[]opts.GaugeData{{Value: totalAverageVisitsPerOrder}}, setMinMax)
+
+	f, _ := os.Create(insightsCacheFolder + "/go_seo_VisitsPerOrderGauge.html")
+
+	_ = gauge.Render(f)
+}
+
+// Table containing the detailed KPI insights
+func textTableDataDetail() {
+
+	var detailedKPITableData [][]string
+
+	formatInteger := message.NewPrinter(language.English)
+
+	for i := 0; i < noOfMonths; i++ {
+		formattedDate := formatDate(startMonthDates[i])
+		orders := formatInteger.Sprintf("%d", seoOrders[i])
+		revenue := formatInteger.Sprintf("%d", seoRevenue[i])
+		orderValue := formatInteger.Sprintf("%d", seoOrderValue[i])
+		visits := formatInteger.Sprintf("%d", seoVisits[i])
+		visitValue := formatInteger.Sprintf("%.2f", seoVisitValue[i])
+		visitsPerOrderValue := formatInteger.Sprintf("%d", seoVisitsPerOrder[i])
+
+		scImpressions := formatInteger.Sprintf("%d", seoScImpressions[i])
+		scClicks := formatInteger.Sprintf("%d", seoScClicks[i])
+		scAvgPosition := fmt.Sprintf("%.2f", seoScAvgPosition[i])
+		scCTR := fmt.Sprintf("%.2f", seoScCTR[i])
+
+		// Year-on-year changes
+		lastYear := lastYearByMonth[startMonthDates[i]]
+
+		row := []string{
+			formattedDate,
+			orders,
+			currencySymbol + revenue,
+			currencySymbol + orderValue,
+			visits,
+			currencySymbol + visitValue,
+			visitsPerOrderValue,
+			scImpressions,
+			scClicks,
+			scAvgPosition,
+			scCTR,
+			yoyChange(float64(seoRevenue[i]), float64(lastYear.Revenue)),
+			yoyChange(float64(seoVisits[i]), float64(lastYear.Visits)),
+			yoyChange(float64(seoOrders[i]), float64(lastYear.Orders)),
+			yoyChange(float64(seoOrderValue[i]), float64(lastYear.AvgOrderValue)),
+			yoyChange(seoVisitValue[i], math.Round(lastYear.AvgVisitValue*100)/100),
+		}
+		detailedKPITableData = append(detailedKPITableData, row)
+	}
+
+	// Generate the table
+	htmlContent := generateHTMLDetailedKPIInsightsTable(detailedKPITableData)
+
+	// Save the HTML to a file
+	saveHTML(htmlContent, "/go_seo_OrganicBusinessInsights.html")
+}
+
+// Winning keywords, branded & non-branded
+func textWinningKeywords(brandedMode bool, sessionID string) {
+
+	var htmlFileName = ""
+
+	// Define the display values based on branded or non-branded mode
+	var htmlKeyword = ""
+	var htmlClicks = ""
+	var htmlClickGap = 0
+	var htmlSecondPlaceKW = ""
+	var htmlCTR float64
+	var htmlAvgPosition float64
+	var brandedHeader = ""
+
+	formatInteger := message.NewPrinter(language.English)
+
+	if brandedMode {
+		brandedHeader = "Branded Keywords"
+		htmlKeyword = kwKeywords[0]
+		htmlClicks = formatInteger.Sprintf("%d", kwCountClicks[0])
+		htmlClickGap = int(((float64(kwCountClicks[0]) - float64(kwCountClicks[1])) / float64(kwCountClicks[1])) * 100)
+		htmlSecondPlaceKW = kwKeywords[1]
+		htmlCTR = kwMetricsCTR[0]
+		htmlAvgPosition = kwMetricsAvgPosition[0]
+		fmt.Println("\n" + yellow + sessionID + reset + " Branded keywords\n")
+		for i := 0; i < len(kwKeywords); i++ {
+			fmt.Printf(green+"Keyword:"+reset+bold+" %s"+reset+","+green+" Clicks:"+reset+" %d,"+green+" CTR:"+reset+" %.2f,"+green+" Avg. Position:"+reset+" %.2f\n",
+				kwKeywords[i], kwCountClicks[i], kwMetricsCTR[i], kwMetricsAvgPosition[i])
+		}
+	}
+
+	if !brandedMode {
+		brandedHeader = "Non-Branded Keywords"
+		htmlKeyword = kwKeywordsNonBranded[0]
+		htmlClicks = formatInteger.Sprintf("%d", kwCountClicksNonBranded[0])
+		htmlClickGap = int(((float64(kwCountClicksNonBranded[0]) - float64(kwCountClicksNonBranded[1])) / float64(kwCountClicksNonBranded[1])) * 100)
+		htmlSecondPlaceKW = kwKeywordsNonBranded[1]
+		htmlCTR = kwCTRNonBranded[0]
+		htmlAvgPosition = kwAvgPositionNonBranded[0]
+		fmt.Println("\n" + yellow + sessionID + reset + " Non branded keywords\n")
+		for i := 0; i < len(kwKeywords); i++ {
+			fmt.Printf(green+"Keyword:"+reset+bold+" %s"+reset+","+green+" Clicks:"+reset+" %d,"+green+" CTR:"+reset+" %.2f,"+green+" Avg. Position:"+reset+" %.2f\n",
+				kwKeywordsNonBranded[i], kwCountClicksNonBranded[i], kwCTRNonBranded[i], kwAvgPositionNonBranded[i])
+		}
+	}
+
+	// Get the last month name
+	htmlLastMonthName := ""
+	if len(startMonthNames) > 0 {
+		htmlLastMonthName = startMonthNames[len(startMonthNames)-1]
+	}
+
+	// HTML content for the winning keyword
+	htmlContent := fmt.Sprintf(`
+<!DOCTYPE html>
+<html>
+<head>
+	<style>
+        body {
+            font-family: 'Arial', sans-serif;
+            margin: 0;
+            padding: 0;
+            display: flex;
+            justify-content: center;
+            align-items: center;
+            height: 100vh;
         }
-		.right-justify {
-            text-align: right;
-            display: block; 
+        .content { 
+            padding: 15px;
+            text-align: left;  
         }
-		.deepskyblue {
+        .blueText {
             color: DeepSkyBlue;
+            font-size: 25px;
+            font-weight: bold;  
         }
-        .darkgrey {
-            color: #00796b;
+        .keyword-font {
+            font-family: 'Arial', sans-serif;
+            font-size: 18px;
+            color: LightSlateGray;
+            line-height: 1.6; 
+        }
+        .header-font {
+            font-family: 'Arial', sans-serif;
+            font-size: 25px;
+            color: Teal;
+            font-weight: bold;
+            line-height: 1.6; 
+        }
+        b {
+            color: #333;
         }
     </style>
 </head>
 <body>
     <div class="content">
-	<span class="header-font right-justify">
-        <span class="deepskyblue">Version:</span>
-        <span class="darkgrey">` + fmt.Sprintf("%s", version) + `</span>
-    </span> 
-	<span class="header-font right-justify">
-        <span class="deepskyblue">Session:</span>
-        <span class="darkgrey">` + fmt.Sprintf("%s", sessionID) + `</span>
+        <span class="header-font">
+        %s
+ </span>
+    <span class="keyword-font">
+        The winning keyword during <b>%s</b> was <span class="blueText">%s</span>. 
+        This keyword generated <b>%s</b> clicks which is <b>%d%%</b> more clicks than the second placed keyword  <b>%s</b>. The click-through rate for the winning keyword was <b>%.2f%%</b> 
+        from an average position of <b>%.2f</b>.
     </span>
-	<span class="header-font">The following insights are based on the previous ` + fmt.Sprintf("%d", noOfMonths) + ` months.</span>
-		<span class="header-font">Access the Botify project <a href="` + projectURL + `" target="_blank">here</a></span> (` + organization + `)
-        <br>
-        <br>
-        <span class="header-font">Click the chart title to view the chart in a new window.</span>
-        <br>
-		<br>
-			<span class="header-font">This broadsheet for <strong style="color: DeepSkyBlue;">` + organization + "/" + project + `</strong> was generated on ` + currentDate + ` at ` + currentTimeFormatted + `</span>
-		<br>
-		` + htmlDataIssue + `
-    </div>
+	</div>
 </body>
 </html>
-`
-	// Save the HTML to a file
-	saveHTML(htmlContent, "/go_seo_Header.html")
-}
-
-// If data issue have been detected generate the HTML to include in the header
-func generateDataIssueHTML(revenueDataIssue bool, visitsDataIssue bool, ordersDataIssue bool) string {
-
-	htmlDataIssue := "<br>"
-
-	htmlDataIssue += "<span style=\"color: red;\">Warning: Less than 12 months valid data has been found for "
+`, brandedHeader, htmlLastMonthName, htmlKeyword, htmlClicks, htmlClickGap, htmlSecondPlaceKW, htmlCTR, htmlAvgPosition)
 
-	// Check which variables are true and include them in the HTML content
-	if revenueDataIssue {
-		htmlDataIssue += "revenue, "
-	}
-	if visitsDataIssue {
-		htmlDataIssue += "visits, "
-	}
-	if ordersDataIssue {
-		htmlDataIssue += "orders, "
+	if brandedMode {
+		htmlFileName = "/go_seo_WinningKeywordBranded.html"
+	} else {
+		htmlFileName = "/go_seo_WinningKeywordNonBranded.html"
 	}
 
-	htmlDataIssue += "</br>"
-
-	// Trim the trailing comma and space
-	htmlDataIssue = htmlDataIssue[:len(htmlDataIssue)-2] + ". </span>"
-
-	return htmlDataIssue
+	// Save the HTML to a file
+	saveHTML(htmlContent, htmlFileName)
 }
 
-// CMGR Badges
-func badgeCMGR() {
-
-	cmgrRevenue32 := float32(cmgrRevenue)
-	cmgrVisits32 := float32(cmgrVisits)
-	cmgrVisitValue32 := float32(cmgrVisitValue)
-	cmgrOrderValue32 := float32(cmgrOrderValue)
-	cmgrOrderValueValue32 := float32(cmgrOrderValueValue)
-
-	// Generate the badges
-	insightsCacheFolderTrimmed := strings.TrimPrefix(insightsCacheFolder, ".")
+// Generate the HTML for the table
+func generateHTMLDetailedKPIInsightsTable(data [][]string) string {
 
-	// URL to full screen badge display
-	clickURL := protocol + "://" + fullHost + insightsCacheFolderTrimmed + "/go_seo_CMGRRevenue.html"
-	generateLiquidBadge("Revenue", cmgrRevenue32, clickURL, "Revenue growth over the period")
+	unit, unitPlural := granularityUnit()
 
-	clickURL = protocol + "://" + fullHost + insightsCacheFolderTrimmed + "/go_seo_CMGRVisits.html"
-	generateLiquidBadge("Visits", cmgrVisits32, clickURL, "Visits growth")
+	htmlContent := `
+<!DOCTYPE html>
+<html>
+<head>
+<style>
+    body {
+        font-family: Arial, sans-serif;
+    }
+    table {
+        width: 100%;
+        border-collapse: collapse;
+        margin: 10px 0;
+        font-size: 18px;
+        text-align: left;
+    }
+    th, td {
+        padding: 12px;
+        border-bottom: 1px solid #ddd;
+    }
+    th {
+        background-color: #f2f2f2;
+    }
+    th.title {
+        color: gray;
+        font-weight: bold;
+    }
+    td {
+        color: dimgray;
+    }
+    tr:nth-child(even) {
+        background-color: #f9f9f9;
+    }
+    tr:hover {
+        background-color: deepskyblue;
+    }
+    h2 {
+        color: dimgray;
+        margin-bottom: 20px;
+    }
+    h3 {
+        color: gray;
+        margin-bottom: 13px;
+    }
+</style>
+</head>
+<body style="min-height: 10vh;">
+    <table>
+        <thead>
+            <tr>
+                <th class="title" style="color: DeepSkyBlue;">` + strings.ToUpper(unit[:1]) + unit[1:] + `</th>
+				<th class="title" style="color: DeepSkyBlue;">Order volume</th>
+                <th class="title" style="color: DeepSkyBlue;">Revenue</th>
+                <th class="title" style="color: DeepSkyBlue;">Order value</th>
+                <th class="title" style="color: DeepSkyBlue;">No. of visits</th>
+                <th class="title" style="color: DeepSkyBlue;">Revenue per visit</th>
+                <th class="title" style="color: DeepSkyBlue;">Visits per order</th>
+                <th class="title" style="color: DeepSkyBlue;">Impressions</th>
+                <th class="title" style="color: DeepSkyBlue;">Clicks</th>
+                <th class="title" style="color: DeepSkyBlue;">Avg. position</th>
+                <th class="title" style="color: DeepSkyBlue;">Avg. CTR</th>
+                <th class="title" style="color: DeepSkyBlue;">Revenue YoY %</th>
+                <th class="title" style="color: DeepSkyBlue;">Visits YoY %</th>
+                <th class="title" style="color: DeepSkyBlue;">Orders YoY %</th>
+                <th class="title" style="color: DeepSkyBlue;">Order value YoY %</th>
+                <th class="title" style="color: DeepSkyBlue;">RPV YoY %</th>
+            </tr>
+        </thead>
+        <tbody>`
 
-	clickURL = protocol + "://" + fullHost + insightsCacheFolderTrimmed + "/go_seo_CMGRVisitValue.html"
-	generateLiquidBadge("Visit Value", cmgrVisitValue32, clickURL, "Visit value (RPV)")
+	// Title
+	htmlContent += fmt.Sprintf("<h2>\n\nOrganic Business insights for the previous %d %s</h2>", noOfMonths, unitPlural)
+	// Non brand message
+	htmlContent += fmt.Sprintf("<h3>\n\nNote: Impressions, Clicks, Avg. position & Avg. CTR are all Non-Branded traffic</h3>")
 
-	clickURL = protocol + "://" + fullHost + insightsCacheFolderTrimmed + "/go_seo_CMGROrders.html"
-	generateLiquidBadge("Orders", cmgrOrderValue32, clickURL, "Order volume")
+	// Insert the KPIs
+	for _, row := range data {
+		htmlContent += "<tr>"
+		for _, cell := range row {
+			htmlContent += "<td>" + cell + "</td>"
+		}
+		htmlContent += "</tr>"
+	}
+	htmlContent += `
+        </tbody>
+    </table>
+</body>
+</html>`
 
-	clickURL = protocol + "://" + fullHost + insightsCacheFolderTrimmed + "/go_seo_CMGROrderValue.html"
-	generateLiquidBadge("Order Value", cmgrOrderValueValue32, clickURL, "Order value (AOV)")
+	return htmlContent
 }
 
-// Total Visits, Orders & Revenue
-func tableVisitsOrdersRevenue() {
+// Generate the HTML for the keywords insights
+func textDetailedKeywordsInsights(brandedMode bool) {
 
 	formatInteger := message.NewPrinter(language.English)
 
-	totalVisitsFormatted := formatInteger.Sprintf("%d", metricsVisitsOrganic)
-	totalOrdersFormatted := formatInteger.Sprintf("%d", metricsOrdersOrganic)
-	totalRevenueFormatted := formatInteger.Sprintf("%d", metricsRevenueOrganic)
-
-	totalAverageOrderValueFormatted := formatInteger.Sprintf("%d", totalAverageOrderValueOrganic)
-	totalAverageVisitsPerOrderFormatted := formatInteger.Sprintf("%d", totalAverageVisitsPerOrder)
-	totalAverageVisitValueFormatted := fmt.Sprintf("%.2f", totalAverageVisitValue)
-
 	htmlContent := `
 <!DOCTYPE html>
 <html>
 <head>
-    <style>
+	<style>
         body {
             font-family: Arial, sans-serif;
-            color: #333;
-            display: flex;
-            justify-content: center;
-            align-items: center;
             margin: 0;
-            height: 100vh;
-            background-color: #f4f4f4; 
-        }
-        .container {
-            display: flex;
-            flex-direction: column; 
-            justify-content: center;
-            align-items: center;
-            width: 100%;
-            height: 100%;
-            padding: 20px;
-            box-sizing: border-box;
-        }
-        .header {
-            font-size: 30px;
-            font-weight: bold;
-            color: Grey;
-            margin-bottom: 20px;
-            text-align: center;
+            padding: 0;
         }
-        .wrapper {
-            width: 100%;
-            max-width: 1200px;
-            padding: 20px;
-            border-radius: 8px;
-            background-color: #fff; 
-            box-shadow: 0 4px 8px rgba(0,0,0,0.1); 
+        .table-container {
+            overflow: auto;
+            height: 500px; 
+            border: 2px solid transparent; 
+            border-radius: 16px; 
+            margin: 20px; 
         }
         table {
             width: 100%;
-            border-collapse: collapse;
-            text-align: center;
+            border-collapse: collapse; 
+            color: DimGray;
+            font-size: 17px;
+            text-align: left;
+            border-radius: 16px; 
         }
         th, td {
-            font-size: 35px;
-            padding: 10px;
+            padding: 8px;
         }
         th {
-            color: #555;
-            font-weight: 600;
+            background-color: White;
+            color: deepskyblue;
+            position: sticky; 
+            top: 0; 
+            z-index: 10; /* Ensure header stays on top */
         }
         td {
-            color: #00796b;
-        }
-    </style>
-</head>
-<body>
-    <div class="container">
-        <div class="wrapper">
-            <table>
-                <tr>
-					<th style="color: teal; padding-right: 120px;">Organic</th>
-                    <th style="color: deepskyblue;">Revenue</th>
-                    <th style="color: deepskyblue;">Visits</th>
-                    <th style="color: deepskyblue;">RPV</th>
-                </tr>
-                <tr>
-                    <td>` + fmt.Sprintf("%s", "") + `</td>
-                    <td>` + fmt.Sprintf("%s%s", currencySymbol, totalRevenueFormatted) + `</td>
-                    <td>` + fmt.Sprintf("%s", totalVisitsFormatted) + `</td>
-                    <td>` + fmt.Sprintf("%s", totalAverageVisitValueFormatted) + `</td>
-                </tr>
-                <tr>
-                    <td>` + fmt.Sprintf("%s", "") + `</td>
-                    <th style="color: deepskyblue;">Orders</th>
-                    <th style="color: deepskyblue;">AOV</th>
-                    <th style="color: deepskyblue;">Visits Per Order</th>
-                </tr>
-                <tr>
-                    <td>` + fmt.Sprintf("%s", "") + `</td>
-                    <td>` + fmt.Sprintf("%s", totalOrdersFormatted) + `</td>
-                    <td>` + fmt.Sprintf("%s%s", currencySymbol, totalAverageOrderValueFormatted) + `</td>
-                    <td>` + fmt.Sprintf("%s", totalAverageVisitsPerOrderFormatted) + `</td>
-                </tr>
-            </table>
-        </div>
-    </div>
-</body>
-</html>
-`
-	// Save the HTML to a file
-	saveHTML(htmlContent, "/go_seo_TotalsOrganic.html")
-}
-
-// Non-branded performance
-func tableNonBrandedPerformance() {
-
-	formatInteger := message.NewPrinter(language.English)
-
-	scImpressionsTotalFormatted := formatInteger.Sprintf("%d", scImpressionsTotal)
-	scClicksTotalFormatted := formatInteger.Sprintf("%d", scClicksTotal)
-	scAvgPositionTotalFormatted := fmt.Sprintf("%.2f", scAvgPositionTotal)
-	scCTRTotalFormatted := fmt.Sprintf("%.2f", scCTRTotal)
-
-	htmlContent := `
-<!DOCTYPE html>
-<html>
-<head>
-    <style>
-        body {
-            font-family: Arial, sans-serif;
-            color: #333;
-            display: flex;
-            justify-content: center;
-            align-items: center;
-            margin: 0;
-            height: 100vh;
-            background-color: #f4f4f4; 
-        }
-        .container {
-            display: flex;
-            flex-direction: column; 
-            justify-content: center;
-            align-items: center;
-            width: 100%;
-            height: 100%;
-            padding: 20px;
-            box-sizing: border-box;
+            border-bottom: 1px solid transparent; 
         }
-        .header {
-            font-size: 30px;
-            font-weight: bold;
-            color: Grey;
-            margin-bottom: 20px;
-            text-align: center;
+        tr:nth-child(odd) {
+            background-color: #f9f9f9;
         }
-        .wrapper {
-            width: 100%;
-            max-width: 1200px;
-            padding: 20px;
-            border-radius: 8px;
-            background-color: #fff; 
-            box-shadow: 0 4px 8px rgba(0,0,0,0.1); 
+        tr:hover {
+            background-color: DeepSkyBlue;
+            color: white; 
         }
-        table {
-            width: 100%;
-            border-collapse: collapse;
-            text-align: center;
+        /* Apply rounded corners to header and footer rows */
+        thead th:first-child {
+            border-top-left-radius: 16px;
         }
-        th, td {
-            font-size: 35px;
-            padding: 10px;
+        thead th:last-child {
+            border-top-right-radius: 16px;
         }
-        th {
-            color: #555;
-            font-weight: 600;
+        tbody tr:last-child td:first-child {
+            border-bottom-left-radius: 16px;
         }
-        td {
-            color: #00796b;
+        tbody tr:last-child td:last-child {
+            border-bottom-right-radius: 16px;
         }
     </style>
 </head>
 <body>
-    <div class="container">
-        <div class="wrapper">
-            <table>
-                <tr>                            
-					<th style="color: teal;">Non Brand Keyword Performance</th>
-                    <th style="color: deepskyblue;">Impressions</th>
-                    <th style="color: deepskyblue;">Clicks</th>
-                    <th style="color: deepskyblue;">Avg. Position</th>
-                    <th style="color: deepskyblue;">Avg. CTR</th>
-                </tr>
-				<tr>
-                    <td>` + fmt.Sprintf("%s", "") + `</td>
-                    <td>` + fmt.Sprintf("%s", scImpressionsTotalFormatted) + `</td>
-                    <td>` + fmt.Sprintf("%s", scClicksTotalFormatted) + `</td>
-                    <td>` + fmt.Sprintf("%s", scAvgPositionTotalFormatted) + `</td>
-                    <td>` + fmt.Sprintf("%s", scCTRTotalFormatted) + `</td>
-                </tr>
-            </table>
-        </div>
-    </div>
-</body>
-</html>
-`
+    <table>
+        <tr>
+            <th>Keyword</th>
+            <th>No. of Clicks</th>
+            <th>CTR</th>
+            <th>Average Position</th>
+        </tr>`
+
+	// Branded keywords details
+	if brandedMode {
+		for i := 0; i < noKeywordsInCloud; i++ {
+			kwCountClicksFormatted := formatInteger.Sprintf("%d", kwCountClicks[i])
+			htmlContent += fmt.Sprintf("<tr>\n"+
+				"    <td>%s</td>\n"+
+				"    <td>%s</td>\n"+
+				"    <td>%.2f%%</td>\n"+
+				"    <td>%.2f</td>\n"+
+				"</tr>\n",
+				kwKeywords[i],
+				kwCountClicksFormatted,
+				kwMetricsCTR[i],
+				kwMetricsAvgPosition[i])
+		}
+	}
+
+	// Non branded keywords details
+	if !brandedMode {
+		for i := 0; i < noKeywordsInCloud; i++ {
+			kwCountClicksFormattedNonBranded := formatInteger.Sprintf("%d", kwCountClicksNonBranded[i])
+			htmlContent += fmt.Sprintf("<tr>\n"+
+				"    <td>%s</td>\n"+
+				"    <td>%s</td>\n"+
+				"    <td>%.2f%%</td>\n"+
+				"    <td>%.2f</td>\n"+
+				"</tr>\n",
+				kwKeywordsNonBranded[i],
+				kwCountClicksFormattedNonBranded,
+				kwCTRNonBranded[i],
+				kwAvgPositionNonBranded[i])
+		}
+	}
+
+	htmlContent += fmt.Sprintf("</table>\n")
+	htmlContent += fmt.Sprintf("</body>\n")
+	htmlContent += fmt.Sprintf("</html>\n")
+
 	// Save the HTML to a file
-	saveHTML(htmlContent, "/go_seo_TotalsNonBrandedPerformance.html")
+	// Branded keywords details
+	if brandedMode {
+		saveHTML(htmlContent, "/go_seo_KeywordBrandedInsights.html")
+	}
+	// Branded keywords details
+	if !brandedMode {
+		saveHTML(htmlContent, "/go_seo_KeywordNonBrandedInsights.html")
+	}
 }
 
-// Bar chart. Revenue and Visits
-func barRevenueVisits() {
+// generate the slice containing the projected revenue data
+func forecastDataCompute() {
 
-	// Generate the URL to the chart. Used to display the chart full screen when the header is clicked
-	insightsCacheFolderTrimmed := strings.TrimPrefix(insightsCacheFolder, ".")
-	clickURL := protocol + "://" + fullHost + insightsCacheFolderTrimmed + "/go_seo_RevenueVisitsBar.html"
+	// First create a slice containing the visit ranges
+	numElements := forecastMaxVisits/forecastIncrement + 1
+	forecastVisitIncrements = make([]int, numElements)
+	forecastVisitIncrementsString = make([]string, numElements)
 
-	bar := charts.NewBar()
-	bar.SetGlobalOptions(
-		charts.WithTitleOpts(opts.Title{
-			Title:    "Revenue & visits (click for full screen)",
-			Subtitle: "How many organic visits does the site attract and what is the generated revenue from those visits?",
-			Link:     clickURL,
-		}),
-		charts.WithLegendOpts(opts.Legend{Right: "80px"}),
-		charts.WithDataZoomOpts(opts.DataZoom{
-			Type:  "slider",
-			Start: 1,
-			End:   100,
-		}),
-		charts.WithInitializationOpts(opts.Initialization{
-			Width:     chartDefaultWidth,
-			Height:    chartDefaultHeight,
-			PageTitle: "Revenue & visits",
-		}),
-		charts.WithColorsOpts(opts.Colors{kpiColourVisits, kpiColourRevenue}),
-	)
+	// Populate the slice with the visit ranges
+	formatInteger := message.NewPrinter(language.English)
 
-	barDataRevenue := generateBarItems(seoRevenue)
-	barDataVisits := generateBarItems(seoVisits)
+	for i := 0; i < numElements; i++ {
+		forecastVisitIncrements[i] = i * forecastIncrement
+		// Create a formatted String version for use in the chart XAxis
+		forecastVisitIncrementsString[i] = formatInteger.Sprintf("%d", forecastVisitIncrements[i])
+	}
 
-	var seriesWithCurrency = "Revenue (" + currencySymbol + ")"
+	// Create a slice to hold the forecast revenue values
+	forecastRevenue = make([]int, numElements)
+	for i := 0; i < numElements; i++ {
+		if totalAverageVisitsPerOrder != 0 {
+			forecastRevenue[i] = int(float64(forecastVisitIncrements[i]) / float64(totalAverageVisitsPerOrder) * float64(totalAverageOrderValueOrganic))
+		} else {
+			forecastRevenue[i] = 0
+		}
+	}
+}
 
-	bar.SetXAxis(startMonthNames).
-		AddSeries(seriesWithCurrency, barDataRevenue).
-		AddSeries("Visits", barDataVisits).
-		SetSeriesOptions(
-			charts.WithMarkPointNameTypeItemOpts(
-				opts.MarkPointNameTypeItem{Name: "Highest", Type: "max", ItemStyle: &opts.ItemStyle{Color: "rgb(144, 238, 144)"}},
-				opts.MarkPointNameTypeItem{Name: "Lowest", Type: "min", ItemStyle: &opts.ItemStyle{Color: "rgb(255, 55, 55)"}},
-			),
-			charts.WithMarkPointStyleOpts(
-				opts.MarkPointStyle{
-					SymbolSize: 90,
-				}),
-		)
+// Seasonal forecast of the KPI history (Holt-Winters, additive seasonality)
+// Holt's linear trend is used when less than two full seasons of history are available
+func seasonalForecastCompute() {
 
-	var f *os.File
-	var err error
+	seasonalForecasts = nil
 
-	// Assign 'f' here
-	f, err = os.Create(insightsCacheFolder + "/go_seo_RevenueVisitsBar.html")
-	if err != nil {
-		fmt.Printf(red+"Error. barRevenueVisits. Cannot create go_seo_RevenueVisitsBar.html: %v\n"+reset, err)
+	names, starts, history := forecastHistory()
+	if len(starts) < 3 {
+		fmt.Println(yellow + "Not enough history to compute the seasonal forecast" + reset)
 		return
 	}
 
-	// Render the chart to the file
-	_ = bar.Render(f)
+	// Names of the forecast periods
+	var forecastNames []string
+	for h := 1; h <= forecastPeriods; h++ {
+		forecastNames = append(forecastNames, periodLabel(nextPeriodStart(starts[len(starts)-1], h)))
+	}
+
+	for _, kpi := range []string{"Revenue", "Visits", "Orders"} {
+		forecast := holtWintersForecast(history[kpi], seasonLength(), forecastPeriods)
+		forecast.KPI = kpi
+		forecast.HistoryNames = names
+		forecast.Names = forecastNames
+		seasonalForecasts = append(seasonalForecasts, forecast)
+		fmt.Printf(green+"%s forecast (%s): %.0f in %s\n"+reset, kpi, forecast.Method, forecast.Forecast[len(forecast.Forecast)-1], forecastNames[len(forecastNames)-1])
+	}
 }
 
-// Visits per order line chart
-func lineVisitsPerOrder() {
+// The longest run of consecutive periods ending with the last reporting period
+// The reporting periods are completed with the same periods last year (period_1)
+func forecastHistory() ([]string, []time.Time, map[string][]float64) {
 
-	// Generate the URL to the chart. Used to display the chart full screen when the header is clicked
-	insightsCacheFolderTrimmed := strings.TrimPrefix(insightsCacheFolder, ".")
-	clickURL := protocol + "://" + fullHost + insightsCacheFolderTrimmed + "/go_seo_VisitsPerOrderLine.html"
+	type historyPoint struct {
+		start                   time.Time
+		revenue, visits, orders float64
+	}
+	points := make(map[string]historyPoint)
 
-	line := charts.NewLine()
-	line.SetGlobalOptions(
-		charts.WithTitleOpts(opts.Title{
-			Title:    "Visits per order (click for full screen)",
-			Subtitle: "High number of visits per order indicates poor quality traffic or conversion inefficiency.",
-			Link:     clickURL,
-		}),
-		charts.WithDataZoomOpts(opts.DataZoom{
-			Type:  "slider",
-			Start: 0,
-			End:   100,
-		}),
-		charts.WithInitializationOpts(opts.Initialization{
-			Width:     chartDefaultWidth,
-			Height:    chartDefaultHeight,
-			PageTitle: "Average visits per order",
-		}),
+	addPoint := func(date string, revenue, visits, orders float64) {
+		start, err := time.Parse("20060102", date)
+		if err != nil {
+			fmt.Println(red+"Error. forecastHistory. Cannot parse the date:"+reset, err)
+			return
+		}
+		start = periodStart(start)
+		points[start.Format("20060102")] = historyPoint{start, revenue, visits, orders}
+	}
 
-		charts.WithColorsOpts(opts.Colors{kpiColourVisitsPerOrder}),
-		// disable show the legend
-		charts.WithLegendOpts(opts.Legend{Show: opts.Bool(false)}),
-	)
+	for _, startMonthDate := range startMonthDates {
+		if lastYear, ok := lastYearByMonth[startMonthDate]; ok && lastYear.RevenueStatus == "success" {
+			addPoint(lastYearDate(startMonthDate), float64(lastYear.Revenue), float64(lastYear.Visits), float64(lastYear.Orders))
+		}
+	}
+	for i, startMonthDate := range startMonthDates {
+		addPoint(startMonthDate, float64(seoRevenue[i]), float64(seoVisits[i]), float64(seoOrders[i]))
+	}
 
-	lineVisitsPerOrderValue := generateLineItems(seoVisitsPerOrder)
+	history := map[string][]float64{}
+	if len(startMonthDates) == 0 {
+		return nil, nil, history
+	}
 
-	line.SetXAxis(startMonthNames).AddSeries("Visits per order", lineVisitsPerOrderValue).SetSeriesOptions(
-		charts.WithAreaStyleOpts(opts.AreaStyle{
-			Color: "lightSkyBlue",
-		}),
-		charts.WithLineChartOpts(opts.LineChart{
-			Smooth: opts.Bool(true),
-		}),
-		charts.WithMarkPointNameTypeItemOpts(
-			opts.MarkPointNameTypeItem{Name: "Highest No. of visits", Type: "max", ItemStyle: &opts.ItemStyle{Color: "rgb(255, 55, 55)"}},
-			opts.MarkPointNameTypeItem{Name: "Lowest  No. of visits", Type: "min", ItemStyle: &opts.ItemStyle{Color: "rgb(144, 238, 144)"}},
-		),
-		charts.WithMarkPointStyleOpts(
-			opts.MarkPointStyle{
-				// the size of the markLine symbol
-				SymbolSize: 90,
-			}),
-	)
+	// Walk back from the last reporting period
+	lastStart, _ := time.Parse("20060102", startMonthDates[len(startMonthDates)-1])
+	var run []historyPoint
+	for start := periodStart(lastStart); ; start = nextPeriodStart(start, -1) {
+		point, ok := points[start.Format("20060102")]
+		if !ok {
+			break
+		}
+		run = append([]historyPoint{point}, run...)
+	}
 
-	f, _ := os.Create(insightsCacheFolder + "/go_seo_VisitsPerOrderLine.html")
+	var names []string
+	var starts []time.Time
+	for _, point := range run {
+		names = append(names, periodLabel(point.start))
+		starts = append(starts, point.start)
+		history["Revenue"] = append(history["Revenue"], point.revenue)
+		history["Visits"] = append(history["Visits"], point.visits)
+		history["Orders"] = append(history["Orders"], point.orders)
+	}
 
-	_ = line.Render(f)
+	return names, starts, history
 }
 
-// Function to generate line chart items from an array of float64 values
-func generateLineItems(visitsPerOrder []int) []opts.LineData {
+// The start of the month, ISO week or day containing the date
+func periodStart(date time.Time) time.Time {
 
-	items := make([]opts.LineData, len(visitsPerOrder))
-	for i, val := range visitsPerOrder {
-		items[i] = opts.LineData{Value: val}
+	switch activePeriod.Granularity {
+	case "weekly":
+		return date.AddDate(0, 0, -(int(date.Weekday())+6)%7)
+	case "daily":
+		return date
 	}
-	return items
+	return time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
 }
 
-// Visit value bar chart
-func barVisitValue() {
+// The start of the period n periods after (or before) the period starting on start
+func nextPeriodStart(start time.Time, n int) time.Time {
 
-	// Generate the URL to the chart. Used to display the chart full screen when the header is clicked
-	insightsCacheFolderTrimmed := strings.TrimPrefix(insightsCacheFolder, ".")
-	clickURL := protocol + "://" + fullHost + insightsCacheFolderTrimmed + "/go_seo_VisitsValueBar.html"
+	switch activePeriod.Granularity {
+	case "weekly":
+		return start.AddDate(0, 0, 7*n)
+	case "daily":
+		return start.AddDate(0, 0, n)
+	}
+	return start.AddDate(0, n, 0)
+}
 
-	bar := charts.NewBar()
-	bar.SetGlobalOptions(charts.WithTitleOpts(opts.Title{
-		Title:    "Revenue per visit - RPV (click for full screen)",
-		Subtitle: "A high organic visit value is a strong indicator of the effectiveness and profitability of the site's organic traffic.",
-		Link:     clickURL,
-	}),
-		charts.WithLegendOpts(opts.Legend{Right: "80px"}),
-		charts.WithDataZoomOpts(opts.DataZoom{
-			Type:  "slider",
-			Start: 0,
-			End:   100,
-		}),
-		charts.WithInitializationOpts(opts.Initialization{
-			Width:     chartDefaultWidth,
-			Height:    chartDefaultHeight,
-			PageTitle: "Organic visit value",
-		}),
-		charts.WithColorsOpts(opts.Colors{kpiColourOrganicVisitValue}),
-		// disable show the legend
-		charts.WithLegendOpts(opts.Legend{Show: opts.Bool(false)}),
-	)
+// The number of periods in a season. A year of months, a year of weeks or a week of days
+func seasonLength() int {
 
-	barDataVisitValue := generateBarItemsFloat(seoVisitValue)
+	switch activePeriod.Granularity {
+	case "weekly":
+		return 52
+	case "daily":
+		return 7
+	}
+	return 12
+}
 
-	bar.SetXAxis(startMonthNames).
-		AddSeries("Organic visit value", barDataVisitValue).
-		SetSeriesOptions(charts.WithMarkLineNameTypeItemOpts(
-			opts.MarkLineNameTypeItem{Name: "Lowest visit value", Type: "min"},
-			opts.MarkLineNameTypeItem{Name: "Highest visit value", Type: "max"},
-			opts.MarkLineNameTypeItem{Name: "Average visit value", Type: "average"},
-		),
-			charts.WithMarkLineStyleOpts(
-				opts.MarkLineStyle{
-					Label:     &opts.Label{FontSize: 15},
-					LineStyle: &opts.LineStyle{Color: "rgb(255, 128, 0)", Width: 3, Opacity: .7, Type: "dotted"},
-				},
-			),
-		)
+// Holt-Winters (additive) forecast for the next horizon periods. The smoothing parameters minimise the one-step-ahead squared errors
+// The prediction intervals are based on the variance of the one-step-ahead errors (Hyndman & Athanasopoulos, class 1 ETS models)
+func holtWintersForecast(values []float64, season int, horizon int) seasonalForecast {
 
-	f, _ := os.Create(insightsCacheFolder + "/go_seo_VisitsValueBar.html")
+	forecast := seasonalForecast{History: values}
 
-	_ = bar.Render(f)
-}
+	// Holt's linear trend without seasonality when there are less than two full seasons
+	seasonal := len(values) >= 2*season
+	gammas := []float64{0}
+	forecast.Method = "Holt's linear trend"
+	if seasonal {
+		gammas = smoothingGrid
+		forecast.Method = "Holt-Winters"
+	} else {
+		season = 0
+	}
 
-// Order volume bar chart
-func barOrders() {
+	bestSSE := math.Inf(1)
+	var bestAlpha, bestBeta, bestGamma float64
+	for _, alpha := range smoothingGrid {
+		for _, beta := range smoothingGrid {
+			for _, gamma := range gammas {
+				sse, _, _, _, _ := holtWintersFit(values, season, alpha, beta, gamma)
+				if sse < bestSSE {
+					bestSSE, bestAlpha, bestBeta, bestGamma = sse, alpha, beta, gamma
+				}
+			}
+		}
+	}
 
-	// Generate the URL to the chart. Used to display the chart full screen when the header is clicked
-	insightsCacheFolderTrimmed := strings.TrimPrefix(insightsCacheFolder, ".")
-	clickURL := protocol + "://" + fullHost + insightsCacheFolderTrimmed + "/go_seo_OrdersBar.html"
+	_, errorCount, level, trend, seasonals := holtWintersFit(values, season, bestAlpha, bestBeta, bestGamma)
+	sigma := 0.0
+	if errorCount > 0 {
+		sigma = math.Sqrt(bestSSE / float64(errorCount))
+	}
 
-	bar := charts.NewBar()
-	bar.SetGlobalOptions(charts.WithTitleOpts(opts.Title{
-		Title:    "Order volume (click for full screen)",
-		Subtitle: "Order volume placed during an organic visit.",
-		Link:     clickURL,
-	}),
-		charts.WithLegendOpts(opts.Legend{Right: "80px"}),
-		charts.WithDataZoomOpts(opts.DataZoom{
-			Type:  "slider",
-			Start: 1,
-			End:   100,
-		}),
-		charts.WithInitializationOpts(opts.Initialization{
-			Width:     chartDefaultWidth,
-			Height:    chartDefaultHeight,
-			PageTitle: "Order volume",
-		}),
-		charts.WithColorsOpts(opts.Colors{kpiColourNoOfOrders}),
-		// disable show the legend
-		charts.WithLegendOpts(opts.Legend{Show: opts.Bool(false)}),
-	)
+	// Sum of the squared error weights for the h-step variance
+	weights := 0.0
+	for h := 1; h <= horizon; h++ {
+		point := level + float64(h)*trend
+		if seasonal {
+			point += seasonals[len(seasonals)-season+(h-1)%season]
+		}
+		se := sigma * math.Sqrt(1+weights)
 
-	barDataOrders := generateBarItems(seoOrders)
+		forecast.Forecast = append(forecast.Forecast, math.Max(point, 0))
+		forecast.Lower80 = append(forecast.Lower80, math.Max(point-z80*se, 0))
+		forecast.Upper80 = append(forecast.Upper80, math.Max(point+z80*se, 0))
+		forecast.Lower95 = append(forecast.Lower95, math.Max(point-z95*se, 0))
+		forecast.Upper95 = append(forecast.Upper95, math.Max(point+z95*se, 0))
 
-	bar.SetXAxis(startMonthNames).
-		AddSeries("Orders", barDataOrders).
-		SetSeriesOptions(charts.WithMarkLineNameTypeItemOpts(
-			opts.MarkLineNameTypeItem{Name: "Lowest No. orders", Type: "min"},
-			opts.MarkLineNameTypeItem{Name: "Highest No. orders", Type: "max"},
-			opts.MarkLineNameTypeItem{Name: "Average No. orders", Type: "average"},
-		),
-			charts.WithMarkLineStyleOpts(
-				opts.MarkLineStyle{
-					Label:     &opts.Label{FontSize: 15},
-					LineStyle: &opts.LineStyle{Color: "rgb(255, 128, 0)", Width: 3, Opacity: .7, Type: "dotted"},
-				},
-			),
-		)
+		weight := bestAlpha * (1 + float64(h)*bestBeta)
+		if seasonal && h%season == 0 {
+			weight += bestGamma * (1 - bestAlpha)
+		}
+		weights += weight * weight
+	}
+
+	return forecast
+}
 
-	f, _ := os.Create(insightsCacheFolder + "/go_seo_OrdersBar.html")
+// Fit the Holt-Winters model. Returns the sum of the squared one-step-ahead errors, the number of errors, the final level, trend & seasonal components
+// A season of 0 fits Holt's linear trend
+func holtWintersFit(values []float64, season int, alpha, beta, gamma float64) (float64, int, float64, float64, []float64) {
 
-	_ = bar.Render(f)
+	var level, trend float64
+	var seasonals []float64
+	first := 1
+
+	if season > 0 {
+		// The first season initialises the level & the seasonal components, the second season the trend
+		var firstMean, secondMean float64
+		for i := 0; i < season; i++ {
+			firstMean += values[i] / float64(season)
+			secondMean += values[season+i] / float64(season)
+		}
+		// The seasonal components are detrended. The first season's mean is its level at mid-season, the level is moved to the end of the season
+		trend = (secondMean - firstMean) / float64(season)
+		middle := float64(season-1) / 2
+		for i := 0; i < season; i++ {
+			seasonals = append(seasonals, values[i]-(firstMean+(float64(i)-middle)*trend))
+		}
+		level = firstMean + middle*trend
+		first = season
+	} else {
+		// The first two values initialise the level & the trend. The first error is at the third value, the second would always be 0
+		level = values[1]
+		trend = values[1] - values[0]
+		first = 2
+	}
+
+	sse := 0.0
+	errorCount := 0
+	for t := first; t < len(values); t++ {
+		seasonalComponent := 0.0
+		if season > 0 {
+			seasonalComponent = seasonals[t-season]
+		}
+		predicted := level + trend + seasonalComponent
+		sse += (values[t] - predicted) * (values[t] - predicted)
+		errorCount++
+
+		newLevel := alpha*(values[t]-seasonalComponent) + (1-alpha)*(level+trend)
+		trend = beta*(newLevel-level) + (1-beta)*trend
+		if season > 0 {
+			seasonals = append(seasonals, gamma*(values[t]-newLevel)+(1-gamma)*seasonalComponent)
+		}
+		level = newLevel
+	}
+
+	return sse, errorCount, level, trend, seasonals
 }
 
-// Order value bar chart
-func barOrderValue() {
+// Seasonal forecast line charts with the 80% & 95% prediction intervals as bands
+func lineSeasonalForecast() {
 
-	// Generate the URL to the chart. Used to display the chart full screen when the header is clicked
 	insightsCacheFolderTrimmed := strings.TrimPrefix(insightsCacheFolder, ".")
-	clickURL := protocol + "://" + fullHost + insightsCacheFolderTrimmed + "/go_seo_OrderValueBar.html"
 
-	bar := charts.NewBar()
+	colours := map[string]string{"Revenue": kpiColourRevenue, "Visits": kpiColourVisits, "Orders": kpiColourNoOfOrders}
 
-	bar.SetGlobalOptions(charts.WithTitleOpts(opts.Title{
-		Title:    "Order value - AOV (click for full screen)",
-		Subtitle: "The average value of an order placed during a visit from an organic source. A higher value reflects effective SEO strategies driving quality traffic.",
-		Link:     clickURL,
-	}),
-		charts.WithLegendOpts(opts.Legend{Right: "80px"}),
-		charts.WithDataZoomOpts(opts.DataZoom{
-			Type:  "slider",
-			Start: 1,
-			End:   100,
-		}),
-		charts.WithInitializationOpts(opts.Initialization{
-			Width:     chartDefaultWidth,
-			Height:    chartDefaultHeight,
-			PageTitle: "Average order value",
-		}),
-		charts.WithColorsOpts(opts.Colors{kpiColourOrderValue}),
-		// disable show the legend
-		charts.WithLegendOpts(opts.Legend{Show: opts.Bool(false)}),
-	)
+	// The charts are included in the broadsheet. A placeholder is saved when the forecast could not be computed
+	if len(seasonalForecasts) == 0 {
+		for _, kpi := range []string{"Revenue", "Visits", "Orders"} {
+			saveHTML(`<!DOCTYPE html>
+<html>
+<head>
+<style>
+    body {
+        font-family: Arial, sans-serif;
+    }
+    h2 {
+        color: dimgray;
+        margin-bottom: 20px;
+    }
+    h3 {
+        color: gray;
+        margin-bottom: 13px;
+    }
+</style>
+</head>
+<body><h2>`+kpi+` forecast</h2><h3>Not enough history is available to compute the forecast.</h3></body></html>`, "/go_seo_SeasonalForecast"+kpi+".html")
+		}
+		return
+	}
 
-	barDataOrderValue := generateBarItems(seoOrderValue)
+	for _, forecast := range seasonalForecasts {
 
-	bar.SetXAxis(startMonthNames).
-		AddSeries("Order value", barDataOrderValue).
-		SetSeriesOptions(charts.WithMarkLineNameTypeItemOpts(
-			opts.MarkLineNameTypeItem{Name: "Lowest order value", Type: "min"},
-			opts.MarkLineNameTypeItem{Name: "Highest order value", Type: "max"},
-			opts.MarkLineNameTypeItem{Name: "Average order value", Type: "average"},
-		),
-			charts.WithMarkLineStyleOpts(
-				opts.MarkLineStyle{
-					Label:     &opts.Label{FontSize: 15},
-					LineStyle: &opts.LineStyle{Color: "rgb(255, 128, 0)", Width: 3, Opacity: .7, Type: "dotted"},
-				},
-			),
+		// Generate the URL to the chart. Used to display the chart full screen when the header is clicked
+		fileName := "/go_seo_SeasonalForecast" + forecast.KPI + ".html"
+		clickURL := protocol + "://" + fullHost + insightsCacheFolderTrimmed + fileName
+
+		line := charts.NewLine()
+		line.SetGlobalOptions(
+			charts.WithTitleOpts(opts.Title{
+				Title:    forecast.KPI + " forecast (click for full screen)",
+				Subtitle: fmt.Sprintf("%s forecast for the next %d %s with 80%% & 95%% prediction intervals", forecast.Method, len(forecast.Forecast), forecastUnit()),
+				Link:     clickURL,
+			}),
+			charts.WithInitializationOpts(opts.Initialization{
+				Width:     chartDefaultWidth,
+				Height:    chartDefaultHeight,
+				PageTitle: "Organic " + strings.ToLower(forecast.KPI) + " forecast",
+			}),
+			charts.WithTooltipOpts(opts.Tooltip{
+				Show:    opts.Bool(true),
+				Trigger: "axis",
+			}),
+			charts.WithColorsOpts(opts.Colors{colours[forecast.KPI], kpiColourRevenueForecast, "transparent", kpiColourRevenueForecast, "transparent", kpiColourRevenueForecast}),
+			charts.WithLegendOpts(opts.Legend{Show: opts.Bool(true), Right: "1%", Data: []string{"Actual", "Forecast"}}),
 		)
 
-	f, _ := os.Create(insightsCacheFolder + "/go_seo_OrderValueBar.html")
-
-	_ = bar.Render(f)
+		// The forecast & the intervals start from the last actual value
+		lastActual := forecast.History[len(forecast.History)-1]
+		actual := forecastLineItems(forecast.History, nil, 0)
+		predicted := forecastLineItems(forecast.History, forecast.Forecast, lastActual)
+		lower95 := forecastLineItems(forecast.History, forecast.Lower95, lastActual)
+		band95 := forecastLineItems(forecast.History, forecastBand(forecast.Lower95, forecast.Upper95), 0)
+		lower80 := forecastLineItems(forecast.History, forecast.Lower80, lastActual)
+		band80 := forecastLineItems(forecast.History, forecastBand(forecast.Lower80, forecast.Upper80), 0)
+
+		hiddenLine := charts.WithLineStyleOpts(opts.LineStyle{Color: "transparent"})
+
+		line.SetXAxis(append(append([]string{}, forecast.HistoryNames...), forecast.Names...)).
+			AddSeries("Actual", actual, charts.WithLineChartOpts(opts.LineChart{Smooth: opts.Bool(true)})).
+			AddSeries("Forecast", predicted,
+				charts.WithLineChartOpts(opts.LineChart{Smooth: opts.Bool(true)}),
+				charts.WithLineStyleOpts(opts.LineStyle{Type: "dashed"})).
+			AddSeries("95% lower", lower95, charts.WithLineChartOpts(opts.LineChart{Stack: "interval95", ShowSymbol: opts.Bool(false)}), hiddenLine).
+			AddSeries("95% interval", band95, charts.WithLineChartOpts(opts.LineChart{Stack: "interval95", ShowSymbol: opts.Bool(false)}), hiddenLine,
+				charts.WithAreaStyleOpts(opts.AreaStyle{Color: kpiColourRevenueForecast, Opacity: 0.15})).
+			AddSeries("80% lower", lower80, charts.WithLineChartOpts(opts.LineChart{Stack: "interval80", ShowSymbol: opts.Bool(false)}), hiddenLine).
+			AddSeries("80% interval", band80, charts.WithLineChartOpts(opts.LineChart{Stack: "interval80", ShowSymbol: opts.Bool(false)}), hiddenLine,
+				charts.WithAreaStyleOpts(opts.AreaStyle{Color: kpiColourRevenueForecast, Opacity: 0.3}))
+
+		f, _ := os.Create(insightsCacheFolder + fileName)
+
+		_ = line.Render(f)
+	}
 }
 
-// Function to generate BarData items from an array of integers
-func generateBarItems(revenue []int) []opts.BarData {
-
-	items := make([]opts.BarData, len(revenue))
-	for i, val := range revenue {
-		items[i] = opts.BarData{Value: val}
+// Line items for the history followed by the forecast. The forecast series starts with the last actual value
+func forecastLineItems(history []float64, forecast []float64, lastActual float64) []opts.LineData {
+
+	items := make([]opts.LineData, 0, len(history)+len(forecast))
+	for i, value := range history {
+		switch {
+		case forecast == nil:
+			items = append(items, opts.LineData{Value: math.Round(value)})
+		case i == len(history)-1:
+			items = append(items, opts.LineData{Value: math.Round(lastActual)})
+		default:
+			// Missing values are not drawn
+			items = append(items, opts.LineData{Value: "-"})
+		}
+	}
+	for _, value := range forecast {
+		items = append(items, opts.LineData{Value: math.Round(value)})
 	}
 	return items
 }
 
-// Function to generate BarData items from an array of float64 values
-func generateBarItemsFloat(revenue []float64) []opts.BarData {
+// The width of a prediction interval, stacked on the lower bound to draw the band
+func forecastBand(lower []float64, upper []float64) []float64 {
 
-	items := make([]opts.BarData, len(revenue))
-	for i, val := range revenue {
-		items[i] = opts.BarData{Value: val}
+	band := make([]float64, len(lower))
+	for i := range lower {
+		band[i] = upper[i] - lower[i]
 	}
-	return items
+	return band
 }
 
-// CMGR badges
-func generateLiquidBadge(badgeKPI string, badgeKPIValue float32, clickURL string, title string) {
+// The name of the forecast periods
+func forecastUnit() string {
 
-	badgeKPIValueCalc := badgeKPIValue * 100
+	_, unitPlural := granularityUnit()
+	return unitPlural
+}
 
-	subTitle := fmt.Sprintf("Compound growth CMGR. Rounded from %.2f%%", badgeKPIValueCalc)
+// Table containing the seasonal forecast & the prediction intervals
+func tableSeasonalForecast() {
 
-	liquid := charts.NewLiquid()
+	formatInteger := message.NewPrinter(language.English)
 
-	liquid.SetGlobalOptions(
-		charts.WithInitializationOpts(opts.Initialization{
-			PageTitle: badgeKPI + " growth (CMGR)",
-			Width:     badgeDefaultWidth,
-			Height:    badgeDefaultHeight,
-		}),
-		charts.WithTitleOpts(opts.Title{
-			Title:    title,
-			Subtitle: subTitle,
-			Link:     clickURL,
-		}),
-	)
+	htmlContent := `
+<!DOCTYPE html>
+<html>
+<head>
+<style>
+    body {
+        font-family: Arial, sans-serif;
+    }
+    table {
+        width: 100%;
+        border-collapse: collapse;
+        margin: 10px 0;
+        font-size: 14px;
+        text-align: left;
+    }
+    th, td {
+        padding: 8px;
+        border-bottom: 1px solid #ddd;
+    }
+    th {
+        background-color: #f2f2f2;
+    }
+    th.title {
+        color: DeepSkyBlue;
+        font-weight: bold;
+    }
+    td {
+        color: dimgray;
+    }
+    tr:nth-child(even) {
+        background-color: #f9f9f9;
+    }
+    tr:hover {
+        background-color: deepskyblue;
+    }
+    h2 {
+        color: dimgray;
+        margin-bottom: 20px;
+    }
+    h3 {
+        color: gray;
+        margin-bottom: 13px;
+    }
+</style>
+</head>
+<body>`
 
-	liquid.AddSeries("", genLiquidItems([]float32{badgeKPIValue})).
-		SetSeriesOptions(
-			charts.WithLabelOpts(opts.Label{
-				Show: opts.Bool(true),
-			}),
+	if len(seasonalForecasts) == 0 {
+		htmlContent += "<h2>Forecast</h2><h3>Not enough history is available to compute the forecast.</h3></body></html>"
+		saveHTML(htmlContent, "/go_seo_SeasonalForecastTable.html")
+		return
+	}
 
-			charts.WithLiquidChartOpts(opts.LiquidChart{
-				IsWaveAnimation: opts.Bool(true),
-				Shape:           "roundRect",
-			}),
-		)
+	htmlContent += fmt.Sprintf("<h2>Organic forecast for the next %d %s</h2>", len(seasonalForecasts[0].Forecast), forecastUnit())
+	htmlContent += fmt.Sprintf("<h3>%s, based on %d %s of history. The ranges are the 80%% & 95%% prediction intervals</h3>", seasonalForecasts[0].Method, len(seasonalForecasts[0].History), forecastUnit())
+	htmlContent += `
+    <table>
+        <thead>
+            <tr>
+                <th class="title">Period</th>`
+	for _, forecast := range seasonalForecasts {
+		htmlContent += `
+                <th class="title">` + forecast.KPI + `</th>
+                <th class="title">80% interval</th>
+                <th class="title">95% interval</th>`
+	}
+	htmlContent += `
+            </tr>
+        </thead>
+        <tbody>`
 
-	// Removing spaces from badgeKPI to ensure a clean URL for the HTML is generated.
-	badgeKPI = strings.ReplaceAll(badgeKPI, " ", "")
-	badgeFileName := fmt.Sprintf("/go_seo_CMGR%s.html", badgeKPI)
-	f, _ := os.Create(insightsCacheFolder + badgeFileName)
+	for i, name := range seasonalForecasts[0].Names {
+		htmlContent += "<tr><td>" + name + "</td>"
+		for _, forecast := range seasonalForecasts {
+			symbol := ""
+			if forecast.KPI == "Revenue" {
+				symbol = currencySymbol
+			}
+			htmlContent += formatInteger.Sprintf("<td>%s%.0f</td><td>%s%.0f - %s%.0f</td><td>%s%.0f - %s%.0f</td>",
+				symbol, forecast.Forecast[i],
+				symbol, forecast.Lower80[i], symbol, forecast.Upper80[i],
+				symbol, forecast.Lower95[i], symbol, forecast.Upper95[i])
+		}
+		htmlContent += "</tr>"
+	}
 
-	_ = liquid.Render(f)
+	htmlContent += `
+        </tbody>
+    </table>
+</body>
+</html>`
+
+	saveHTML(htmlContent, "/go_seo_SeasonalForecastTable.html")
 }
 
-// Get data for the liquid badge
-func genLiquidItems(data []float32) []opts.LiquidData {
+// Fit the CTR by position curve (CTR = a * position^b) on the non-branded keywords ranked by impressions
+// The clicks & impressions are aggregated by position (rounded), keywords without clicks included. The curve is fitted on the
+// position bins (log-linear least squares, weighted by impressions). The default curve is used when the project data cannot be fitted
+func fitCTRCurve(keywords []scenarioKeyword) {
 
-	items := make([]opts.LiquidData, 0)
-	for i := 0; i < len(data); i++ {
-		items = append(items, opts.LiquidData{Value: data[i]})
-	}
-	return items
-}
+	ctrCurveA, ctrCurveB, ctrCurveFitted = defaultCTRCurveA, defaultCTRCurveB, false
 
-// Branded and non-branded wordclouds
-func wordcloudBrandedNonBranded(brandedMode bool) {
+	binClicks, binImpressions := ctrPositionBins(keywords)
 
-	var clickURL string
-	var pageTitle string
+	var sumW, sumX, sumY, sumXX, sumXY float64
+	bins := 0
+	for position := 1; position <= ctrCurveMaxPosition; position++ {
+		impressions := binImpressions[position]
+		if impressions == 0 {
+			continue
+		}
+		// A bin without clicks has a CTR of 0. Half a click is added so that its log can be taken
+		x := math.Log(float64(position))
+		y := math.Log((binClicks[position] + 0.5) / (impressions + 1))
+		sumW += impressions
+		sumX += impressions * x
+		sumY += impressions * y
+		sumXX += impressions * x * x
+		sumXY += impressions * x * y
+		bins++
+	}
 
-	if brandedMode {
-		// Generate the URL to the chart. Used to display the chart full screen when the header is clicked
-		insightsCacheFolderTrimmed := strings.TrimPrefix(insightsCacheFolder, ".")
-		clickURL = protocol + "://" + fullHost + insightsCacheFolderTrimmed + "/go_seo_WordcloudBranded.html"
-		pageTitle = "Branded wordcloud"
+	denominator := sumW*sumXX - sumX*sumX
+	if bins < 3 || denominator == 0 {
+		fmt.Println(yellow + "Not enough non-branded keywords to fit the CTR curve. The default curve is used" + reset)
+		return
 	}
-	if !brandedMode {
-		// Generate the URL to the chart. Used to display the chart full screen when the header is clicked
-		insightsCacheFolderTrimmed := strings.TrimPrefix(insightsCacheFolder, ".")
-		clickURL = protocol + "://" + fullHost + insightsCacheFolderTrimmed + "/go_seo_WordcloudNonBranded.html"
-		pageTitle = "Non Branded wordcloud"
+
+	b := (sumW*sumXY - sumX*sumY) / denominator
+	a := math.Exp((sumY - b*sumX) / sumW)
+	// The CTR must decrease with the position
+	if b >= 0 || a <= 0 || a > 1 {
+		fmt.Println(yellow + "The fitted CTR curve does not decrease with the position. The default curve is used" + reset)
+		return
 	}
 
-	wordcloud := charts.NewWordCloud()
+	ctrCurveA, ctrCurveB, ctrCurveFitted = a, b, true
+	fmt.Printf(green+"CTR curve: CTR = %.4f * position^%.3f (%d keywords, %d positions)\n"+reset, a, b, len(keywords), bins)
+}
 
-	wordcloud.SetGlobalOptions(
-		charts.WithInitializationOpts(opts.Initialization{
-			Width:     wordcloudDefaultWidth,
-			Height:    wordcloudDefaultHeight,
-			PageTitle: pageTitle,
-		}),
-		charts.WithLegendOpts(opts.Legend{Show: opts.Bool(false)}),
-		charts.WithTitleOpts(opts.Title{
-			Link: clickURL,
-		}))
+// The clicks & impressions of the keywords for each position (rounded), from 1 to ctrCurveMaxPosition
+func ctrPositionBins(keywords []scenarioKeyword) ([]float64, []float64) {
 
-	// Generate the branded wordcloud
-	if brandedMode {
-		wordcloud.AddSeries("Clicks", generateWCData(kwKeywords, kwCountClicks)).
-			SetSeriesOptions(
-				charts.WithWorldCloudChartOpts(
-					opts.WordCloudChart{
-						SizeRange: []float32{10, 150},
-						Shape:     "circle",
-					}),
-			)
+	binClicks := make([]float64, ctrCurveMaxPosition+1)
+	binImpressions := make([]float64, ctrCurveMaxPosition+1)
+	for _, keyword := range keywords {
+		position := int(math.Round(keyword.AvgPosition))
+		if position < 1 || position > ctrCurveMaxPosition {
+			continue
+		}
+		binClicks[position] += float64(keyword.Clicks)
+		binImpressions[position] += float64(keyword.Impressions)
 	}
 
-	// Generate the non-branded wordcloud
-	if !brandedMode {
-		wordcloud.AddSeries("Clicks", generateWCDataNonBranded(kwKeywordsNonBranded, kwCountClicksNonBranded)).
-			SetSeriesOptions(
-				charts.WithWorldCloudChartOpts(
-					opts.WordCloudChart{
-						SizeRange: []float32{10, 150},
-						Shape:     "circle",
-					}),
-			)
+	return binClicks, binImpressions
+}
+
+// The CTR at the position according to the fitted curve
+func ctrAtPosition(position float64) float64 {
+
+	if position < 1 {
+		position = 1
 	}
+	return math.Min(ctrCurveA*math.Pow(position, ctrCurveB), 1)
+}
 
-	if brandedMode {
-		f, _ := os.Create(insightsCacheFolder + "/go_seo_WordcloudBranded.html")
-		_ = wordcloud.Render(f)
+// Estimate the clicks, visits, orders & revenue for the scenario
+// Keywords ranking in positions 4 to 10 move up by positionGain, the impressions of all keywords grow by impressionGain %
+// The clicks of each keyword are its impressions at the CTR of the curve for the new position, so keywords without clicks are valued too
+// One click is counted as one visit
+func runScenario(name string, positionGain int, impressionGain float64) ctrScenario {
+
+	scenario := ctrScenario{Name: name, PositionGain: positionGain, ImpressionGain: impressionGain}
+
+	clicks := 0.0
+	for _, keyword := range scenarioKeywords {
+		newPosition := keyword.AvgPosition
+		if rounded := math.Round(newPosition); rounded >= scenarioMinPosition && rounded <= scenarioMaxPosition && positionGain > 0 {
+			newPosition = math.Max(newPosition-float64(positionGain), 1)
+		}
+		clicks += float64(keyword.Impressions) * ctrAtPosition(newPosition) * (1 + impressionGain/100)
 	}
 
-	if !brandedMode {
-		f, _ := os.Create(insightsCacheFolder + "/go_seo_WordcloudNonBranded.html")
-		_ = wordcloud.Render(f)
-	}
+	scenario.Clicks = clicks
+	scenario.Visits = clicks
+	scenario.Orders = clicks * conversionRatePercent() / 100
+	scenario.Revenue = clicks * totalAverageVisitValue
 
+	return scenario
 }
 
-// Generate the data for the wordcloud - Branded
-func generateWCData(kwKeywords []string, kwCountClicks []int) (items []opts.WordCloudData) {
+// The baseline, the scenario chosen in the form & a range of preset scenarios
+func scenarioPlannerCompute() {
 
-	items = make([]opts.WordCloudData, 0)
-	// Iterate over kwKeywords and kwCountClicks slices starting from index 1
-	// We start at index 1 because the top keyword is generally significantly higher performing than the following keywords and will distort the wordcloud if included
-	for i := 1; i < len(kwKeywords); i++ {
-		// Check if index is valid for kwCountClicks slice
-		if i < len(kwCountClicks) {
-			// Append WordCloudData struct with keyword and corresponding count
-			items = append(items, opts.WordCloudData{Name: kwKeywords[i], Value: kwCountClicks[i]})
-		}
+	scenarios = nil
+	fitCTRCurve(scenarioKeywords)
+
+	scenarios = append(scenarios,
+		runScenario("Current performance", 0, 0),
+		runScenario(fmt.Sprintf("Positions 4-10 up by %d, impressions +%.0f%%", scenarioPositionGain, scenarioImpressionGain), scenarioPositionGain, scenarioImpressionGain))
+
+	for _, positionGain := range []int{1, 2, 3} {
+		scenarios = append(scenarios, runScenario(fmt.Sprintf("Positions 4-10 up by %d", positionGain), positionGain, 0))
+	}
+	for _, impressionGain := range []float64{10, 25, 50} {
+		scenarios = append(scenarios, runScenario(fmt.Sprintf("Impressions +%.0f%%", impressionGain), 0, impressionGain))
 	}
-	return items
 }
 
-// Generate the data for the wordcloud - Non-branded
-func generateWCDataNonBranded(kwKeywordsNonBranded []string, kwCountClicksNonBranded []int) (items []opts.WordCloudData) {
+// The chosen scenario from the form. The defaults are used for empty or invalid values
+func scenarioFromForm(r *http.Request) (int, float64) {
 
-	items = make([]opts.WordCloudData, 0)
-	// Iterate over kwKeywords and kwCountClicks slices starting from index 1
-	// We start at index 1 because the top keyword is generally significantly higher performing than the following keywords and will distort the wordcloud if included
-	for i := 1; i < len(kwKeywordsNonBranded); i++ {
-		// Check if index is valid for kwCountClicks slice
-		if i < len(kwCountClicksNonBranded) {
-			// Append WordCloudData struct with keyword and corresponding count
-			items = append(items, opts.WordCloudData{Name: kwKeywordsNonBranded[i], Value: kwCountClicksNonBranded[i]})
+	positionGain := defaultScenarioPositionGain
+	if value := r.Form.Get("scenarioPositionGain"); value != "" {
+		gain, err := strconv.Atoi(value)
+		if err != nil || gain < 0 || gain > 9 {
+			fmt.Println(yellow + "Warning: the position gain must be between 0 and 9. Will default to " + strconv.Itoa(positionGain) + "." + reset)
+		} else {
+			positionGain = gain
 		}
 	}
-	return items
+
+	impressionGain := defaultScenarioImpressionGain
+	if value := r.Form.Get("scenarioImpressionGain"); value != "" {
+		gain, err := strconv.ParseFloat(value, 64)
+		if err != nil || gain < 0 || gain > 1000 {
+			fmt.Printf(yellow+"Warning: the impression gain must be between 0 and 1000%%. Will default to %.0f%%.\n"+reset, impressionGain)
+		} else {
+			impressionGain = gain
+		}
+	}
+
+	return positionGain, impressionGain
 }
 
-// River chart for revenue and visits
-func riverRevenueVisits() {
+// Observed & fitted CTR by position
+func lineCTRCurve() {
 
 	// Generate the URL to the chart. Used to display the chart full screen when the header is clicked
 	insightsCacheFolderTrimmed := strings.TrimPrefix(insightsCacheFolder, ".")
-	clickURL := protocol + "://" + fullHost + insightsCacheFolderTrimmed + "/go_seo_VisitsRevenueRiver.html"
+	clickURL := protocol + "://" + fullHost + insightsCacheFolderTrimmed + "/go_seo_CTRCurve.html"
 
-	river := charts.NewThemeRiver()
+	subTitle := fmt.Sprintf("Fitted on the top %d non-branded keywords by impressions. CTR = %.3f x position^%.2f", len(scenarioKeywords), ctrCurveA, ctrCurveB)
+	if !ctrCurveFitted {
+		subTitle = "Not enough non-branded keyword data to fit the curve, a typical CTR curve is shown"
+	}
 
-	river.SetGlobalOptions(
+	line := charts.NewLine()
+	line.SetGlobalOptions(
 		charts.WithTitleOpts(opts.Title{
-			Title:    "Revenue & visits (click for full screen)",
-			Subtitle: "Insights into the fluctuations in organic visitors to a site and the corresponding revenue generation.",
-			Link:     clickURL}),
-		charts.WithSingleAxisOpts(opts.SingleAxis{
-			Type:   "time",
-			Bottom: "10%",
+			Title:    "Non-branded CTR by position (click for full screen)",
+			Subtitle: subTitle,
+			Link:     clickURL,
 		}),
-		charts.WithYAxisOpts(opts.YAxis{
-			Type: "time",
+		charts.WithInitializationOpts(opts.Initialization{
+			Width:     chartDefaultWidth,
+			Height:    chartDefaultHeight,
+			PageTitle: "CTR by position",
 		}),
 		charts.WithTooltipOpts(opts.Tooltip{
+			Show:    opts.Bool(true),
 			Trigger: "axis",
 		}),
-		// Increase the canvas size
-		charts.WithInitializationOpts(opts.Initialization{
-			Width:     chartDefaultWidth,
-			Height:    chartDefaultHeight,
-			PageTitle: "Revenue & visits",
+		charts.WithYAxisOpts(opts.YAxis{
+			Name: "CTR %",
 		}),
-
-		charts.WithColorsOpts(opts.Colors{kpiColourVisits, kpiColourRevenue}),
-		// disable show the legend
-		charts.WithLegendOpts(opts.Legend{Show: opts.Bool(false)}),
+		charts.WithColorsOpts(opts.Colors{kpiColourRevenueForecast, kpiColourOrganicVisitValue}),
+		charts.WithLegendOpts(opts.Legend{Show: opts.Bool(true), Right: "1%"}),
 	)
 
-	// Populate ThemeRiverData slice
-	var themeRiverData []opts.ThemeRiverData
-
-	// Add the Revenue data
-	// The date is formatted from YYYYMMDD to YYYY/MM/DD
-	for i, date := range startMonthDates {
-		parsedDate, err := time.Parse("20060102", date)
-		if err != nil {
-			fmt.Printf(red+"Error. riverRevenueVisits. Error parsing date: %v\n"+reset, err)
-			break
-		}
-		formattedDate := parsedDate.Format("2006/01/02")
-		themeRiverData = append(themeRiverData, opts.ThemeRiverData{
-			Date:  formattedDate,
-			Value: float64(seoRevenue[i]),
-			Name:  "Revenue",
-		})
-	}
+	// Observed CTR for each position (rounded), weighted by impressions
+	var positions []string
+	observedClicks, observedImpressions := ctrPositionBins(scenarioKeywords)
 
-	// Add the Visits data
-	// The date is formatted from YYYYMMDD to YYYY/MM/DD
-	for i, date := range startMonthDates {
-		parsedDate, err := time.Parse("20060102", date)
-		if err != nil {
-			fmt.Printf(red+"Error. riverRevenueVisits. Error parsing date: %v\n"+reset, err)
-			break
+	var fitted, observed []opts.LineData
+	for position := 1; position <= ctrCurveMaxPosition; position++ {
+		positions = append(positions, strconv.Itoa(position))
+		fitted = append(fitted, opts.LineData{Value: math.Round(ctrAtPosition(float64(position))*10000) / 100})
+		if observedImpressions[position] > 0 {
+			observed = append(observed, opts.LineData{Value: math.Round(observedClicks[position]/observedImpressions[position]*10000) / 100})
+		} else {
+			observed = append(observed, opts.LineData{Value: "-"})
 		}
-		formattedDate := parsedDate.Format("2006/01/02")
-		themeRiverData = append(themeRiverData, opts.ThemeRiverData{
-			Date:  formattedDate,
-			Value: float64(seoVisits[i]),
-			Name:  "Visits",
-		})
 	}
 
-	river.AddSeries("themeRiver", themeRiverData)
+	line.SetXAxis(positions).
+		AddSeries("Fitted CTR", fitted, charts.WithLineChartOpts(opts.LineChart{Smooth: opts.Bool(true)})).
+		AddSeries("Observed CTR", observed, charts.WithLineChartOpts(opts.LineChart{ConnectNulls: opts.Bool(true)}))
 
-	f, _ := os.Create(insightsCacheFolder + "/go_seo_VisitsRevenueRiver.html")
+	f, _ := os.Create(insightsCacheFolder + "/go_seo_CTRCurve.html")
 
-	_ = river.Render(f)
+	_ = line.Render(f)
 }
 
-func gaugeVisitsPerOrder() {
+// Table containing the scenario estimates
+func tableScenarioPlanner() {
 
-	gauge := charts.NewGauge()
+	formatInteger := message.NewPrinter(language.English)
 
-	insightsCacheFolderTrimmed = strings.TrimPrefix(insightsCacheFolder, ".")
-	clickURL := protocol + "://" + fullHost + insightsCacheFolderTrimmed + "/go_seo_VisitsPerOrderGauge.html"
+	htmlContent := `
+<!DOCTYPE html>
+<html>
+<head>
+<style>
+    body {
+        font-family: Arial, sans-serif;
+    }
+    table {
+        width: 100%;
+        border-collapse: collapse;
+        margin: 10px 0;
+        font-size: 14px;
+        text-align: left;
+    }
+    th, td {
+        padding: 8px;
+        border-bottom: 1px solid #ddd;
+    }
+    th {
+        background-color: #f2f2f2;
+    }
+    th.title {
+        color: DeepSkyBlue;
+        font-weight: bold;
+    }
+    td {
+        color: dimgray;
+    }
+    tr.chosen td {
+        color: black;
+        font-weight: bold;
+    }
+    tr:nth-child(even) {
+        background-color: #f9f9f9;
+    }
+    tr:hover {
+        background-color: deepskyblue;
+    }
+    h2 {
+        color: dimgray;
+        margin-bottom: 20px;
+    }
+    h3 {
+        color: gray;
+        margin-bottom: 13px;
+    }
+</style>
+</head>
+<body>`
 
-	setMinMax := charts.WithSeriesOpts(func(s *charts.SingleSeries) {
-		s.Min = minVisitsPerOrder
-		s.Max = maxVisitsPerOrder
-	})
+	lastPeriod := ""
+	if len(startMonthNames) > 0 {
+		lastPeriod = startMonthNames[len(startMonthNames)-1]
+	}
 
-	gauge.SetGlobalOptions(
-		charts.WithTitleOpts(opts.Title{
-			Title: "Lowest, average & highest organic visits per order",
-			Link:  clickURL}),
+	htmlContent += "<h2>Ranking improvement scenarios</h2>"
+	htmlContent += fmt.Sprintf("<h3>Estimated from the impressions of the top %d non-branded keywords by impressions in %s, at the fitted CTR for their position. One click is counted as one visit, revenue at %s%.2f per visit & orders at a %.2f%% conversion rate.</h3>",
+		len(scenarioKeywords), lastPeriod, currencySymbol, totalAverageVisitValue, conversionRatePercent())
+	htmlContent += `
+    <table>
+        <thead>
+            <tr>
+                <th class="title">Scenario</th>
+                <th class="title">Clicks</th>
+                <th class="title">Visits</th>
+                <th class="title">Orders</th>
+                <th class="title">Revenue</th>
+                <th class="title">Revenue change</th>
+            </tr>
+        </thead>
+        <tbody>`
 
-		charts.WithInitializationOpts(opts.Initialization{
-			Width:     gaugeDefaultWidth,
-			Height:    gaugeDefaultHeight,
-			PageTitle: "Visits per order",
-		}),
-	)
-	gauge.AddSeries("",
-		[]opts.GaugeData{{Value: totalAverageVisitsPerOrder}}, setMinMax)
+	for i, scenario := range scenarios {
+		rowClass := ""
+		if i == 1 {
+			rowClass = ` class="chosen"`
+		}
+		revenueChange := "-"
+		if i > 0 {
+			revenueChange = formatInteger.Sprintf("+%s%.0f", currencySymbol, scenario.Revenue-scenarios[0].Revenue)
+		}
+		htmlContent += formatInteger.Sprintf("<tr%s><td>%s</td><td>%.0f</td><td>%.0f</td><td>%.0f</td><td>%s%.0f</td><td>%s</td></tr>",
+			rowClass, scenario.Name, scenario.Clicks, scenario.Visits, scenario.Orders, currencySymbol, scenario.Revenue, revenueChange)
+	}
 
-	f, _ := os.Create(insightsCacheFolder + "/go_seo_VisitsPerOrderGauge.html")
+	htmlContent += `
+        </tbody>
+    </table>
+</body>
+</html>`
 
-	_ = gauge.Render(f)
+	saveHTML(htmlContent, "/go_seo_ScenarioPlanner.html")
 }
 
-// Table containing the detailed KPI insights
-func textTableDataDetail() {
+// The organic conversion rate (%) for the reporting period
+func conversionRatePercent() float64 {
 
-	var detailedKPITableData [][]string
+	if metricsVisitsOrganic == 0 {
+		return 0
+	}
+	return float64(metricsOrdersOrganic) / float64(metricsVisitsOrganic) * 100
+}
 
-	formatInteger := message.NewPrinter(language.English)
+// Get the top non-branded keywords by impressions for the CTR curve & the scenarios
+func generateScenarioKeywordsBQL(startDate string, endDate string) {
 
-	for i := 0; i < noOfMonths; i++ {
-		formattedDate := formatDate(startMonthDates[i])
-		orders := formatInteger.Sprintf("%d", seoOrders[i])
-		revenue := formatInteger.Sprintf("%d", seoRevenue[i])
-		orderValue := formatInteger.Sprintf("%d", seoOrderValue[i])
-		visits := formatInteger.Sprintf("%d", seoVisits[i])
-		visitValue := formatInteger.Sprintf("%.2f", seoVisitValue[i])
-		visitsPerOrderValue := formatInteger.Sprintf("%d", seoVisitsPerOrder[i])
+	scenarioKeywords = nil
 
-		scImpressions := formatInteger.Sprintf("%d", seoScImpressions[i])
-		scClicks := formatInteger.Sprintf("%d", seoScClicks[i])
-		scAvgPosition := fmt.Sprintf("%.2f", seoScAvgPosition[i])
-		scCTR := fmt.Sprintf("%.2f", seoScCTR[i])
+	collection := "search_console_by_property"
+	bqlScenarioKeywords := newBQLQuery(startDate, endDate, collection).
+		dimensions("keyword").
+		metrics(
+			bqlField(collection, 0, "count_impressions"),
+			bqlField(collection, 0, "count_clicks"),
+			bqlField(collection, 0, "avg_position")).
+		sortBy("metrics", 0, "desc").
+		filter(bqlAnd(bqlEq("keyword_meta.branded", false)))
 
-		row := []string{
-			formattedDate,
-			orders,
-			currencySymbol + revenue,
-			currencySymbol + orderValue,
-			visits,
-			currencySymbol + visitValue,
-			visitsPerOrderValue,
-			scImpressions,
-			scClicks,
-			scAvgPosition,
-			scCTR,
-		}
-		detailedKPITableData = append(detailedKPITableData, row)
+	rows, err := runBQL(scenarioKeywordsLimit, bqlScenarioKeywords)
+	if err != nil {
+		fmt.Printf(red+"Error. generateScenarioKeywordsBQL. The query failed: %v\n"+reset, err)
+		return
 	}
 
-	// Generate the table
-	htmlContent := generateHTMLDetailedKPIInsightsTable(detailedKPITableData)
+	for _, row := range rows {
+		keyword, ok := row.Dimensions["keyword"].(string)
+		if !ok {
+			continue
+		}
+		scenarioKeyword := scenarioKeyword{
+			Keyword:     keyword,
+			Impressions: int(row.Metrics[bqlField(collection, 0, "count_impressions")]),
+			Clicks:      int(row.Metrics[bqlField(collection, 0, "count_clicks")]),
+			AvgPosition: row.Metrics[bqlField(collection, 0, "avg_position")],
+		}
+		// Keywords without an average position (null) can't be placed on the CTR curve
+		if scenarioKeyword.AvgPosition < 1 {
+			continue
+		}
+		scenarioKeywords = append(scenarioKeywords, scenarioKeyword)
+	}
 
-	// Save the HTML to a file
-	saveHTML(htmlContent, "/go_seo_OrganicBusinessInsights.html")
+	fmt.Printf(green+"Scenario planner: %d non-branded keywords acquired\n"+reset, len(scenarioKeywords))
 }
 
-// Winning keywords, branded & non-branded
-func textWinningKeywords(brandedMode bool, sessionID string) {
+// Flag the striking distance & low CTR keywords and estimate the revenue opportunity of each keyword
+// Uses the CTR by position curve. Striking distance keywords are valued at a top 3 position, the others at the expected CTR for their position
+func keywordOpportunitiesCompute() {
+
+	// The opportunities are the keywords acquired for the scenarios
+	keywordOpportunities = nil
+	for _, keyword := range scenarioKeywords {
+		opportunity := keywordOpportunity{
+			Keyword:     keyword.Keyword,
+			Impressions: keyword.Impressions,
+			Clicks:      keyword.Clicks,
+			AvgPosition: keyword.AvgPosition,
+		}
+		if opportunity.Impressions > 0 {
+			opportunity.CTR = float64(opportunity.Clicks) / float64(opportunity.Impressions)
+		}
+		keywordOpportunities = append(keywordOpportunities, opportunity)
+	}
 
-	var htmlFileName = ""
+	// High impressions are in the top quartile of the keywords acquired
+	var impressions []int
+	for _, opportunity := range keywordOpportunities {
+		impressions = append(impressions, opportunity.Impressions)
+	}
+	sort.Ints(impressions)
+	strikingDistanceImpressions = 0
+	if len(impressions) > 0 {
+		strikingDistanceImpressions = impressions[len(impressions)*3/4]
+	}
 
-	// Define the display values based on branded or non-branded mode
-	var htmlKeyword = ""
-	var htmlClicks = ""
-	var htmlClickGap = 0
-	var htmlSecondPlaceKW = ""
-	var htmlCTR float64
-	var htmlAvgPosition float64
-	var brandedHeader = ""
+	for i := range keywordOpportunities {
+		opportunity := &keywordOpportunities[i]
+		position := opportunity.AvgPosition
+		opportunity.ExpectedCTR = ctrAtPosition(position)
 
-	formatInteger := message.NewPrinter(language.English)
+		opportunity.StrikingDistance = position >= strikingDistanceMin && position <= strikingDistanceMax && opportunity.Impressions >= strikingDistanceImpressions
+		opportunity.LowCTR = opportunity.Impressions > 0 && opportunity.CTR < opportunity.ExpectedCTR*lowCTRRatio
 
-	if brandedMode {
-		brandedHeader = "Branded Keywords"
-		htmlKeyword = kwKeywords[0]
-		htmlClicks = formatInteger.Sprintf("%d", kwCountClicks[0])
-		htmlClickGap = int(((float64(kwCountClicks[0]) - float64(kwCountClicks[1])) / float64(kwCountClicks[1])) * 100)
-		htmlSecondPlaceKW = kwKeywords[1]
-		htmlCTR = kwMetricsCTR[0]
-		htmlAvgPosition = kwMetricsAvgPosition[0]
-		fmt.Println("\n" + yellow + sessionID + reset + " Branded keywords\n")
-		for i := 0; i < len(kwKeywords); i++ {
-			fmt.Printf(green+"Keyword:"+reset+bold+" %s"+reset+","+green+" Clicks:"+reset+" %d,"+green+" CTR:"+reset+" %.2f,"+green+" Avg. Position:"+reset+" %.2f\n",
-				kwKeywords[i], kwCountClicks[i], kwMetricsCTR[i], kwMetricsAvgPosition[i])
+		targetClicks := float64(opportunity.Impressions) * opportunity.ExpectedCTR
+		if opportunity.StrikingDistance {
+			targetClicks = math.Max(targetClicks, float64(opportunity.Impressions)*ctrAtPosition(strikingDistanceTarget))
 		}
+		opportunity.ClicksOpportunity = math.Max(targetClicks-float64(opportunity.Clicks), 0)
+		opportunity.RevenueOpportunity = opportunity.ClicksOpportunity * totalAverageVisitValue
+	}
+
+	// The largest opportunities first
+	sort.SliceStable(keywordOpportunities, func(i, j int) bool {
+		return keywordOpportunities[i].RevenueOpportunity > keywordOpportunities[j].RevenueOpportunity
+	})
+}
+
+// The opportunity type of the keyword
+func (k keywordOpportunity) opportunityType() string {
+
+	switch {
+	case k.StrikingDistance && k.LowCTR:
+		return "Striking distance, low CTR"
+	case k.StrikingDistance:
+		return "Striking distance"
+	case k.LowCTR:
+		return "Low CTR"
 	}
+	return "-"
+}
 
-	if !brandedMode {
-		brandedHeader = "Non-Branded Keywords"
-		htmlKeyword = kwKeywordsNonBranded[0]
-		htmlClicks = formatInteger.Sprintf("%d", kwCountClicksNonBranded[0])
-		htmlClickGap = int(((float64(kwCountClicksNonBranded[0]) - float64(kwCountClicksNonBranded[1])) / float64(kwCountClicksNonBranded[1])) * 100)
-		htmlSecondPlaceKW = kwKeywordsNonBranded[1]
-		htmlCTR = kwCTRNonBranded[0]
-		htmlAvgPosition = kwAvgPositionNonBranded[0]
-		fmt.Println("\n" + yellow + sessionID + reset + " Non branded keywords\n")
-		for i := 0; i < len(kwKeywords); i++ {
-			fmt.Printf(green+"Keyword:"+reset+bold+" %s"+reset+","+green+" Clicks:"+reset+" %d,"+green+" CTR:"+reset+" %.2f,"+green+" Avg. Position:"+reset+" %.2f\n",
-				kwKeywordsNonBranded[i], kwCountClicksNonBranded[i], kwCTRNonBranded[i], kwAvgPositionNonBranded[i])
+// Render the keyword opportunities as CSV
+func renderKeywordOpportunitiesCSV() (string, error) {
+
+	var csvContent strings.Builder
+	writer := csv.NewWriter(&csvContent)
+
+	if err := writer.Write([]string{"Keyword", "Impressions", "Clicks", "CTR", "Expected CTR", "Avg. position", "Opportunity", "Clicks opportunity", "Revenue opportunity"}); err != nil {
+		return "", err
+	}
+	for _, opportunity := range keywordOpportunities {
+		if err := writer.Write([]string{
+			opportunity.Keyword,
+			strconv.Itoa(opportunity.Impressions),
+			strconv.Itoa(opportunity.Clicks),
+			fmt.Sprintf("%.4f", opportunity.CTR),
+			fmt.Sprintf("%.4f", opportunity.ExpectedCTR),
+			fmt.Sprintf("%.2f", opportunity.AvgPosition),
+			opportunity.opportunityType(),
+			fmt.Sprintf("%.0f", opportunity.ClicksOpportunity),
+			fmt.Sprintf("%.2f", opportunity.RevenueOpportunity),
+		}); err != nil {
+			return "", err
 		}
 	}
+	writer.Flush()
 
-	// Get the last month name
-	htmlLastMonthName := ""
-	if len(startMonthNames) > 0 {
-		htmlLastMonthName = startMonthNames[len(startMonthNames)-1]
-	}
+	return csvContent.String(), writer.Error()
+}
 
-	// HTML content for the winning keyword
-	htmlContent := fmt.Sprintf(`
-<!DOCTYPE html>
-<html>
-<head>
-	<style>
-        body {
-            font-family: 'Arial', sans-serif;
-            margin: 0;
-            padding: 0;
-            display: flex;
-            justify-content: center;
-            align-items: center;
-            height: 100vh;
-        }
-        .content { 
-            padding: 15px;
-            text-align: left;  
-        }
-        .blueText {
-            color: DeepSkyBlue;
-            font-size: 25px;
-            font-weight: bold;  
-        }
-        .keyword-font {
-            font-family: 'Arial', sans-serif;
-            font-size: 18px;
-            color: LightSlateGray;
-            line-height: 1.6; 
-        }
-        .header-font {
-            font-family: 'Arial', sans-serif;
-            font-size: 25px;
-            color: Teal;
-            font-weight: bold;
-            line-height: 1.6; 
-        }
-        b {
-            color: #333;
-        }
-    </style>
-</head>
-<body>
-    <div class="content">
-        <span class="header-font">
-        %s
- </span>
-    <span class="keyword-font">
-        The winning keyword during <b>%s</b> was <span class="blueText">%s</span>. 
-        This keyword generated <b>%s</b> clicks which is <b>%d%%</b> more clicks than the second placed keyword  <b>%s</b>. The click-through rate for the winning keyword was <b>%.2f%%</b> 
-        from an average position of <b>%.2f</b>.
-    </span>
-	</div>
-</body>
-</html>
-`, brandedHeader, htmlLastMonthName, htmlKeyword, htmlClicks, htmlClickGap, htmlSecondPlaceKW, htmlCTR, htmlAvgPosition)
+// Sortable table containing the keyword opportunities & the CSV download
+func tableKeywordOpportunities() {
 
-	if brandedMode {
-		htmlFileName = "/go_seo_WinningKeywordBranded.html"
+	csvContent, err := renderKeywordOpportunitiesCSV()
+	if err != nil {
+		fmt.Println(red+"Error. tableKeywordOpportunities. Cannot render the CSV:"+reset, err)
 	} else {
-		htmlFileName = "/go_seo_WinningKeywordNonBranded.html"
+		saveHTML(csvContent, "/go_seo_KeywordOpportunities.csv")
 	}
 
-	// Save the HTML to a file
-	saveHTML(htmlContent, htmlFileName)
-}
+	formatInteger := message.NewPrinter(language.English)
 
-// Generate the HTML for the table
-func generateHTMLDetailedKPIInsightsTable(data [][]string) string {
+	strikingDistanceCount, lowCTRCount := 0, 0
+	for _, opportunity := range keywordOpportunities {
+		if opportunity.StrikingDistance {
+			strikingDistanceCount++
+		}
+		if opportunity.LowCTR {
+			lowCTRCount++
+		}
+	}
 
 	htmlContent := `
 <!DOCTYPE html>
@@ -2290,19 +4647,17 @@ func generateHTMLDetailedKPIInsightsTable(data [][]string) string {
         width: 100%;
         border-collapse: collapse;
         margin: 10px 0;
-        font-size: 18px;
+        font-size: 14px;
         text-align: left;
     }
     th, td {
-        padding: 12px;
+        padding: 8px;
         border-bottom: 1px solid #ddd;
     }
     th {
         background-color: #f2f2f2;
-    }
-    th.title {
-        color: gray;
-        font-weight: bold;
+        color: DeepSkyBlue;
+        cursor: pointer;
     }
     td {
         color: dimgray;
@@ -2321,198 +4676,370 @@ func generateHTMLDetailedKPIInsightsTable(data [][]string) string {
         color: gray;
         margin-bottom: 13px;
     }
+    a {
+        color: DeepSkyBlue;
+    }
 </style>
 </head>
-<body style="min-height: 10vh;">
-    <table>
+<body>`
+
+	htmlContent += "<h2>Non-branded keyword opportunities</h2>"
+	htmlContent += formatInteger.Sprintf("<h3>%d keywords analysed, %d in striking distance (positions %.0f-%.0f with %d+ impressions), %d with a low CTR for their position. Revenue opportunity at %s%.2f per visit. <a href=\"go_seo_KeywordOpportunities.csv\" download>Download CSV</a></h3>",
+		len(keywordOpportunities), strikingDistanceCount, strikingDistanceMin, strikingDistanceMax, strikingDistanceImpressions, lowCTRCount, currencySymbol, totalAverageVisitValue)
+	htmlContent += `
+    <table id="opportunities">
         <thead>
             <tr>
-                <th class="title" style="color: DeepSkyBlue;">Month</th>
-				<th class="title" style="color: DeepSkyBlue;">Order volume</th>
-                <th class="title" style="color: DeepSkyBlue;">Revenue</th>
-                <th class="title" style="color: DeepSkyBlue;">Order value</th>
-                <th class="title" style="color: DeepSkyBlue;">No. of visits</th>
-                <th class="title" style="color: DeepSkyBlue;">Revenue per visit</th>
-                <th class="title" style="color: DeepSkyBlue;">Visits per order</th>
-                <th class="title" style="color: DeepSkyBlue;">Impressions</th>
-                <th class="title" style="color: DeepSkyBlue;">Clicks</th>
-                <th class="title" style="color: DeepSkyBlue;">Avg. position</th>
-                <th class="title" style="color: DeepSkyBlue;">Avg. CTR</th>
+                <th onclick="sortTable(0, false)">Keyword</th>
+                <th onclick="sortTable(1, true)">Impressions</th>
+                <th onclick="sortTable(2, true)">Clicks</th>
+                <th onclick="sortTable(3, true)">CTR %</th>
+                <th onclick="sortTable(4, true)">Expected CTR %</th>
+                <th onclick="sortTable(5, true)">Avg. position</th>
+                <th onclick="sortTable(6, false)">Opportunity</th>
+                <th onclick="sortTable(7, true)">Clicks opportunity</th>
+                <th onclick="sortTable(8, true)">Revenue opportunity</th>
             </tr>
         </thead>
         <tbody>`
 
-	// Title
-	htmlContent += fmt.Sprintf("<h2>\n\nOrganic Business insights for the previous %d months</h2>", noOfMonths)
-	// Non brand message
-	htmlContent += fmt.Sprintf("<h3>\n\nNote: Impressions, Clicks, Avg. position & Avg. CTR are all Non-Branded traffic</h3>")
-
-	// Insert the KPIs
-	for _, row := range data {
-		htmlContent += "<tr>"
-		for _, cell := range row {
-			htmlContent += "<td>" + cell + "</td>"
+	for i, opportunity := range keywordOpportunities {
+		if i == maxOpportunitiesDisplayed {
+			break
 		}
-		htmlContent += "</tr>"
+		htmlContent += formatInteger.Sprintf("<tr><td>%s</td><td>%d</td><td>%d</td><td>%.2f</td><td>%.2f</td><td>%.2f</td><td>%s</td><td>%.0f</td><td>%s%.0f</td></tr>",
+			html.EscapeString(opportunity.Keyword), opportunity.Impressions, opportunity.Clicks, opportunity.CTR*100, opportunity.ExpectedCTR*100,
+			opportunity.AvgPosition, opportunity.opportunityType(), opportunity.ClicksOpportunity, currencySymbol, opportunity.RevenueOpportunity)
 	}
+
 	htmlContent += `
         </tbody>
     </table>
+<script>
+    // Sort the table when a header is clicked. Click again to reverse the order
+    let sortColumn = -1;
+    let sortAscending = true;
+    function sortTable(column, numeric) {
+        sortAscending = column === sortColumn ? !sortAscending : !numeric;
+        sortColumn = column;
+        const tbody = document.querySelector("#opportunities tbody");
+        const rows = Array.from(tbody.rows);
+        const value = row => {
+            const text = row.cells[column].innerText;
+            return numeric ? parseFloat(text.replace(/[^0-9.\-]/g, "")) || 0 : text.toLowerCase();
+        };
+        rows.sort((a, b) => {
+            const x = value(a), y = value(b);
+            return (x < y ? -1 : x > y ? 1 : 0) * (sortAscending ? 1 : -1);
+        });
+        rows.forEach(row => tbody.appendChild(row));
+    }
+</script>
 </body>
 </html>`
 
-	return htmlContent
+	saveHTML(htmlContent, "/go_seo_KeywordOpportunities.html")
 }
 
-// Generate the HTML for the keywords insights
-func textDetailedKeywordsInsights(brandedMode bool) {
+// Get the keyword movers between the previous period & the last period, branded & non-branded
+func getKeywordMovers(startDate string, endDate string) {
 
-	formatInteger := message.NewPrinter(language.English)
+	keywordMoversBranded, keywordMoversNonBranded = nil, nil
 
-	htmlContent := `
-<!DOCTYPE html>
-<html>
-<head>
-	<style>
-        body {
-            font-family: Arial, sans-serif;
-            margin: 0;
-            padding: 0;
-        }
-        .table-container {
-            overflow: auto;
-            height: 500px; 
-            border: 2px solid transparent; 
-            border-radius: 16px; 
-            margin: 20px; 
-        }
-        table {
-            width: 100%;
-            border-collapse: collapse; 
-            color: DimGray;
-            font-size: 17px;
-            text-align: left;
-            border-radius: 16px; 
-        }
-        th, td {
-            padding: 8px;
-        }
-        th {
-            background-color: White;
-            color: deepskyblue;
-            position: sticky; 
-            top: 0; 
-            z-index: 10; /* Ensure header stays on top */
-        }
-        td {
-            border-bottom: 1px solid transparent; 
-        }
-        tr:nth-child(odd) {
-            background-color: #f9f9f9;
-        }
-        tr:hover {
-            background-color: DeepSkyBlue;
-            color: white; 
-        }
-        /* Apply rounded corners to header and footer rows */
-        thead th:first-child {
-            border-top-left-radius: 16px;
-        }
-        thead th:last-child {
-            border-top-right-radius: 16px;
-        }
-        tbody tr:last-child td:first-child {
-            border-bottom-left-radius: 16px;
-        }
-        tbody tr:last-child td:last-child {
-            border-bottom-right-radius: 16px;
-        }
-    </style>
-</head>
-<body>
-    <table>
-        <tr>
-            <th>Keyword</th>
-            <th>No. of Clicks</th>
-            <th>CTR</th>
-            <th>Average Position</th>
-        </tr>`
+	lastStart, err := time.Parse("20060102", startDate)
+	if err != nil {
+		fmt.Println(red+"Error. getKeywordMovers. Cannot parse the start date:"+reset, err)
+		return
+	}
+	previousStart := nextPeriodStart(periodStart(lastStart), -1)
+	previousEnd := periodStart(lastStart).AddDate(0, 0, -1)
+	previousStartDate := previousStart.Format("20060102")
+	previousEndDate := previousEnd.Format("20060102")
 
-	// Branded keywords details
-	if brandedMode {
-		for i := 0; i < noKeywordsInCloud; i++ {
-			kwCountClicksFormatted := formatInteger.Sprintf("%d", kwCountClicks[i])
-			htmlContent += fmt.Sprintf("<tr>\n"+
-				"    <td>%s</td>\n"+
-				"    <td>%s</td>\n"+
-				"    <td>%.2f%%</td>\n"+
-				"    <td>%.2f</td>\n"+
-				"</tr>\n",
-				kwKeywords[i],
-				kwCountClicksFormatted,
-				kwMetricsCTR[i],
-				kwMetricsAvgPosition[i])
+	lastEnd, _ := time.Parse("20060102", endDate)
+	keywordMoversLabel = periodLabel(previousStart) + " to " + periodLabel(periodStart(lastEnd))
+
+	keywordMoversBranded = generateKeywordMoversBQL(startDate, endDate, previousStartDate, previousEndDate, true)
+	keywordMoversNonBranded = generateKeywordMoversBQL(startDate, endDate, previousStartDate, previousEndDate, false)
+
+	fmt.Printf(green+"Keyword movers (%s): %d branded, %d non-branded\n"+reset, keywordMoversLabel, len(keywordMoversBranded), len(keywordMoversNonBranded))
+}
+
+// The clicks & position of the keywords in the last period (period_0) & the previous period (period_1)
+// The top keywords of each period are acquired so that new & lost keywords are both found
+func generateKeywordMoversBQL(startDate string, endDate string, previousStartDate string, previousEndDate string, branded bool) []keywordMover {
+
+	collection := "search_console_by_property"
+	movers := make(map[string]*keywordMover)
+	var keywords []string
+
+	// Sorted by the clicks in the last period, then by the clicks in the previous period
+	for _, sortIndex := range []int{0, 2} {
+		bqlMovers := newBQLQuery(startDate, endDate, collection).
+			period(previousStartDate, previousEndDate).
+			dimensions("keyword").
+			metrics(
+				bqlField(collection, 0, "count_clicks"),
+				bqlField(collection, 0, "avg_position"),
+				bqlField(collection, 1, "count_clicks"),
+				bqlField(collection, 1, "avg_position")).
+			sortBy("metrics", sortIndex, "desc").
+			filter(bqlAnd(bqlEq("keyword_meta.branded", branded)))
+
+		rows, err := runBQL(moversKeywords, bqlMovers)
+		if err != nil {
+			fmt.Printf(red+"Error. generateKeywordMoversBQL. The query failed: %v\n"+reset, err)
+			return nil
+		}
+
+		for _, row := range rows {
+			keyword, ok := row.Dimensions["keyword"].(string)
+			if !ok {
+				continue
+			}
+			if _, found := movers[keyword]; found {
+				continue
+			}
+			movers[keyword] = &keywordMover{
+				Keyword:             keyword,
+				Branded:             branded,
+				Clicks:              int(row.Metrics[bqlField(collection, 0, "count_clicks")]),
+				AvgPosition:         row.Metrics[bqlField(collection, 0, "avg_position")],
+				PreviousClicks:      int(row.Metrics[bqlField(collection, 1, "count_clicks")]),
+				PreviousAvgPosition: row.Metrics[bqlField(collection, 1, "avg_position")],
+			}
+			keywords = append(keywords, keyword)
 		}
 	}
 
-	// Non branded keywords details
-	if !brandedMode {
-		for i := 0; i < noKeywordsInCloud; i++ {
-			kwCountClicksFormattedNonBranded := formatInteger.Sprintf("%d", kwCountClicksNonBranded[i])
-			htmlContent += fmt.Sprintf("<tr>\n"+
-				"    <td>%s</td>\n"+
-				"    <td>%s</td>\n"+
-				"    <td>%.2f%%</td>\n"+
-				"    <td>%.2f</td>\n"+
-				"</tr>\n",
-				kwKeywordsNonBranded[i],
-				kwCountClicksFormattedNonBranded,
-				kwCTRNonBranded[i],
-				kwAvgPositionNonBranded[i])
+	var classified []keywordMover
+	for _, keyword := range keywords {
+		mover := *movers[keyword]
+		mover.Movement = mover.classify()
+		if mover.Movement != "" {
+			classified = append(classified, mover)
+		}
+	}
+
+	return classified
+}
+
+// New, lost, rising or falling. By clicks, then by position when the clicks are unchanged. Empty for unchanged keywords
+func (m keywordMover) classify() string {
+
+	switch {
+	case m.Clicks > 0 && m.PreviousClicks == 0:
+		return "New"
+	case m.Clicks == 0 && m.PreviousClicks > 0:
+		return "Lost"
+	case m.Clicks == 0 && m.PreviousClicks == 0:
+		return ""
+	case m.Clicks > m.PreviousClicks:
+		return "Rising"
+	case m.Clicks < m.PreviousClicks:
+		return "Falling"
+	case m.positionChange() > 0:
+		return "Rising"
+	case m.positionChange() < 0:
+		return "Falling"
+	}
+	return ""
+}
+
+// The change in position. Positive when the keyword ranks higher
+func (m keywordMover) positionChange() float64 {
+
+	if m.AvgPosition == 0 || m.PreviousAvgPosition == 0 {
+		return 0
+	}
+	return m.PreviousAvgPosition - m.AvgPosition
+}
+
+// The top movers of a type. New keywords by clicks, lost keywords by previous clicks, rising & falling by the change in clicks
+func topKeywordMovers(movers []keywordMover, movement string) []keywordMover {
+
+	var top []keywordMover
+	for _, mover := range movers {
+		if mover.Movement == movement {
+			top = append(top, mover)
 		}
 	}
 
-	htmlContent += fmt.Sprintf("</table>\n")
-	htmlContent += fmt.Sprintf("</body>\n")
-	htmlContent += fmt.Sprintf("</html>\n")
+	magnitude := func(m keywordMover) int {
+		change := m.Clicks - m.PreviousClicks
+		if change < 0 {
+			return -change
+		}
+		return change
+	}
+	sort.SliceStable(top, func(i, j int) bool {
+		if magnitude(top[i]) != magnitude(top[j]) {
+			return magnitude(top[i]) > magnitude(top[j])
+		}
+		return math.Abs(top[i].positionChange()) > math.Abs(top[j].positionChange())
+	})
 
-	// Save the HTML to a file
-	// Branded keywords details
-	if brandedMode {
-		saveHTML(htmlContent, "/go_seo_KeywordBrandedInsights.html")
+	if len(top) > noTopMovers {
+		top = top[:noTopMovers]
 	}
-	// Branded keywords details
-	if !brandedMode {
-		saveHTML(htmlContent, "/go_seo_KeywordNonBrandedInsights.html")
+	return top
+}
+
+// Render the branded & non-branded keyword movers as CSV
+func renderKeywordMoversCSV() (string, error) {
+
+	var csvContent strings.Builder
+	writer := csv.NewWriter(&csvContent)
+
+	if err := writer.Write([]string{"Keyword", "Branded", "Movement", "Clicks", "Previous clicks", "Clicks change", "Avg. position", "Previous avg. position", "Position change"}); err != nil {
+		return "", err
+	}
+	for _, mover := range append(append([]keywordMover{}, keywordMoversBranded...), keywordMoversNonBranded...) {
+		if err := writer.Write([]string{
+			mover.Keyword,
+			strconv.FormatBool(mover.Branded),
+			mover.Movement,
+			strconv.Itoa(mover.Clicks),
+			strconv.Itoa(mover.PreviousClicks),
+			strconv.Itoa(mover.Clicks - mover.PreviousClicks),
+			fmt.Sprintf("%.2f", mover.AvgPosition),
+			fmt.Sprintf("%.2f", mover.PreviousAvgPosition),
+			fmt.Sprintf("%.2f", mover.positionChange()),
+		}); err != nil {
+			return "", err
+		}
 	}
+	writer.Flush()
+
+	return csvContent.String(), writer.Error()
 }
 
-// generate the slice containing the projected revenue data
-func forecastDataCompute() {
+// Keyword movers panel & the CSV download
+func textKeywordMovers(brandedMode bool) {
 
-	// First create a slice containing the visit ranges
-	numElements := forecastMaxVisits/forecastIncrement + 1
-	forecastVisitIncrements = make([]int, numElements)
-	forecastVisitIncrementsString = make([]string, numElements)
+	movers := keywordMoversNonBranded
+	title := "Non-branded keyword movers"
+	htmlFileName := "/go_seo_KeywordMoversNonBranded.html"
+	if brandedMode {
+		movers = keywordMoversBranded
+		title = "Branded keyword movers"
+		htmlFileName = "/go_seo_KeywordMoversBranded.html"
+
+		// The CSV includes the branded & non-branded keywords
+		csvContent, err := renderKeywordMoversCSV()
+		if err != nil {
+			fmt.Println(red+"Error. textKeywordMovers. Cannot render the CSV:"+reset, err)
+		} else {
+			saveHTML(csvContent, "/go_seo_KeywordMovers.csv")
+		}
+	}
 
-	// Populate the slice with the visit ranges
 	formatInteger := message.NewPrinter(language.English)
 
-	for i := 0; i < numElements; i++ {
-		forecastVisitIncrements[i] = i * forecastIncrement
-		// Create a formatted String version for use in the chart XAxis
-		forecastVisitIncrementsString[i] = formatInteger.Sprintf("%d", forecastVisitIncrements[i])
-	}
+	htmlContent := `
+<!DOCTYPE html>
+<html>
+<head>
+<style>
+    body {
+        font-family: Arial, sans-serif;
+    }
+    table {
+        width: 100%;
+        border-collapse: collapse;
+        margin: 5px 0 15px 0;
+        font-size: 13px;
+        text-align: left;
+    }
+    th, td {
+        padding: 6px;
+        border-bottom: 1px solid #ddd;
+    }
+    th {
+        background-color: #f2f2f2;
+        color: DeepSkyBlue;
+    }
+    td {
+        color: dimgray;
+    }
+    tr:hover {
+        background-color: deepskyblue;
+    }
+    h2 {
+        color: dimgray;
+        margin-bottom: 10px;
+    }
+    h3 {
+        color: gray;
+        margin-bottom: 5px;
+    }
+    .up {
+        color: green;
+    }
+    .down {
+        color: red;
+    }
+    a {
+        color: DeepSkyBlue;
+    }
+</style>
+</head>
+<body>`
 
-	// Create a slice to hold the forecast revenue values
-	forecastRevenue = make([]int, numElements)
-	for i := 0; i < numElements; i++ {
-		if totalAverageVisitsPerOrder != 0 {
-			forecastRevenue[i] = forecastVisitIncrements[i] / totalAverageVisitsPerOrder * totalAverageOrderValueOrganic
-		} else {
-			forecastRevenue[i] = 0
+	htmlContent += "<h2>" + title + "</h2>"
+	htmlContent += "<span>" + keywordMoversLabel + `. <a href="go_seo_KeywordMovers.csv" download>Download CSV</a></span>`
+
+	for _, movement := range []string{"New", "Lost", "Rising", "Falling"} {
+		top := topKeywordMovers(movers, movement)
+		htmlContent += "<h3>" + movement + "</h3>"
+		if len(top) == 0 {
+			htmlContent += "<span>No keywords</span>"
+			continue
+		}
+		htmlContent += `
+    <table>
+        <thead>
+            <tr>
+                <th>Keyword</th>
+                <th>Clicks</th>
+                <th>Change</th>
+                <th>Avg. position</th>
+                <th>Change</th>
+            </tr>
+        </thead>
+        <tbody>`
+		for _, mover := range top {
+			clicksChange := mover.Clicks - mover.PreviousClicks
+			position := "-"
+			if mover.AvgPosition > 0 {
+				position = fmt.Sprintf("%.1f", mover.AvgPosition)
+			}
+			htmlContent += formatInteger.Sprintf("<tr><td>%s</td><td>%d</td><td class=\"%s\">%+d</td><td>%s</td><td class=\"%s\">%+.1f</td></tr>",
+				html.EscapeString(mover.Keyword), mover.Clicks, changeClass(float64(clicksChange)), clicksChange,
+				position, changeClass(mover.positionChange()), mover.positionChange())
 		}
+		htmlContent += `
+        </tbody>
+    </table>`
+	}
+
+	htmlContent += `
+</body>
+</html>`
+
+	saveHTML(htmlContent, htmlFileName)
+}
+
+// The class used to colour a change. Green when up, red when down
+func changeClass(change float64) string {
+
+	switch {
+	case change > 0:
+		return "up"
+	case change < 0:
+		return "down"
 	}
+	return ""
 }
 
 // Revenue forecast line chart
@@ -2592,7 +5119,11 @@ func textForecastNarrative() {
 		noOfOrderVisits = 0
 	}
 
-	var projectedRevenue = noOfOrderVisits * totalAverageOrderValueOrganic
+	// Consistent with the forecast chart
+	var projectedRevenue = 0
+	if totalAverageVisitsPerOrder != 0 {
+		projectedRevenue = int(float64(forecastIncrement) / float64(totalAverageVisitsPerOrder) * float64(totalAverageOrderValueOrganic))
+	}
 
 	// Format the integers with commas
 	formatInteger := message.NewPrinter(language.English)
@@ -2691,9 +5222,9 @@ func barNonOrganic() {
 			Height:    chartDefaultHeight,
 			PageTitle: "Non-organic contribution",
 		}),
-		charts.WithColorsOpts(opts.Colors{kpiColourNonOrganic}),
-		// disable show the legend
-		charts.WithLegendOpts(opts.Legend{Show: opts.Bool(false)}),
+		charts.WithColorsOpts(opts.Colors{kpiColourNonOrganic, kpiColourComparison}),
+		// The legend is only shown with the comparison period
+		charts.WithLegendOpts(opts.Legend{Show: opts.Bool(activePeriod.comparing()), Right: "80px"}),
 	)
 
 	barDataOrders := generateBarItems(nonOrganicPerformanceValues)
@@ -2706,6 +5237,11 @@ func barNonOrganic() {
 			),
 		)
 
+	// Comparison period
+	if _, nonOrganicContribution := comparisonContribution(); nonOrganicContribution != nil {
+		bar.AddSeries("Non-organic contribution (%)"+comparisonSeriesSuffix(), generateBarItems(nonOrganicContribution))
+	}
+
 	f, _ := os.Create(insightsCacheFolder + "/go_seo_NonOrganicComparison.html")
 
 	_ = bar.Render(f)
@@ -2730,21 +5266,26 @@ func barOrganic() {
 			Height:    chartDefaultHeight,
 			PageTitle: "Non-organic contribution",
 		}),
-		charts.WithColorsOpts(opts.Colors{kpiColourOrganic}),
-		// disable show the legend
-		charts.WithLegendOpts(opts.Legend{Show: opts.Bool(false)}),
+		charts.WithColorsOpts(opts.Colors{kpiColourOrganic, kpiColourComparison}),
+		// The legend is only shown with the comparison period
+		charts.WithLegendOpts(opts.Legend{Show: opts.Bool(activePeriod.comparing()), Right: "80px"}),
 	)
 
 	barDataOrders := generateBarItems(organicPerformanceValues)
 
 	bar.SetXAxis(organicPerformanceCategory).
-		AddSeries("Non-organic contribution (%)", barDataOrders).
+		AddSeries("Organic contribution (%)", barDataOrders).
 		SetSeriesOptions(
 			charts.WithMarkLineStyleOpts(
 				opts.MarkLineStyle{},
 			),
 		)
 
+	// Comparison period
+	if organicContribution, _ := comparisonContribution(); organicContribution != nil {
+		bar.AddSeries("Organic contribution (%)"+comparisonSeriesSuffix(), generateBarItems(organicContribution))
+	}
+
 	f, _ := os.Create(insightsCacheFolder + "/go_seo_OrganicComparison.html")
 
 	_ = bar.Render(f)
@@ -2865,10 +5406,13 @@ func footerNotes() {
 
 	dashboardPermaLink = protocol + "://" + fullHost + insightsCacheFolderTrimmed + "/go_seo_BusinessInsights.html"
 
+	unit, unitPlural := granularityUnit()
+	growthRate, growthRateFullName := growthRateName()
+
 	// Text content for the footer
 	var footerNotesStrings = []string{
-		"The current month is not included in the analysis, only full months are reported on.",
-		"Compound Growth (CMGR) refers to the Compound Monthly Growth Rate of the KPI. CMGR is a financial term used to measure the growth rate of a metric over a monthly basis taking into account the compounding effect. CMGR provides a clear and standardised method to measure growth over time.",
+		"The current " + unit + " is not included in the analysis, only full " + unitPlural + " are reported on.",
+		"Compound Growth (" + growthRate + ") refers to the " + growthRateFullName + " of the KPI. " + growthRate + " is a financial term used to measure the growth rate of a metric over a " + strings.ToLower(strings.Fields(growthRateFullName)[1]) + " basis taking into account the compounding effect. " + growthRate + " provides a clear and standardised method to measure growth over time.",
 		"The permalink for this broadsheet is <a href=\"" + dashboardPermaLink + "\" target=\"_blank\">" + dashboardPermaLink + "</a>",
 	}
 
@@ -2900,7 +5444,7 @@ func footerNotes() {
 	saveHTML(htmlContent, "/go_seo_Footer.html")
 }
 
-// formatDate converts date from YYYYMMDD to Month-Year format
+// formatDate converts date from YYYYMMDD to Month-Year format (ISO week or day for weekly & daily insights)
 func formatDate(dateStr string) string {
 
 	date, err := time.Parse("20060102", dateStr)
@@ -2908,7 +5452,7 @@ func formatDate(dateStr string) string {
 		fmt.Println(red+"Error. formatDate. Cannot parse date:"+reset, err)
 		return dateStr
 	}
-	return date.Format("January 2006")
+	return periodLabel(date)
 }
 
 // Function used to generate and save the HTML content to a file
@@ -3117,8 +5661,12 @@ func generateDashboardContainerHTML(company string) {
         <li><a href="#visit_value">Revenue per visit (RPV)</a></li>
         <li><a href="#detailed_insights">Detailed insights</a></li>
         <li><a href="#revenue_forecast">Revenue forecast</a></li>
+        <li><a href="#seasonal_forecast">Seasonal forecast</a></li>
         <li><a href="#wordcloud_branded">Top branded keywords</a></li>
         <li><a href="#wordcloud_non_branded">Top non branded keywords</a></li>
+        <li><a href="#scenario_planner">Scenario planner</a></li>
+        <li><a href="#keyword_opportunities">Keyword opportunities</a></li>
+        <li><a href="#keyword_movers">Keyword movers</a></li>
     </ul>
 </nav>
 
@@ -3235,6 +5783,16 @@ func generateDashboardContainerHTML(company string) {
 		<iframe src="go_seo_RevenueForecastNarrative.html" title="Visits per order" class="tall-iframe"></iframe>
 	</section>
 
+	<section id="seasonal_forecast" class="container row">
+		<iframe src="go_seo_SeasonalForecastRevenue.html" title="Revenue seasonal forecast" class="medium-iframe"></iframe>
+		<iframe src="go_seo_SeasonalForecastTable.html" title="Seasonal forecast" class="medium-iframe"></iframe>
+	</section>
+
+	<section class="container row">
+		<iframe src="go_seo_SeasonalForecastVisits.html" title="Visits seasonal forecast" class="medium-iframe"></iframe>
+		<iframe src="go_seo_SeasonalForecastOrders.html" title="Orders seasonal forecast" class="medium-iframe"></iframe>
+	</section>
+
 	<section class="container row no-border">
     	<iframe src="go_seo_TotalsNonBrandedPerformance.html" title="Organic business metrics" class="short-iframe"></iframe>
 	</section>
@@ -3247,6 +5805,20 @@ func generateDashboardContainerHTML(company string) {
         <iframe src="go_seo_KeywordNonBrandedInsights.html" title="Non Branded Keyword Insights" class="tall-iframe" style="height: 700px; width: %s; font-size: 10px;"></iframe>
     </section>
 
+	<section id="scenario_planner" class="container row">
+		<iframe src="go_seo_CTRCurve.html" title="CTR by position" class="medium-iframe"></iframe>
+		<iframe src="go_seo_ScenarioPlanner.html" title="Ranking improvement scenarios" class="medium-iframe"></iframe>
+	</section>
+
+	<section id="keyword_opportunities" class="container row">
+		<iframe src="go_seo_KeywordOpportunities.html" title="Non-branded keyword opportunities" class="tall-iframe"></iframe>
+	</section>
+
+	<section id="keyword_movers" class="container row">
+		<iframe src="go_seo_KeywordMoversBranded.html" title="Branded keyword movers" class="tall-iframe"></iframe>
+		<iframe src="go_seo_KeywordMoversNonBranded.html" title="Non-branded keyword movers" class="tall-iframe"></iframe>
+	</section>
+
 
     <section id="wordcloud_branded" class="horizontal-container no-border">
         <div class="containerColumn no-border">
@@ -3272,7 +5844,8 @@ func generateDashboardContainerHTML(company string) {
 }
 
 // Execute the BQL
-func executeBQL(returnSize int, bqlToExecute string) []byte {
+// An error is returned when the API cannot be reached or returns a non-2xx status (including 429 once the retries are exhausted)
+func executeBQL(returnSize int, query *bqlQuery) ([]byte, error) {
 
 	// If a size needs to be added to the URL, define it here
 	var returnSizeAppend string
@@ -3281,46 +5854,201 @@ func executeBQL(returnSize int, bqlToExecute string) []byte {
 	}
 
 	// Define the URL
-	url := fmt.Sprintf("https://api.botify.com/v1/projects/%s/%s/query%s", organization, project, returnSizeAppend)
+	url := fmt.Sprintf(botifyAPIURL+"/v1/projects/%s/%s/query%s", organization, project, returnSizeAppend)
 
 	// Define the body
-	httpBody := []byte(bqlToExecute)
+	httpBody, err := json.Marshal(query)
+	if err != nil {
+		fmt.Println(red+"Error. executeBQL. Cannot marshal the BQL:"+reset, err)
+		return nil, err
+	}
 
-	// Create the POST request
-	req, errorCheck := http.NewRequest("POST", url, bytes.NewBuffer(httpBody))
-	if errorCheck != nil {
-		fmt.Println(red+"Error. executeBQL. Cannot create request. Perhaps the provided credentials are invalid: "+reset, errorCheck)
+	// Use the cached response unless a refresh has been requested
+	cacheFile := bqlCacheFile(returnSize, httpBody)
+	if !forceRefresh {
+		if responseData, ok := readBQLCache(cacheFile, bqlCacheTTL(query)); ok {
+			return responseData, nil
+		}
 	}
 
-	// Define the headers
-	req.Header.Add("accept", "application/json")
-	req.Header.Add("Authorization", "token "+envBotifyAPIToken)
-	req.Header.Add("Content-Type", "application/json")
+	// Wait for a free slot. No more than maxConcurrentQueries are executed at the same time
+	bqlSlots <- struct{}{}
+	defer func() { <-bqlSlots }()
 
 	// Create HTTP client and execute the request
 	client := &http.Client{
 		Timeout: 60 * time.Second,
 	}
 
-	resp, err := client.Do(req)
-	if err != nil {
-		fmt.Println(red+"Error. executeBQL.  Cannot create the HTTP client:", errorCheck)
-	}
+	for attempt := 0; ; attempt++ {
 
-	defer func() {
+		// Wait if the Botify API rate limit has been reached
+		waitForRateLimit()
+
+		// Create the POST request
+		req, errorCheck := http.NewRequest("POST", url, bytes.NewBuffer(httpBody))
+		if errorCheck != nil {
+			fmt.Println(red+"Error. executeBQL. Cannot create request. Perhaps the provided credentials are invalid: "+reset, errorCheck)
+			return nil, errorCheck
+		}
+
+		// Define the headers
+		req.Header.Add("accept", "application/json")
+		req.Header.Add("Authorization", "token "+envBotifyAPIToken)
+		req.Header.Add("Content-Type", "application/json")
+
+		resp, err := client.Do(req)
+		if err != nil {
+			fmt.Println(red+"Error. executeBQL. Cannot execute the request:"+reset, err)
+			return nil, err
+		}
+
+		// Read the response body
+		responseData, err := io.ReadAll(resp.Body)
 		if err := resp.Body.Close(); err != nil {
-			fmt.Println(red+"Error. executeBQL. Failed to close response body: %v\n"+reset, err)
+			fmt.Println(red+"Error. executeBQL. Failed to close response body:"+reset, err)
+		}
+		if err != nil {
+			fmt.Println(red+"Error. executeBQL. Cannot read response body:"+reset, err)
+			return nil, err
 		}
-	}()
 
-	// Read the response body
-	responseData, err := io.ReadAll(resp.Body)
+		// Rate limited. Pause all queries & try again
+		if resp.StatusCode == http.StatusTooManyRequests && attempt < maxQueryRetries {
+			delay := retryDelay(resp.Header.Get("Retry-After"), attempt)
+			fmt.Printf(yellow+"Botify API rate limit reached. Retrying in %s (attempt %d of %d)\n"+reset, delay, attempt+1, maxQueryRetries)
+			pauseQueries(delay)
+			continue
+		}
+
+		// Errors, including the rate limit once the retries are exhausted
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return nil, fmt.Errorf("the Botify API returned %s %s", resp.Status, strings.TrimSpace(string(responseData[:min(len(responseData), 512)])))
+		}
+
+		// Cache successful responses. Empty results are not cached, the data may not be available yet
+		if resp.StatusCode == http.StatusOK && hasBQLResults(responseData) {
+			writeBQLCache(cacheFile, responseData)
+		}
+
+		// Return the response body as a byte slice
+		return responseData, nil
+	}
+}
+
+// The cache file for a query. Keyed by the organisation/project & a hash of the query
+func bqlCacheFile(returnSize int, httpBody []byte) string {
+
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%s/%s?size=%d\n%s", organization, project, returnSize, httpBody)))
+	safeName := strings.NewReplacer("/", "_", "\\", "_", "..", "_")
+
+	return filepath.Join(envInsightsFolder, "bqlCache", safeName.Replace(organization), safeName.Replace(project), hex.EncodeToString(hash[:])+".json")
+}
+
+// How long a response is cached. Periods which ended more than cacheClosedAfterDays days ago are closed & will not change
+func bqlCacheTTL(query *bqlQuery) time.Duration {
+
+	currentYear, currentMonth, currentDay := time.Now().Date()
+	closedBefore := time.Date(currentYear, currentMonth, currentDay, 0, 0, 0, 0, time.UTC).AddDate(0, 0, -cacheClosedAfterDays)
+
+	for _, period := range query.Periods {
+		if len(period) < 2 {
+			return cacheTTLCurrent
+		}
+		endDate, err := time.Parse("20060102", period[1])
+		if err != nil || !endDate.Before(closedBefore) {
+			return cacheTTLCurrent
+		}
+	}
+
+	return cacheTTLClosed
+}
+
+// Does a response include at least one result
+func hasBQLResults(responseData []byte) bool {
+
+	var response bqlResponse
+	if err := json.Unmarshal(responseData, &response); err != nil {
+		return false
+	}
+
+	return len(response.Results) > 0
+}
+
+// Get a cached response if it has not expired
+func readBQLCache(cacheFile string, ttl time.Duration) ([]byte, bool) {
+
+	info, err := os.Stat(cacheFile)
+	if err != nil || time.Since(info.ModTime()) > ttl {
+		return nil, false
+	}
+
+	responseData, err := os.ReadFile(cacheFile)
+	if err != nil {
+		fmt.Println(red+"Error. readBQLCache. Cannot read the cached response:"+reset, err)
+		return nil, false
+	}
+
+	return responseData, true
+}
+
+// Cache a response. Written to a temporary file first so a partially written response is never read
+func writeBQLCache(cacheFile string, responseData []byte) {
+
+	if err := os.MkdirAll(filepath.Dir(cacheFile), 0755); err != nil {
+		fmt.Println(red+"Error. writeBQLCache. Cannot create the cache folder:"+reset, err)
+		return
+	}
+
+	tempFile, err := os.CreateTemp(filepath.Dir(cacheFile), "bql*.tmp")
+	if err != nil {
+		fmt.Println(red+"Error. writeBQLCache. Cannot create the cache file:"+reset, err)
+		return
+	}
+
+	_, err = tempFile.Write(responseData)
+	if closeErr := tempFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tempFile.Name(), cacheFile)
+	}
 	if err != nil {
-		fmt.Println(red+"Error. executeBQL. Cannot read response body:", err)
+		fmt.Println(red+"Error. writeBQLCache. Cannot write the cache file:"+reset, err)
+		_ = os.Remove(tempFile.Name())
+	}
+}
+
+// Wait until the rate limit pause (if any) has passed
+func waitForRateLimit() {
+
+	bqlRetryMutex.Lock()
+	wait := time.Until(bqlRetryAfter)
+	bqlRetryMutex.Unlock()
+
+	if wait > 0 {
+		time.Sleep(wait)
+	}
+}
+
+// Pause all queries for the specified delay
+func pauseQueries(delay time.Duration) {
+
+	bqlRetryMutex.Lock()
+	defer bqlRetryMutex.Unlock()
+
+	if resumeAt := time.Now().Add(delay); resumeAt.After(bqlRetryAfter) {
+		bqlRetryAfter = resumeAt
 	}
+}
+
+// The delay before retrying. Retry-After (in seconds) if provided by the API, otherwise doubled on every attempt
+func retryDelay(retryAfter string, attempt int) time.Duration {
 
-	// Return the response body as a byte slice
-	return responseData
+	if seconds, err := strconv.Atoi(strings.TrimSpace(retryAfter)); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return queryRetryDelay * time.Duration(1<<attempt)
 }
 
 // Compute the CMGR
@@ -3363,7 +6091,8 @@ func calculateCMGR(sessionID string) {
 	}
 	cmgrOrderValueValue := computeCMGR(seoOrdersValueFloat, "Order value")
 
-	fmt.Printf("\n" + yellow + sessionID + reset + " Compound Monthly Growth Rate\n" + reset)
+	_, growthRateFullName := growthRateName()
+	fmt.Printf("\n" + yellow + sessionID + reset + " " + growthRateFullName + "\n" + reset)
 	fmt.Printf("Revenue: %.2f\n", cmgrRevenue)
 	fmt.Printf("Visits: %.2f\n", cmgrVisits)
 	fmt.Printf("Visit value: %.2f\n", cmgrVisitValue)
@@ -3381,7 +6110,8 @@ func computeCMGR(values []float64, calculatedKPIName string) float64 {
 
 	// The final period value is not included as it is not a full month
 	finalValue := values[len(values)-1]
-	numberOfPeriods := float64(noOfMonths)
+	// The growth is compounded between the first & the last period. n values span n-1 periods
+	numberOfPeriods := float64(len(values) - 1)
 
 	// CMGR formula: (finalValue / initialValue) ^ (1 / numberOfPeriods) - 1
 	cmgr := math.Pow(finalValue/initialValue, 1/numberOfPeriods) - 1
@@ -3400,7 +6130,7 @@ func computeCMGR(values []float64, calculatedKPIName string) float64 {
 func getAnalyticsID() (string, string) {
 
 	// First identify which analytics tool is integrated
-	urlAPIAnalyticsID := "https://api.botify.com/v1/projects/" + organization + "/" + project + "/collections"
+	urlAPIAnalyticsID := botifyAPIURL + "/v1/projects/" + organization + "/" + project + "/collections"
 	req, errorCheck := http.NewRequest("GET", urlAPIAnalyticsID, nil)
 
 	// Define the headers
@@ -3466,6 +6196,20 @@ func calculateDateRanges(analyticsStartDate string) DateRanges {
 		return DateRanges{}
 	}
 
+	// Weekly or daily insights
+	if activePeriod.Granularity == "weekly" || activePeriod.Granularity == "daily" {
+		dateRanges := subMonthlyDateRanges(startTime)
+		return DateRanges{Ranges: dateRanges, Granularity: activePeriod.Granularity}
+	}
+
+	// A reporting period has been chosen
+	if activePeriod.StartMonth != "" {
+		startMonth, _ := time.Parse("2006-01", activePeriod.StartMonth)
+		endMonth, _ := time.Parse("2006-01", activePeriod.EndMonth)
+		dateRanges := monthlyDateRanges(startMonth, endMonth, startTime)
+		return DateRanges{Ranges: dateRanges, Granularity: "monthly"}
+	}
+
 	// Get the current year and month
 	currentYear, currentMonth, _ := time.Now().Date()
 
@@ -3516,7 +6260,7 @@ func calculateDateRanges(analyticsStartDate string) DateRanges {
 	}
 
 	// Return the date range slice
-	return DateRanges{MonthlyRanges: dateRanges}
+	return DateRanges{Ranges: dateRanges, Granularity: "monthly"}
 }
 
 func isLastDayOfMonth(date time.Time) bool {
@@ -3525,8 +6269,10 @@ func isLastDayOfMonth(date time.Time) bool {
 }
 
 // DateRanges struct is used to store the date ranges for use in the BQL when the SEO KPIs are acquired
+// The ranges are months, ISO weeks or days depending on the granularity
 type DateRanges struct {
-	MonthlyRanges [][2]time.Time
+	Ranges      [][2]time.Time
+	Granularity string
 }
 
 // Function to calculate the number of months between two dates
@@ -3699,7 +6445,7 @@ func generateSessionID(length int) (string, error) {
 // Get the currency used
 func getCurrencyCompany() string {
 
-	url := fmt.Sprintf("https://api.botify.com/v1/analyses/%s/%s?page=1&only_success=true", organization, project)
+	url := fmt.Sprintf(botifyAPIURL+"/v1/analyses/%s/%s?page=1&only_success=true", organization, project)
 
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
@@ -3803,6 +6549,98 @@ func createInsightsCacheFolder(cacheFolder string) {
 	}
 }
 
+// Get the query concurrency, rate limit & cache settings from the .ini file
+func getQuerySettings() {
+
+	// Load the INI file
+	cfg, err := ini.Load("seoBusinessInsights.ini")
+	if err != nil {
+		fmt.Printf(red+"Error. getQuerySettings. Failed to read seoBusinessInsights.ini file: %v"+reset, err)
+		return
+	}
+
+	if cfg.Section("").HasKey("maxConcurrentQueries") {
+		value, err := cfg.Section("").Key("maxConcurrentQueries").Int()
+		if err != nil || value < 1 {
+			fmt.Println(yellow + "Warning: 'maxConcurrentQueries' is invalid. Will default to " + strconv.Itoa(maxConcurrentQueries) + "." + reset)
+		} else {
+			maxConcurrentQueries = value
+			bqlSlots = make(chan struct{}, maxConcurrentQueries)
+		}
+	}
+
+	if cfg.Section("").HasKey("maxQueryRetries") {
+		value, err := cfg.Section("").Key("maxQueryRetries").Int()
+		if err != nil || value < 0 {
+			fmt.Println(yellow + "Warning: 'maxQueryRetries' is invalid. Will default to " + strconv.Itoa(maxQueryRetries) + "." + reset)
+		} else {
+			maxQueryRetries = value
+		}
+	}
+
+	if cfg.Section("").HasKey("cacheTTLClosedPeriods") {
+		value, err := cfg.Section("").Key("cacheTTLClosedPeriods").Duration()
+		if err != nil || value < 0 {
+			fmt.Println(yellow + "Warning: 'cacheTTLClosedPeriods' is invalid. Will default to " + cacheTTLClosed.String() + "." + reset)
+		} else {
+			cacheTTLClosed = value
+		}
+	}
+
+	if cfg.Section("").HasKey("cacheTTLCurrentPeriod") {
+		value, err := cfg.Section("").Key("cacheTTLCurrentPeriod").Duration()
+		if err != nil || value < 0 {
+			fmt.Println(yellow + "Warning: 'cacheTTLCurrentPeriod' is invalid. Will default to " + cacheTTLCurrent.String() + "." + reset)
+		} else {
+			cacheTTLCurrent = value
+		}
+	}
+
+	if cfg.Section("").HasKey("cacheClosedAfterDays") {
+		value, err := cfg.Section("").Key("cacheClosedAfterDays").Int()
+		if err != nil || value < 0 {
+			fmt.Println(yellow + "Warning: 'cacheClosedAfterDays' is invalid. Will default to " + strconv.Itoa(cacheClosedAfterDays) + "." + reset)
+		} else {
+			cacheClosedAfterDays = value
+		}
+	}
+
+	if cfg.Section("").HasKey("opportunityKeywords") {
+		value, err := cfg.Section("").Key("opportunityKeywords").Int()
+		if err != nil || value < 1 || value > 2000 {
+			fmt.Println(yellow + "Warning: 'opportunityKeywords' must be between 1 and 2000. Will default to " + strconv.Itoa(scenarioKeywordsLimit) + "." + reset)
+		} else {
+			scenarioKeywordsLimit = value
+		}
+	}
+
+	if cfg.Section("").HasKey("moversKeywords") {
+		value, err := cfg.Section("").Key("moversKeywords").Int()
+		if err != nil || value < 1 || value > 2000 {
+			fmt.Println(yellow + "Warning: 'moversKeywords' must be between 1 and 2000. Will default to " + strconv.Itoa(moversKeywords) + "." + reset)
+		} else {
+			moversKeywords = value
+		}
+	}
+
+	if cfg.Section("").HasKey("forecastPeriods") {
+		value, err := cfg.Section("").Key("forecastPeriods").Int()
+		if err != nil || value < 3 || value > 12 {
+			fmt.Println(yellow + "Warning: 'forecastPeriods' must be between 3 and 12. Will default to " + strconv.Itoa(forecastPeriods) + "." + reset)
+		} else {
+			forecastPeriods = value
+		}
+	}
+
+	// Point to a local stand-in of the Botify API
+	if envBotifyAPIURL := os.Getenv("envBotifyAPIURL"); envBotifyAPIURL != "" {
+		botifyAPIURL = strings.TrimSuffix(envBotifyAPIURL, "/")
+		fmt.Println(yellow + "Botify API URL: " + botifyAPIURL + reset)
+	}
+
+	fmt.Printf(green+"Concurrent queries: %d\n"+reset, maxConcurrentQueries)
+}
+
 func getHostnamePort() {
 
 	// Load the INI file
@@ -3938,6 +6776,12 @@ func startup() {
 	// Get the hostname and port
 	getHostnamePort()
 
+	// Get the query concurrency, rate limit & cache settings
+	getQuerySettings()
+
+	// Get the default reporting period from the command line
+	getPeriodFlags()
+
 	fmt.Println(green + "\n... waiting for requests\n" + reset)
 }
 
@@ -0,0 +1,467 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Point the BQL queries at a stand-in of the Botify API. The cache is written to a temporary folder
+func useBotifyStandIn(t *testing.T, handler http.HandlerFunc) {
+
+	t.Helper()
+	botify := httptest.NewServer(handler)
+
+	savedURL, savedFolder, savedRefresh, savedOrganization, savedProject := botifyAPIURL, envInsightsFolder, forceRefresh, organization, project
+	t.Cleanup(func() {
+		botify.Close()
+		botifyAPIURL, envInsightsFolder, forceRefresh, organization, project = savedURL, savedFolder, savedRefresh, savedOrganization, savedProject
+		bqlRetryMutex.Lock()
+		bqlRetryAfter = time.Time{}
+		bqlRetryMutex.Unlock()
+	})
+
+	botifyAPIURL = botify.URL
+	envInsightsFolder = t.TempDir()
+	forceRefresh = false
+	organization = "org"
+	project = "proj"
+}
+
+// The monthly queries are executed concurrently. The insights are returned in the order of the months, whatever order the responses arrive in
+func TestFetchMonthlyInsightsOrder(t *testing.T) {
+
+	var inFlight, maxInFlight int32
+	useBotifyStandIn(t, func(w http.ResponseWriter, r *http.Request) {
+		running := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			highest := atomic.LoadInt32(&maxInFlight)
+			if running <= highest || atomic.CompareAndSwapInt32(&maxInFlight, highest, running) {
+				break
+			}
+		}
+
+		var query bqlQuery
+		if err := json.NewDecoder(r.Body).Decode(&query); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// Every metric of a month is its month number. The first months are answered last
+		month, err := strconv.Atoi(query.Periods[0][0][4:6])
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		time.Sleep(time.Duration(13-month) * 5 * time.Millisecond)
+
+		metrics := make([]float64, len(query.Query.Metrics))
+		for i := range metrics {
+			metrics[i] = float64(month)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"results": []map[string]interface{}{{"dimensions": []interface{}{}, "metrics": metrics}},
+		})
+	})
+
+	var startMonthDates, endMonthDates []string
+	for month := 1; month <= 12; month++ {
+		startMonthDates = append(startMonthDates, fmt.Sprintf("2023%02d01", month))
+		endMonthDates = append(endMonthDates, fmt.Sprintf("2023%02d28", month))
+	}
+
+	insights, _ := fetchMonthlyInsights("visits.dip", startMonthDates, endMonthDates, false)
+
+	if len(insights) != 12 {
+		t.Fatalf("months = %d, want 12", len(insights))
+	}
+	for i, month := range insights {
+		if month.RevenueStatus != "success" || month.SearchStatus != "success" {
+			t.Fatalf("month %d: revenue status %q, search status %q", i+1, month.RevenueStatus, month.SearchStatus)
+		}
+		if month.Revenue != i+1 || month.Visits != i+1 || month.SearchConsole.Clicks != i+1 {
+			t.Errorf("month %d: revenue %d, visits %d, clicks %d, want %d", i+1, month.Revenue, month.Visits, month.SearchConsole.Clicks, i+1)
+		}
+	}
+	if maxInFlight > int32(maxConcurrentQueries) {
+		t.Errorf("%d queries executed at the same time, want at most %d", maxInFlight, maxConcurrentQueries)
+	}
+}
+
+// A rate limited query (HTTP 429) is retried after the delay given in Retry-After
+func TestExecuteBQLRetryAfter(t *testing.T) {
+
+	var requests int32
+	var mutex sync.Mutex
+	var requestTimes []time.Time
+	useBotifyStandIn(t, func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		requestTimes = append(requestTimes, time.Now())
+		mutex.Unlock()
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, "rate limit reached", http.StatusTooManyRequests)
+			return
+		}
+		_, _ = w.Write([]byte(`{"results":[{"dimensions":[],"metrics":[42]}]}`))
+	})
+
+	// The doubled delay used without Retry-After is much longer than the delay requested by the API
+	savedRetryDelay := queryRetryDelay
+	defer func() { queryRetryDelay = savedRetryDelay }()
+	queryRetryDelay = 5 * time.Second
+
+	rows, err := runBQL(0, newBQLQuery("20230101", "20230131", "visits.dip").metrics(bqlField("visits.dip", 0, "nb")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || rows[0].Metrics["visits.dip.period_0.nb"] != 42 {
+		t.Errorf("rows = %v, want one row with 42 visits", rows)
+	}
+	if requests != 2 {
+		t.Fatalf("requests = %d, want 2", requests)
+	}
+	if wait := requestTimes[1].Sub(requestTimes[0]); wait < time.Second || wait > 3*time.Second {
+		t.Errorf("retried after %s, want the 1s requested by Retry-After", wait)
+	}
+}
+
+// A 429 returned after all retries is an error
+func TestExecuteBQLRetriesExhausted(t *testing.T) {
+
+	var requests int32
+	useBotifyStandIn(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Retry-After", "0")
+		http.Error(w, "rate limit reached", http.StatusTooManyRequests)
+	})
+
+	savedRetries := maxQueryRetries
+	defer func() { maxQueryRetries = savedRetries }()
+	maxQueryRetries = 2
+
+	if _, err := executeBQL(0, newBQLQuery("20230101", "20230131", "visits.dip").metrics(bqlField("visits.dip", 0, "nb"))); err == nil {
+		t.Error("no error returned once the retries are exhausted")
+	}
+	if requests != 3 {
+		t.Errorf("requests = %d, want 3 (the query & 2 retries)", requests)
+	}
+}
+
+// The delay before retrying. Retry-After when provided, otherwise doubled on every attempt
+func TestRetryDelay(t *testing.T) {
+
+	savedRetryDelay := queryRetryDelay
+	defer func() { queryRetryDelay = savedRetryDelay }()
+	queryRetryDelay = 2 * time.Second
+
+	tests := []struct {
+		retryAfter string
+		attempt    int
+		want       time.Duration
+	}{
+		{"3", 0, 3 * time.Second},
+		{" 10 ", 4, 10 * time.Second},
+		{"0", 2, 0},
+		{"", 0, 2 * time.Second},
+		{"", 2, 8 * time.Second},
+		{"Wed, 21 Oct 2015 07:28:00 GMT", 1, 4 * time.Second},
+		{"-1", 0, 2 * time.Second},
+	}
+
+	for _, test := range tests {
+		if got := retryDelay(test.retryAfter, test.attempt); got != test.want {
+			t.Errorf("retryDelay(%q, %d) = %s, want %s", test.retryAfter, test.attempt, got, test.want)
+		}
+	}
+}
+
+// Closed periods are cached for cacheTTLClosed, periods which ended in the last cacheClosedAfterDays days for cacheTTLCurrent
+func TestBQLCacheTTL(t *testing.T) {
+
+	savedClosedAfterDays := cacheClosedAfterDays
+	defer func() { cacheClosedAfterDays = savedClosedAfterDays }()
+	cacheClosedAfterDays = 7
+
+	currentYear, currentMonth, currentDay := time.Now().Date()
+	today := time.Date(currentYear, currentMonth, currentDay, 0, 0, 0, 0, time.UTC)
+	daysAgo := func(days int) string { return today.AddDate(0, 0, -days).Format("20060102") }
+
+	tests := []struct {
+		name    string
+		periods [][]string
+		want    time.Duration
+	}{
+		{"Closed period", [][]string{{daysAgo(60), daysAgo(30)}}, cacheTTLClosed},
+		{"Ended just over cacheClosedAfterDays ago", [][]string{{daysAgo(30), daysAgo(8)}}, cacheTTLClosed},
+		{"Ended cacheClosedAfterDays ago", [][]string{{daysAgo(30), daysAgo(7)}}, cacheTTLCurrent},
+		{"Current period", [][]string{{daysAgo(10), daysAgo(0)}}, cacheTTLCurrent},
+		{"Closed period compared with a current period", [][]string{{daysAgo(400), daysAgo(370)}, {daysAgo(5), daysAgo(1)}}, cacheTTLCurrent},
+		{"Closed period compared with last year", [][]string{{daysAgo(60), daysAgo(30)}, {daysAgo(425), daysAgo(395)}}, cacheTTLClosed},
+		{"Invalid end date", [][]string{{daysAgo(60), "unknown"}}, cacheTTLCurrent},
+	}
+
+	for _, test := range tests {
+		query := &bqlQuery{Periods: test.periods}
+		if got := bqlCacheTTL(query); got != test.want {
+			t.Errorf("%s: TTL = %s, want %s", test.name, got, test.want)
+		}
+	}
+}
+
+// The reporting & comparison months, the comparison type & the granularity are checked
+func TestReportingPeriodValidate(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		period  reportingPeriod
+		wantErr bool
+	}{
+		{"Default period", reportingPeriod{}, false},
+		{"No comparison", reportingPeriod{StartMonth: "2024-01", EndMonth: "2024-06", Comparison: "none"}, false},
+		{"Previous period", reportingPeriod{StartMonth: "2024-01", EndMonth: "2024-06", Comparison: "previous"}, false},
+		{"Last year", reportingPeriod{Comparison: "lastYear", Granularity: "weekly"}, false},
+		{"Custom period", reportingPeriod{StartMonth: "2024-01", EndMonth: "2024-06", Comparison: "custom", CompareStartMonth: "2023-01", CompareEndMonth: "2023-03"}, false},
+		{"Single month", reportingPeriod{StartMonth: "2024-01", EndMonth: "2024-01", Granularity: "daily"}, false},
+		{"Start month only", reportingPeriod{StartMonth: "2024-01"}, true},
+		{"End month only", reportingPeriod{EndMonth: "2024-01"}, true},
+		{"Invalid month", reportingPeriod{StartMonth: "2024-13", EndMonth: "2024-14"}, true},
+		{"Invalid format", reportingPeriod{StartMonth: "01/2024", EndMonth: "06/2024"}, true},
+		{"End before start", reportingPeriod{StartMonth: "2024-06", EndMonth: "2024-01"}, true},
+		{"Custom period without months", reportingPeriod{Comparison: "custom"}, true},
+		{"Custom end before start", reportingPeriod{Comparison: "custom", CompareStartMonth: "2023-06", CompareEndMonth: "2023-01"}, true},
+		{"Unknown comparison", reportingPeriod{Comparison: "lastMonth"}, true},
+		{"Unknown granularity", reportingPeriod{Granularity: "yearly"}, true},
+	}
+
+	for _, test := range tests {
+		if err := test.period.validate(); (err != nil) != test.wantErr {
+			t.Errorf("%s: validate() = %v, want an error: %v", test.name, err, test.wantErr)
+		}
+	}
+}
+
+// The comparison periods are paired with the reporting periods. A shorter custom period is paired with the oldest periods only
+func TestComparisonMonths(t *testing.T) {
+
+	savedPeriod := activePeriod
+	defer func() { activePeriod = savedPeriod }()
+
+	months := []string{"20240115", "20240201", "20240301"}
+
+	tests := []struct {
+		name       string
+		period     reportingPeriod
+		dates      []string
+		wantStarts []string
+		wantEnds   []string
+		wantNames  []string
+	}{
+		{
+			name:       "Previous period",
+			period:     reportingPeriod{Comparison: "previous"},
+			dates:      months,
+			wantStarts: []string{"20231001", "20231101", "20231201"},
+			wantEnds:   []string{"20231031", "20231130", "20231231"},
+			wantNames:  []string{"October 2023", "November 2023", "December 2023"},
+		},
+		{
+			name:       "Same period last year",
+			period:     reportingPeriod{Comparison: "lastYear"},
+			dates:      months,
+			wantStarts: []string{"20230101", "20230201", "20230301"},
+			wantEnds:   []string{"20230131", "20230228", "20230331"},
+			wantNames:  []string{"January 2023", "February 2023", "March 2023"},
+		},
+		{
+			name:       "Custom period shorter than the reporting period",
+			period:     reportingPeriod{Comparison: "custom", CompareStartMonth: "2022-06", CompareEndMonth: "2022-07"},
+			dates:      months,
+			wantStarts: []string{"20220601", "20220701"},
+			wantEnds:   []string{"20220630", "20220731"},
+			wantNames:  []string{"June 2022", "July 2022"},
+		},
+		{
+			name:       "Previous days",
+			period:     reportingPeriod{Comparison: "previous", Granularity: "daily"},
+			dates:      []string{"20240301", "20240302"},
+			wantStarts: []string{"20240228", "20240229"},
+			wantEnds:   []string{"20240228", "20240229"},
+			wantNames:  []string{"Wed 28 Feb 2024", "Thu 29 Feb 2024"},
+		},
+		{
+			name:   "No comparison",
+			period: reportingPeriod{Comparison: "none"},
+			dates:  months,
+		},
+	}
+
+	for _, test := range tests {
+		activePeriod = test.period
+		starts, ends, names := comparisonMonths(test.period, test.dates)
+		if fmt.Sprint(starts) != fmt.Sprint(test.wantStarts) || fmt.Sprint(ends) != fmt.Sprint(test.wantEnds) || fmt.Sprint(names) != fmt.Sprint(test.wantNames) {
+			t.Errorf("%s: comparisonMonths() = %v %v %v, want %v %v %v", test.name, starts, ends, names, test.wantStarts, test.wantEnds, test.wantNames)
+		}
+	}
+}
+
+// The growth rate is compounded over the number of periods between the first & the last value
+func TestComputeCMGR(t *testing.T) {
+
+	tests := []struct {
+		name   string
+		values []float64
+		want   float64
+	}{
+		{"Doubled over one period", []float64{100, 200}, 1},
+		{"Doubled over 12 monthly values", []float64{100, 110, 120, 130, 140, 150, 160, 170, 180, 190, 195, 200}, math.Pow(2, 1.0/11) - 1},
+		{"Flat", []float64{50, 80, 50}, 0},
+		{"Single value", []float64{100}, 0},
+	}
+
+	for _, test := range tests {
+		if got := computeCMGR(test.values, test.name); math.Abs(got-test.want) > 1e-9 {
+			t.Errorf("%s: computeCMGR() = %f, want %f", test.name, got, test.want)
+		}
+	}
+}
+
+// A seasonal series (trend & yearly seasonality) is forecast with Holt-Winters. The forecast follows the series & lies within its intervals
+func TestRunScenario(t *testing.T) {
+
+	savedKeywords, savedA, savedB := scenarioKeywords, ctrCurveA, ctrCurveB
+	savedVisits, savedOrders, savedVisitValue := metricsVisitsOrganic, metricsOrdersOrganic, totalAverageVisitValue
+	defer func() {
+		scenarioKeywords, ctrCurveA, ctrCurveB = savedKeywords, savedA, savedB
+		metricsVisitsOrganic, metricsOrdersOrganic, totalAverageVisitValue = savedVisits, savedOrders, savedVisitValue
+	}()
+
+	// CTR = 0.3 / position
+	ctrCurveA, ctrCurveB = 0.3, -1
+	metricsVisitsOrganic, metricsOrdersOrganic, totalAverageVisitValue = 100, 2, 1.5
+	scenarioKeywords = []scenarioKeyword{
+		{Keyword: "top", Impressions: 1000, Clicks: 300, AvgPosition: 1},
+		{Keyword: "no clicks", Impressions: 600, Clicks: 0, AvgPosition: 6},
+		{Keyword: "deep", Impressions: 1500, Clicks: 0, AvgPosition: 15},
+	}
+
+	tests := []struct {
+		name           string
+		positionGain   int
+		impressionGain float64
+		wantClicks     float64
+	}{
+		// 1000 x 0.3 + 600 x 0.05 + 1500 x 0.02
+		{"Current performance", 0, 0, 360},
+		// Only the keyword in positions 4-10 moves, from 6 to 3
+		{"Positions up by 3", 3, 0, 300 + 600*0.1 + 30},
+		{"Impressions +50%", 0, 50, 360 * 1.5},
+		{"Both", 3, 50, (300 + 600*0.1 + 30) * 1.5},
+	}
+
+	for _, test := range tests {
+		scenario := runScenario(test.name, test.positionGain, test.impressionGain)
+		if math.Abs(scenario.Clicks-test.wantClicks) > 1e-9 {
+			t.Errorf("%s: clicks = %f, want %f", test.name, scenario.Clicks, test.wantClicks)
+		}
+		if scenario.Visits != scenario.Clicks {
+			t.Errorf("%s: visits = %f, want the clicks %f", test.name, scenario.Visits, scenario.Clicks)
+		}
+		if math.Abs(scenario.Orders-scenario.Clicks*0.02) > 1e-9 || math.Abs(scenario.Revenue-scenario.Clicks*1.5) > 1e-9 {
+			t.Errorf("%s: orders = %f & revenue = %f, want %f & %f", test.name, scenario.Orders, scenario.Revenue, scenario.Clicks*0.02, scenario.Clicks*1.5)
+		}
+	}
+}
+
+func TestHoltWintersForecast(t *testing.T) {
+
+	series := func(t int) float64 { return 1000 + 10*float64(t) + 200*math.Sin(2*math.Pi*float64(t)/12) }
+
+	// Three years of monthly values with a small deterministic noise
+	var values []float64
+	for i := 0; i < 36; i++ {
+		values = append(values, series(i)+float64((i*7)%5-2)*5)
+	}
+
+	forecast := holtWintersForecast(values, 12, 12)
+
+	if forecast.Method != "Holt-Winters" {
+		t.Fatalf("method = %q, want Holt-Winters", forecast.Method)
+	}
+	if len(forecast.Forecast) != 12 {
+		t.Fatalf("forecast periods = %d, want 12", len(forecast.Forecast))
+	}
+	for h, point := range forecast.Forecast {
+		want := series(36 + h)
+		if math.Abs(point-want) > 0.05*want {
+			t.Errorf("period %d: forecast %.0f, want about %.0f", h+1, point, want)
+		}
+		if !(forecast.Lower95[h] <= forecast.Lower80[h] && forecast.Lower80[h] <= point && point <= forecast.Upper80[h] && forecast.Upper80[h] <= forecast.Upper95[h]) {
+			t.Errorf("period %d: intervals not nested: 95%% %.0f-%.0f, 80%% %.0f-%.0f, forecast %.0f", h+1, forecast.Lower95[h], forecast.Upper95[h], forecast.Lower80[h], forecast.Upper80[h], point)
+		}
+		if forecast.Upper95[h] == forecast.Lower95[h] {
+			t.Errorf("period %d: the 95%% interval is empty", h+1)
+		}
+	}
+	// The intervals widen with the horizon
+	if forecast.Upper95[11]-forecast.Lower95[11] <= forecast.Upper95[0]-forecast.Lower95[0] {
+		t.Errorf("the 95%% interval does not widen: %.0f then %.0f", forecast.Upper95[0]-forecast.Lower95[0], forecast.Upper95[11]-forecast.Lower95[11])
+	}
+}
+
+// Holt's linear trend is used with less than two seasons. The errors start at the third value, the first two initialise the model
+func TestHoltLinearTrend(t *testing.T) {
+
+	values := []float64{100, 120, 135, 160, 170, 195, 205, 230}
+
+	sse, errorCount, _, _, _ := holtWintersFit(values, 0, 0.5, 0.5, 0)
+	if errorCount != len(values)-2 {
+		t.Errorf("errors = %d, want %d", errorCount, len(values)-2)
+	}
+	if sse <= 0 {
+		t.Errorf("sse = %f, want more than 0", sse)
+	}
+
+	forecast := holtWintersForecast(values, 12, 3)
+	if forecast.Method != "Holt's linear trend" {
+		t.Fatalf("method = %q, want Holt's linear trend", forecast.Method)
+	}
+	if forecast.Forecast[0] <= values[len(values)-1] || forecast.Forecast[2] <= forecast.Forecast[0] {
+		t.Errorf("forecast %v does not follow the upward trend", forecast.Forecast)
+	}
+	if forecast.Upper80[0] <= forecast.Forecast[0] {
+		t.Errorf("the 80%% interval is empty")
+	}
+}
+
+// A placeholder is saved for each seasonal forecast chart when there is not enough history
+func TestLineSeasonalForecastPlaceholder(t *testing.T) {
+
+	savedFolder, savedForecasts := insightsCacheFolder, seasonalForecasts
+	defer func() { insightsCacheFolder, seasonalForecasts = savedFolder, savedForecasts }()
+	insightsCacheFolder = t.TempDir()
+	seasonalForecasts = nil
+
+	lineSeasonalForecast()
+
+	for _, kpi := range []string{"Revenue", "Visits", "Orders"} {
+		content, err := os.ReadFile(insightsCacheFolder + "/go_seo_SeasonalForecast" + kpi + ".html")
+		if err != nil {
+			t.Errorf("%s: %v", kpi, err)
+			continue
+		}
+		if !strings.Contains(string(content), "Not enough history") {
+			t.Errorf("%s: the placeholder does not explain why there is no forecast", kpi)
+		}
+	}
+}